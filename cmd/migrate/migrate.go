@@ -0,0 +1,80 @@
+// Package migrate implements the migrate subcommand, which migrates the schema of the database.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/schema"
+)
+
+// Usage describes the arguments of the migrate subcommand.
+const Usage = `usage: migrate <command>
+
+commands:
+  up            apply all pending migrations
+  down          revert the last applied migration
+  to <version>  apply or revert migrations until the schema is at version (0 reverts all)
+  status        list the migrations and whether they have been applied`
+
+// ErrUsage is returned by Run when its arguments are invalid.
+var ErrUsage = errors.New("Invalid arguments for migrate")
+
+// Run runs the migrate subcommand with args (the arguments after "migrate") using migrator.
+// The status of the migrations is written to w once they have been migrated.
+func Run(ctx context.Context, migrator *schema.Migrator, args []string, w io.Writer) error {
+	if migrator == nil {
+		return errors.New("The storage engine has no schema to migrate")
+	}
+	if len(args) == 0 {
+		return ErrUsage
+	}
+
+	var err error
+	switch args[0] {
+	case "up":
+		err = migrator.Up(ctx)
+	case "down":
+		err = migrator.Down(ctx)
+	case "to":
+		if len(args) != 2 {
+			return ErrUsage
+		}
+		var version int
+		version, err = strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("%w: invalid version %q", ErrUsage, args[1])
+		}
+		err = migrator.To(ctx, version)
+	case "status":
+	default:
+		return ErrUsage
+	}
+	if err != nil {
+		return err
+	}
+	return writeStatus(ctx, migrator, w)
+}
+
+// writeStatus writes a table of the migrations and when they were applied to w.
+func writeStatus(ctx context.Context, migrator *schema.Migrator, w io.Writer) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+	return tw.Flush()
+}
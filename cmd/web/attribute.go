@@ -0,0 +1,116 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+// Returns the status code and error messages of a failed tag or attribute storage call, where action describes
+// what failed and key is the log key of the error.
+func attributeError(err error, action, key string) (int, []string) {
+	var notFoundErr *storage.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return http.StatusNotFound, []string{"Product not found", key, notFoundErr.Error()}
+	}
+	return statusFromError(err), []string{"Failed to " + action, key, err.Error()}
+}
+
+//	@Summary		Set the tags of a product
+//	@Description	Replaces the tags of a product. Tags are trimmed and lowercased, and duplicates are removed.
+//	@ID				set-product-tags
+//	@Tags			products
+//	@Accept			json
+//	@Param			id		path	int						true	"Product ID"
+//	@Param			tags	body	models.SetTagsRequest	true	"Tags"
+//	@Success		204
+//	@Failure		400	{object}	errorResponse	"Invalid parameter or tag"
+//	@Failure		404	{object}	errorResponse	"Product not found"
+//	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Failure		503	{object}	errorResponse	"Request cancelled"
+//	@Failure		504	{object}	errorResponse	"Request timed out"
+//	@Router			/products/{id}/tags [put]
+func handleSetProductTags(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			messages := []string{"Invalid parameter 'id'", "atoi_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		var req models.SetTagsRequest
+		err = parseJSONBody(r, &req)
+		if err != nil {
+			messages := []string{"Failed to parse JSON payload", "parse_json_body_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+		if err = req.Validate(); err != nil {
+			messages := []string{"Invalid tags", "validation_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		err = srv.Storage().SetProductTags(r.Context(), id, req.Tags)
+		if err != nil {
+			status, messages := attributeError(err, "set product tags", "set_product_tags_error")
+			respondWithError(w, srv.Logger(), status, messages...)
+			return
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusNoContent, nil)
+	}
+}
+
+//	@Summary		Set the attributes of a product
+//	@Description	Replaces the attributes of a product. Each value must be a string, number or boolean.
+//	@Description	Names must have lowercase letters, digits, underscores and hyphens, so that they can be filtered by with 'attr.{name}'.
+//	@ID				set-product-attributes
+//	@Tags			products
+//	@Accept			json
+//	@Param			id			path	int							true	"Product ID"
+//	@Param			attributes	body	models.SetAttributesRequest	true	"Attributes"
+//	@Success		204
+//	@Failure		400	{object}	errorResponse	"Invalid parameter or attribute"
+//	@Failure		404	{object}	errorResponse	"Product not found"
+//	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Failure		503	{object}	errorResponse	"Request cancelled"
+//	@Failure		504	{object}	errorResponse	"Request timed out"
+//	@Router			/products/{id}/attributes [put]
+func handleSetProductAttributes(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			messages := []string{"Invalid parameter 'id'", "atoi_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		var req models.SetAttributesRequest
+		err = parseJSONBody(r, &req)
+		if err != nil {
+			messages := []string{"Failed to parse JSON payload", "parse_json_body_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+		if err = req.Validate(); err != nil {
+			messages := []string{"Invalid attributes", "validation_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		err = srv.Storage().SetProductAttributes(r.Context(), id, req.Attributes)
+		if err != nil {
+			status, messages := attributeError(err, "set product attributes", "set_product_attributes_error")
+			respondWithError(w, srv.Logger(), status, messages...)
+			return
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusNoContent, nil)
+	}
+}
@@ -0,0 +1,130 @@
+package web_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+)
+
+// Tests setting the tags and attributes of products and filtering by them.
+// Each step is run in order against the same two products.
+func TestServer_TagAndAttributeRoutes(t *testing.T) {
+	srv := newTestServer()
+	srv.MountHandlers()
+
+	for i := 0; i < 2; i++ {
+		_, err := srv.Storage().CreateProduct(context.Background(), &models.CreateProductRequest{
+			Name:  "Test Product",
+			Price: models.NewMoney(1000, models.DefaultCurrency),
+		})
+		if err != nil {
+			t.Fatal(fmt.Errorf("Error creating product: %w", err))
+		}
+	}
+
+	steps := []struct {
+		name               string
+		method             string
+		url                string
+		payload            any
+		expectedStatusCode int
+		// expectedProducts is the number of products in the response, if it is not negative.
+		expectedProducts int
+	}{
+		{
+			"tag", http.MethodPut, "/v1/api/products/1/tags", models.SetTagsRequest{Tags: []string{"Organic", "clearance"}},
+			http.StatusNoContent, -1,
+		},
+		{
+			"tag other", http.MethodPut, "/v1/api/products/2/tags", models.SetTagsRequest{Tags: []string{"organic"}},
+			http.StatusNoContent, -1,
+		},
+		{
+			"tag empty", http.MethodPut, "/v1/api/products/1/tags", models.SetTagsRequest{Tags: []string{" "}},
+			http.StatusBadRequest, -1,
+		},
+		{
+			"tag missing product", http.MethodPut, "/v1/api/products/9/tags", models.SetTagsRequest{Tags: []string{"organic"}},
+			http.StatusNotFound, -1,
+		},
+		{
+			"set attributes", http.MethodPut, "/v1/api/products/1/attributes",
+			models.SetAttributesRequest{Attributes: map[string]any{"material": "cotton", "weight_g": 250}},
+			http.StatusNoContent, -1,
+		},
+		{
+			"set invalid attribute name", http.MethodPut, "/v1/api/products/2/attributes",
+			models.SetAttributesRequest{Attributes: map[string]any{"Material": "wool"}},
+			http.StatusBadRequest, -1,
+		},
+		{
+			"set invalid attribute value", http.MethodPut, "/v1/api/products/2/attributes",
+			models.SetAttributesRequest{Attributes: map[string]any{"sizes": []string{"s", "m"}}},
+			http.StatusBadRequest, -1,
+		},
+		{
+			"filter by tag", http.MethodGet, "/v1/api/products?tag=organic", nil,
+			http.StatusOK, 2,
+		},
+		{
+			"filter by tags", http.MethodGet, "/v1/api/products?tag=ORGANIC&tag=clearance", nil,
+			http.StatusOK, 1,
+		},
+		{
+			"filter by attribute", http.MethodGet, "/v1/api/products?attr.material=cotton", nil,
+			http.StatusOK, 1,
+		},
+		{
+			"filter by number attribute", http.MethodGet, "/v1/api/products?attr.weight_g=250&tag=organic", nil,
+			http.StatusOK, 1,
+		},
+		{
+			"filter by unmatched attribute", http.MethodGet, "/v1/api/products?attr.material=wool", nil,
+			http.StatusOK, 0,
+		},
+		{
+			"filter by invalid attribute", http.MethodGet, "/v1/api/products?attr.Material=cotton", nil,
+			http.StatusBadRequest, -1,
+		},
+	}
+
+	for _, step := range steps {
+		body := new(bytes.Buffer)
+		if step.payload != nil {
+			if err := json.NewEncoder(body).Encode(step.payload); err != nil {
+				t.Fatal(fmt.Errorf("Error encoding JSON payload: %w", err))
+			}
+		}
+		req, err := http.NewRequest(step.method, step.url, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+
+		srv.Mux().ServeHTTP(rr, req)
+
+		checkEqual(t, rr.Code, step.expectedStatusCode, step.name+": Status Code")
+		if step.expectedProducts >= 0 {
+			var page struct {
+				Products []models.Product `json:"products"`
+			}
+			if err = json.NewDecoder(rr.Body).Decode(&page); err != nil {
+				t.Fatal(fmt.Errorf("Error decoding JSON response: %w", err))
+			}
+			checkEqual(t, len(page.Products), step.expectedProducts, step.name+": Products")
+		}
+	}
+
+	product, err := srv.Storage().GetProduct(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, product.Tags, []string{"clearance", "organic"}, "Tags")
+	checkEqual(t, product.Attributes, map[string]any{"material": "cotton", "weight_g": 250.0}, "Attributes")
+}
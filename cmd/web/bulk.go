@@ -0,0 +1,188 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+)
+
+const (
+	// The deadline given to bulk requests, which make many changes in a single transaction.
+	bulkDeadline = 30 * time.Second
+	// The largest number of operations in a bulk request.
+	maxBulkOperations = 1000
+)
+
+// errBulkRollback is returned from an atomic bulk transaction to roll it back after an operation fails.
+var errBulkRollback = errors.New("Bulk operation failed")
+
+// bulkResult is the result of a single operation of a bulk request.
+type bulkResult struct {
+	Op models.BulkOperationType `json:"op"`
+	// Status is the HTTP status code that the operation would have had as a single request.
+	Status int `json:"status"`
+	// ID is the product that was created, updated or deleted.
+	ID int `json:"id,omitempty"`
+	// Version is the version of the product after it was created or updated.
+	Version int    `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// bulkResponse is the results of the operations of a bulk request, in the order they were requested.
+type bulkResponse struct {
+	// Committed is whether the changes made by the successful operations were kept.
+	Committed bool         `json:"committed"`
+	Results   []bulkResult `json:"results"`
+}
+
+//	@Summary		Make many changes to products
+//	@Description	Creates, updates and deletes many products in a single transaction, and returns the result of each operation.
+//	@Description	In 'atomic' mode (the default) every operation is rolled back if any of them fails, and the response is a 409 Conflict.
+//	@Description	In 'best_effort' mode the successful operations are kept, and the response is a 207 Multi-Status if any of them failed.
+//	@Description	In either mode, an error that the request cannot resolve (eg. a database failure) rolls back every operation.
+//	@Description	Updates and deletes only apply to the 'version' of the product, if one is given.
+//	@ID				bulk-products
+//	@Tags			products
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.BulkRequest	true	"Operations"
+//	@Success		200		{object}	bulkResponse		"Every operation succeeded"
+//	@Success		207		{object}	bulkResponse		"Some best-effort operations failed"
+//	@Failure		400		{object}	errorResponse		"Invalid operations"
+//	@Failure		409		{object}	bulkResponse		"An atomic operation failed"
+//	@Failure		500		{object}	errorResponse		"Internal Server Error"
+//	@Failure		503		{object}	errorResponse		"Request cancelled"
+//	@Failure		504		{object}	errorResponse		"Request timed out"
+//	@Router			/products/bulk [post]
+func handleBulkProducts(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req models.BulkRequest
+		err := parseJSONBody(r, &req)
+		if err != nil {
+			messages := []string{"Failed to parse JSON payload", "parse_json_body_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+		if req.Mode == "" {
+			req.Mode = models.BulkAtomic
+		}
+		if err = validateBulkRequest(&req); err != nil {
+			messages := []string{"Invalid operations", "validation_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		var results []bulkResult
+		failed := -1
+		err = srv.Storage().WithTx(r.Context(), func(tx storage.Storage) error {
+			// The transaction may be retried, so the results of a previous attempt are discarded.
+			results = make([]bulkResult, 0, len(req.Operations))
+			failed = -1
+			for i := range req.Operations {
+				result, err := runBulkOperation(r.Context(), tx, &req.Operations[i])
+				if err != nil {
+					// The transaction may already have been rolled back (eg. after a deadlock), so the whole
+					// batch is retried or fails rather than the operation being reported on its own.
+					return err
+				}
+				results = append(results, result)
+				if result.Error == "" {
+					continue
+				}
+				if failed < 0 {
+					failed = i
+				}
+				if req.Mode == models.BulkAtomic {
+					return errBulkRollback
+				}
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errBulkRollback) {
+			messages := []string{"Failed to run bulk operations", "bulk_products_error", err.Error()}
+			respondWithError(w, srv.Logger(), statusFromError(err), messages...)
+			return
+		}
+
+		switch {
+		case failed < 0:
+			respondWithJSON(w, srv.Logger(), http.StatusOK, bulkResponse{Committed: true, Results: results})
+		case req.Mode == models.BulkBestEffort:
+			respondWithJSON(w, srv.Logger(), http.StatusMultiStatus, bulkResponse{Committed: true, Results: results})
+		default:
+			// Every other operation was rolled back, or never run.
+			rolledBack := make([]bulkResult, len(req.Operations))
+			for i, op := range req.Operations {
+				rolledBack[i] = bulkResult{
+					Op:     op.Op,
+					Status: http.StatusFailedDependency,
+					Error:  fmt.Sprintf("Rolled back because operation %d failed", failed),
+				}
+			}
+			rolledBack[failed] = results[failed]
+			respondWithJSON(w, srv.Logger(), http.StatusConflict, bulkResponse{Committed: false, Results: rolledBack})
+		}
+	}
+}
+
+// Returns an error describing the first invalid operation of req, or nil if they are all valid.
+func validateBulkRequest(req *models.BulkRequest) error {
+	if req.Mode != models.BulkAtomic && req.Mode != models.BulkBestEffort {
+		return fmt.Errorf("mode must be %q or %q", models.BulkAtomic, models.BulkBestEffort)
+	}
+	if len(req.Operations) == 0 || len(req.Operations) > maxBulkOperations {
+		return fmt.Errorf("there must be between 1 and %d operations", maxBulkOperations)
+	}
+	for i, op := range req.Operations {
+		switch {
+		case op.Op != models.BulkCreate && op.Op != models.BulkUpdate && op.Op != models.BulkDelete:
+			return fmt.Errorf("operation %d: op must be %q, %q or %q", i, models.BulkCreate, models.BulkUpdate, models.BulkDelete)
+		case op.Op != models.BulkCreate && op.ID < 1:
+			return fmt.Errorf("operation %d: %s requires an id", i, op.Op)
+		case op.Op != models.BulkDelete && op.Product == nil:
+			return fmt.Errorf("operation %d: %s requires a product", i, op.Op)
+		case op.Version < 0:
+			return fmt.Errorf("operation %d: version must not be negative", i)
+		}
+		if op.Product != nil {
+			if err := op.Product.Validate(); err != nil {
+				return fmt.Errorf("operation %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Runs the bulk operation op using tx, and returns its result.
+// Only errors that the client can resolve (see statusFromStorageError) are returned as the result of op, since they
+// leave tx usable. Any other error (eg. a deadlock or a cancelled request) is returned as is, and fails the batch.
+func runBulkOperation(ctx context.Context, tx storage.Storage, op *models.BulkOperation) (bulkResult, error) {
+	result := bulkResult{Op: op.Op, ID: op.ID}
+	var err error
+	switch op.Op {
+	case models.BulkCreate:
+		result.ID, err = tx.CreateProduct(ctx, op.Product)
+		result.Status, result.Version = http.StatusCreated, 1
+	case models.BulkUpdate:
+		product := op.Product.ToProduct(op.ID)
+		product.Version = op.Version
+		err = tx.UpdateProduct(ctx, product)
+		result.Status, result.Version = http.StatusNoContent, product.Version
+	case models.BulkDelete:
+		err = tx.DeleteProduct(ctx, op.ID, op.Version)
+		result.Status = http.StatusNoContent
+	}
+	if err != nil {
+		status := statusFromStorageError(err)
+		if status >= http.StatusInternalServerError {
+			return bulkResult{}, err
+		}
+		return bulkResult{Op: op.Op, ID: op.ID, Status: status, Error: err.Error()}, nil
+	}
+	return result, nil
+}
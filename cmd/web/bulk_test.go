@@ -0,0 +1,218 @@
+package web_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/cmd/web"
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+)
+
+// Tests the Bulk Products route through the server.
+// Each case starts with two products, with IDs 1 and 2.
+func TestServer_ProductRoutes_BulkProducts(t *testing.T) {
+	method := http.MethodPost
+	url := "/v1/api/products/bulk"
+
+	type bulkResult struct {
+		Status  int    `json:"status"`
+		ID      int    `json:"id"`
+		Version int    `json:"version"`
+		Error   string `json:"error"`
+	}
+	type bulkResponse struct {
+		Committed bool         `json:"committed"`
+		Results   []bulkResult `json:"results"`
+	}
+	newProduct := &models.CreateProductRequest{Name: "New Product", Price: models.NewMoney(499, models.DefaultCurrency), StockQuantity: 5}
+
+	tt := []struct {
+		name               string
+		request            models.BulkRequest
+		expectedStatusCode int
+		expectedCommitted  bool
+		expectedStatuses   []int
+		// expectedVersions are the versions returned in the results, which are zero for failures and deletions.
+		expectedVersions []int
+		expectedIDs      []int
+	}{
+		{
+			"happy path",
+			models.BulkRequest{Operations: []models.BulkOperation{
+				{Op: models.BulkCreate, Product: newProduct},
+				{Op: models.BulkUpdate, ID: 1, Version: 1, Product: newProduct},
+				{Op: models.BulkDelete, ID: 2},
+			}},
+			http.StatusOK, true, []int{201, 204, 204}, []int{1, 2, 0}, []int{1, 3},
+		},
+		{
+			"repeated updates",
+			models.BulkRequest{Operations: []models.BulkOperation{
+				{Op: models.BulkUpdate, ID: 1, Version: 1, Product: newProduct},
+				{Op: models.BulkUpdate, ID: 1, Version: storage.AnyVersion, Product: newProduct},
+			}},
+			http.StatusOK, true, []int{204, 204}, []int{2, 3}, []int{1, 2},
+		},
+		{
+			"atomic failure",
+			models.BulkRequest{Mode: models.BulkAtomic, Operations: []models.BulkOperation{
+				{Op: models.BulkCreate, Product: newProduct},
+				{Op: models.BulkDelete, ID: 1},
+				{Op: models.BulkUpdate, ID: 200, Product: newProduct},
+				{Op: models.BulkDelete, ID: 2},
+			}},
+			http.StatusConflict, false, []int{424, 424, 404, 424}, []int{0, 0, 0, 0}, []int{1, 2},
+		},
+		{
+			"atomic version conflict",
+			models.BulkRequest{Operations: []models.BulkOperation{
+				{Op: models.BulkUpdate, ID: 1, Version: 2, Product: newProduct},
+			}},
+			http.StatusConflict, false, []int{412}, []int{0}, []int{1, 2},
+		},
+		{
+			"best effort failure",
+			models.BulkRequest{Mode: models.BulkBestEffort, Operations: []models.BulkOperation{
+				{Op: models.BulkCreate, Product: newProduct},
+				{Op: models.BulkDelete, ID: 200},
+				{Op: models.BulkDelete, ID: 2, Version: 1},
+			}},
+			http.StatusMultiStatus, true, []int{201, 404, 204}, []int{1, 0, 0}, []int{1, 3},
+		},
+		{
+			"invalid op",
+			models.BulkRequest{Operations: []models.BulkOperation{{Op: "upsert", ID: 1}}},
+			http.StatusBadRequest, false, nil, nil, []int{1, 2},
+		},
+		{
+			"invalid mode",
+			models.BulkRequest{Mode: "sometimes", Operations: []models.BulkOperation{{Op: models.BulkDelete, ID: 1}}},
+			http.StatusBadRequest, false, nil, nil, []int{1, 2},
+		},
+		{
+			"update without product",
+			models.BulkRequest{Operations: []models.BulkOperation{{Op: models.BulkUpdate, ID: 1}}},
+			http.StatusBadRequest, false, nil, nil, []int{1, 2},
+		},
+		{
+			"no operations",
+			models.BulkRequest{},
+			http.StatusBadRequest, false, nil, nil, []int{1, 2},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newTestServer()
+			srv.MountHandlers()
+			addProducts(t, srv, []models.CreateProductRequest{
+				{Name: "Test Product", Price: models.NewMoney(199, models.DefaultCurrency), StockQuantity: 10},
+				{Name: "Test Product 2", Price: models.NewMoney(299, models.DefaultCurrency), StockQuantity: 20},
+			})
+
+			body := new(bytes.Buffer)
+			err := json.NewEncoder(body).Encode(tc.request)
+			if err != nil {
+				t.Fatal(fmt.Errorf("Error encoding JSON payload: %w", err))
+			}
+			req, err := http.NewRequest(method, url, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+
+			srv.Mux().ServeHTTP(rr, req)
+
+			checkEqual(t, rr.Code, tc.expectedStatusCode, "Status Code")
+			if tc.expectedStatuses != nil {
+				var response bulkResponse
+				if err = json.NewDecoder(rr.Body).Decode(&response); err != nil {
+					t.Fatal(fmt.Errorf("Error decoding JSON response: %w", err))
+				}
+				checkEqual(t, response.Committed, tc.expectedCommitted, "Committed")
+				var statuses, versions []int
+				for _, result := range response.Results {
+					statuses = append(statuses, result.Status)
+					versions = append(versions, result.Version)
+				}
+				checkEqual(t, statuses, tc.expectedStatuses, "Result Statuses")
+				checkEqual(t, versions, tc.expectedVersions, "Result Versions")
+			}
+
+			page, err := srv.Storage().GetProducts(context.Background(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []int
+			for _, p := range page.Products {
+				ids = append(ids, p.ID)
+			}
+			checkEqual(t, ids, tc.expectedIDs, "Product IDs")
+		})
+	}
+}
+
+// failingStorage is a Storage whose transactions fail to delete products with err.
+type failingStorage struct {
+	storage.Storage
+	err error
+}
+
+func (s *failingStorage) WithTx(ctx context.Context, fn func(tx storage.Storage) error) error {
+	return s.Storage.WithTx(ctx, func(tx storage.Storage) error {
+		return fn(&failingStorage{Storage: tx, err: s.err})
+	})
+}
+
+func (s *failingStorage) DeleteProduct(ctx context.Context, id int, version int) error {
+	return s.err
+}
+
+// failingServer is a testServer whose storage is a failingStorage.
+type failingServer struct {
+	*testServer
+	storage *failingStorage
+}
+
+func (srv *failingServer) Storage() storage.Storage {
+	return srv.storage
+}
+
+// Tests that a best-effort bulk request fails as a whole, rather than reporting the failed operation, if the error
+// is not one that the client can resolve, since the transaction may have been rolled back (eg. after a deadlock).
+func TestServer_ProductRoutes_BulkProducts_TransientError(t *testing.T) {
+	base := newTestServer()
+	srv := &failingServer{testServer: base, storage: &failingStorage{Storage: base.storage, err: errors.New("deadlock")}}
+	srv.Mux().Mount("/v1/api/products", web.ProductRoutes(srv))
+
+	newProduct := &models.CreateProductRequest{Name: "New Product", Price: models.NewMoney(499, models.DefaultCurrency)}
+	body := new(bytes.Buffer)
+	err := json.NewEncoder(body).Encode(models.BulkRequest{Mode: models.BulkBestEffort, Operations: []models.BulkOperation{
+		{Op: models.BulkCreate, Product: newProduct},
+		{Op: models.BulkDelete, ID: 1},
+	}})
+	if err != nil {
+		t.Fatal(fmt.Errorf("Error encoding JSON payload: %w", err))
+	}
+	req, err := http.NewRequest(http.MethodPost, "/v1/api/products/bulk", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	srv.Mux().ServeHTTP(rr, req)
+
+	checkEqual(t, rr.Code, http.StatusInternalServerError, "Status Code")
+	page, err := srv.Storage().GetProducts(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(page.Products), 0, "Products")
+}
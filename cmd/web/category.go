@@ -0,0 +1,302 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+func CategoryRoutes(srv Server) *chi.Mux {
+	router := chi.NewRouter()
+
+	router.With(withDeadline(readDeadline)).Get("/", handleGetCategories(srv))
+	router.With(withDeadline(readDeadline)).Get("/{id}", handleGetCategory(srv))
+	router.With(withDeadline(writeDeadline)).Post("/", handleCreateCategory(srv))
+	router.With(withDeadline(writeDeadline)).Put("/{id}", handleUpdateCategory(srv))
+	router.With(withDeadline(writeDeadline)).Delete("/{id}", handleDeleteCategory(srv))
+
+	return router
+}
+
+// categoriesResponse is a list of category trees.
+type categoriesResponse struct {
+	Categories []models.Category `json:"categories"`
+}
+
+// Returns the status code and error messages of a failed category storage call, where action describes
+// what failed and key is the log key of the error.
+func categoryError(err error, action, key string) (int, []string) {
+	var notFoundErr *storage.NotFoundError
+	var unknownErr *storage.UnknownCategoryError
+	var cycleErr *storage.CategoryCycleError
+	var conflictErr *storage.ConflictError
+	switch {
+	case errors.As(err, &notFoundErr):
+		return http.StatusNotFound, []string{"Category not found", key, notFoundErr.Error()}
+	case errors.As(err, &unknownErr):
+		return http.StatusBadRequest, []string{"Invalid parent category", key, unknownErr.Error()}
+	case errors.As(err, &cycleErr):
+		return http.StatusBadRequest, []string{"Invalid parent category", key, cycleErr.Error()}
+	case errors.As(err, &conflictErr):
+		return http.StatusConflict, []string{"Slug is already used", key, conflictErr.Error()}
+	default:
+		return statusFromError(err), []string{"Failed to " + action, key, err.Error()}
+	}
+}
+
+// Returns the IDs of the category identified by ref (its ID or slug) and of its descendants.
+// A nil slice is returned if there is no such category.
+func categorySubtree(ctx context.Context, s storage.CategoryStorage, ref string) ([]int, error) {
+	categories, err := s.GetCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := strconv.Atoi(ref)
+	for _, c := range categories {
+		if c.ID == id || c.Slug == ref {
+			return models.DescendantIDs(categories, c.ID), nil
+		}
+	}
+	return nil, nil
+}
+
+//	@Summary		Get categories
+//	@Description	Retrieves every category as a tree. The children of each category are ordered by position.
+//	@ID				get-categories
+//	@Tags			categories
+//	@Produce		json
+//	@Success		200	{object}	categoriesResponse	"Root categories with their subtrees"
+//	@Failure		500	{object}	errorResponse		"Internal Server Error"
+//	@Failure		503	{object}	errorResponse		"Request cancelled"
+//	@Failure		504	{object}	errorResponse		"Request timed out"
+//	@Router			/categories [get]
+func handleGetCategories(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		categories, err := srv.Storage().GetCategories(r.Context())
+		if err != nil {
+			messages := []string{"Failed to get categories", "get_categories_error", err.Error()}
+			respondWithError(w, srv.Logger(), statusFromError(err), messages...)
+			return
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusOK, categoriesResponse{models.CategoryTree(categories, 0)})
+	}
+}
+
+//	@Summary		Get a category
+//	@Description	Retrieves a category with its subtree. The children of each category are ordered by position.
+//	@ID				get-category
+//	@Tags			categories
+//	@Produce		json
+//	@Param			id	path		int				true	"Category ID"
+//	@Success		200	{object}	models.Category	"Category with its subtree"
+//	@Failure		400	{object}	errorResponse	"Invalid parameter 'id'"
+//	@Failure		404	{object}	errorResponse	"Category not found"
+//	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Failure		503	{object}	errorResponse	"Request cancelled"
+//	@Failure		504	{object}	errorResponse	"Request timed out"
+//	@Router			/categories/{id} [get]
+func handleGetCategory(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			messages := []string{"Invalid parameter 'id'", "atoi_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		categories, err := srv.Storage().GetCategories(r.Context())
+		if err != nil {
+			messages := []string{"Failed to get category", "get_category_error", err.Error()}
+			respondWithError(w, srv.Logger(), statusFromError(err), messages...)
+			return
+		}
+		tree := models.CategoryTree(categories, id)
+		if len(tree) == 0 {
+			messages := []string{"Category not found", "get_category_error", fmt.Sprintf("no category with ID %d", id)}
+			respondWithError(w, srv.Logger(), http.StatusNotFound, messages...)
+			return
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusOK, tree[0])
+	}
+}
+
+//	@Summary		Create a category
+//	@Description	Creates a category, below its parent if it has one. Its slug is generated from its name if it is omitted.
+//	@ID				create-category
+//	@Tags			categories
+//	@Accept			json
+//	@Produce		json
+//	@Param			category	body		models.CategoryRequest	true	"Category"
+//	@Success		201			{object}	idResponse				"Category ID"
+//	@Failure		400			{object}	errorResponse			"Invalid category"
+//	@Failure		409			{object}	errorResponse			"Slug is already used"
+//	@Failure		500			{object}	errorResponse			"Internal Server Error"
+//	@Failure		503			{object}	errorResponse			"Request cancelled"
+//	@Failure		504			{object}	errorResponse			"Request timed out"
+//	@Router			/categories [post]
+func handleCreateCategory(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req models.CategoryRequest
+		err := parseJSONBody(r, &req)
+		if err != nil {
+			messages := []string{"Failed to parse JSON payload", "parse_json_body_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+		if err = req.Validate(); err != nil {
+			messages := []string{"Invalid category", "validation_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		id, err := srv.Storage().CreateCategory(r.Context(), &req)
+		if err != nil {
+			status, messages := categoryError(err, "create category", "create_category_error")
+			respondWithError(w, srv.Logger(), status, messages...)
+			return
+		}
+
+		respondWithID(w, srv.Logger(), http.StatusCreated, id)
+	}
+}
+
+//	@Summary		Update a category
+//	@Description	Replaces the parent, name, slug and position of a category. A category cannot be moved below itself.
+//	@ID				update-category
+//	@Tags			categories
+//	@Accept			json
+//	@Param			id			path	int						true	"Category ID"
+//	@Param			category	body	models.CategoryRequest	true	"Category"
+//	@Success		204
+//	@Failure		400	{object}	errorResponse	"Invalid parameter or category"
+//	@Failure		404	{object}	errorResponse	"Category not found"
+//	@Failure		409	{object}	errorResponse	"Slug is already used"
+//	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Failure		503	{object}	errorResponse	"Request cancelled"
+//	@Failure		504	{object}	errorResponse	"Request timed out"
+//	@Router			/categories/{id} [put]
+func handleUpdateCategory(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			messages := []string{"Invalid parameter 'id'", "atoi_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		var req models.CategoryRequest
+		err = parseJSONBody(r, &req)
+		if err != nil {
+			messages := []string{"Failed to parse JSON payload", "parse_json_body_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+		if err = req.Validate(); err != nil {
+			messages := []string{"Invalid category", "validation_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		err = srv.Storage().UpdateCategory(r.Context(), req.ToCategory(id))
+		if err != nil {
+			status, messages := categoryError(err, "update category", "update_category_error")
+			respondWithError(w, srv.Logger(), status, messages...)
+			return
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusNoContent, nil)
+	}
+}
+
+//	@Summary		Delete a category
+//	@Description	Deletes a category and unassigns it from its products. Its children are moved to its parent.
+//	@ID				delete-category
+//	@Tags			categories
+//	@Param			id	path	int	true	"Category ID"
+//	@Success		204
+//	@Failure		400	{object}	errorResponse	"Invalid parameter 'id'"
+//	@Failure		404	{object}	errorResponse	"Category not found"
+//	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Failure		503	{object}	errorResponse	"Request cancelled"
+//	@Failure		504	{object}	errorResponse	"Request timed out"
+//	@Router			/categories/{id} [delete]
+func handleDeleteCategory(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			messages := []string{"Invalid parameter 'id'", "atoi_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		err = srv.Storage().DeleteCategory(r.Context(), id)
+		if err != nil {
+			status, messages := categoryError(err, "delete category", "delete_category_error")
+			respondWithError(w, srv.Logger(), status, messages...)
+			return
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusNoContent, nil)
+	}
+}
+
+//	@Summary		Set the categories of a product
+//	@Description	Replaces the categories that a product is assigned to.
+//	@ID				set-product-categories
+//	@Tags			categories
+//	@Accept			json
+//	@Param			id			path	int							true	"Product ID"
+//	@Param			categories	body	models.SetCategoriesRequest	true	"Category IDs"
+//	@Success		204
+//	@Failure		400	{object}	errorResponse	"Invalid parameter or unknown category"
+//	@Failure		404	{object}	errorResponse	"Product not found"
+//	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Failure		503	{object}	errorResponse	"Request cancelled"
+//	@Failure		504	{object}	errorResponse	"Request timed out"
+//	@Router			/products/{id}/categories [put]
+func handleSetProductCategories(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			messages := []string{"Invalid parameter 'id'", "atoi_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		var req models.SetCategoriesRequest
+		err = parseJSONBody(r, &req)
+		if err != nil {
+			messages := []string{"Failed to parse JSON payload", "parse_json_body_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		err = srv.Storage().SetProductCategories(r.Context(), id, req.CategoryIDs)
+		if err != nil {
+			var notFoundErr *storage.NotFoundError
+			var unknownErr *storage.UnknownCategoryError
+			switch {
+			case errors.As(err, &notFoundErr):
+				messages := []string{"Product not found", "set_product_categories_error", notFoundErr.Error()}
+				respondWithError(w, srv.Logger(), http.StatusNotFound, messages...)
+			case errors.As(err, &unknownErr):
+				messages := []string{"Unknown category", "set_product_categories_error", unknownErr.Error()}
+				respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			default:
+				messages := []string{"Failed to set product categories", "set_product_categories_error", err.Error()}
+				respondWithError(w, srv.Logger(), statusFromError(err), messages...)
+			}
+			return
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusNoContent, nil)
+	}
+}
@@ -0,0 +1,137 @@
+package web_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+)
+
+// Tests creating, listing, updating and deleting categories, assigning them to products and filtering by them.
+// Each step is run in order against the same two products.
+func TestServer_CategoryRoutes(t *testing.T) {
+	srv := newTestServer()
+	srv.MountHandlers()
+
+	for i := 0; i < 2; i++ {
+		_, err := srv.Storage().CreateProduct(context.Background(), &models.CreateProductRequest{
+			Name:  "Test Product",
+			Price: models.NewMoney(1000, models.DefaultCurrency),
+		})
+		if err != nil {
+			t.Fatal(fmt.Errorf("Error creating product: %w", err))
+		}
+	}
+	parent := func(id int) *int { return &id }
+
+	steps := []struct {
+		name               string
+		method             string
+		url                string
+		payload            any
+		expectedStatusCode int
+		// expectedProducts is the number of products in the response, if it is not negative.
+		expectedProducts int
+	}{
+		{
+			"create root", http.MethodPost, "/v1/api/categories", models.CategoryRequest{Name: "Clothing"},
+			http.StatusCreated, -1,
+		},
+		{
+			"create child", http.MethodPost, "/v1/api/categories", models.CategoryRequest{ParentID: parent(1), Name: "T-Shirts"},
+			http.StatusCreated, -1,
+		},
+		{
+			"create duplicate slug", http.MethodPost, "/v1/api/categories", models.CategoryRequest{Name: "clothing!"},
+			http.StatusConflict, -1,
+		},
+		{
+			"create invalid slug", http.MethodPost, "/v1/api/categories", models.CategoryRequest{Name: "Hats", Slug: "Hats"},
+			http.StatusBadRequest, -1,
+		},
+		{
+			"create with unknown parent", http.MethodPost, "/v1/api/categories", models.CategoryRequest{ParentID: parent(9), Name: "Hats"},
+			http.StatusBadRequest, -1,
+		},
+		{
+			"move below child", http.MethodPut, "/v1/api/categories/1", models.CategoryRequest{ParentID: parent(2), Name: "Clothing"},
+			http.StatusBadRequest, -1,
+		},
+		{
+			"rename", http.MethodPut, "/v1/api/categories/2", models.CategoryRequest{ParentID: parent(1), Name: "Shirts"},
+			http.StatusNoContent, -1,
+		},
+		{
+			"get tree", http.MethodGet, "/v1/api/categories", nil,
+			http.StatusOK, -1,
+		},
+		{
+			"get subtree", http.MethodGet, "/v1/api/categories/1", nil,
+			http.StatusOK, -1,
+		},
+		{
+			"get missing", http.MethodGet, "/v1/api/categories/9", nil,
+			http.StatusNotFound, -1,
+		},
+		{
+			"assign", http.MethodPut, "/v1/api/products/1/categories", models.SetCategoriesRequest{CategoryIDs: []int{2}},
+			http.StatusNoContent, -1,
+		},
+		{
+			"assign unknown", http.MethodPut, "/v1/api/products/2/categories", models.SetCategoriesRequest{CategoryIDs: []int{9}},
+			http.StatusBadRequest, -1,
+		},
+		{
+			"filter by ancestor", http.MethodGet, "/v1/api/products?category=clothing", nil,
+			http.StatusOK, 1,
+		},
+		{
+			"filter by id", http.MethodGet, "/v1/api/products?category=2", nil,
+			http.StatusOK, 1,
+		},
+		{
+			"filter by unknown", http.MethodGet, "/v1/api/products?category=hats", nil,
+			http.StatusBadRequest, -1,
+		},
+		{
+			"delete", http.MethodDelete, "/v1/api/categories/2", nil,
+			http.StatusNoContent, -1,
+		},
+		{
+			"filter after delete", http.MethodGet, "/v1/api/products?category=clothing", nil,
+			http.StatusOK, 0,
+		},
+	}
+
+	for _, step := range steps {
+		body := new(bytes.Buffer)
+		if step.payload != nil {
+			if err := json.NewEncoder(body).Encode(step.payload); err != nil {
+				t.Fatal(fmt.Errorf("Error encoding JSON payload: %w", err))
+			}
+		}
+		req, err := http.NewRequest(step.method, step.url, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+
+		srv.Mux().ServeHTTP(rr, req)
+
+		checkEqual(t, rr.Code, step.expectedStatusCode, step.name+": Status Code")
+		if step.expectedProducts >= 0 {
+			var page struct {
+				Products []models.Product `json:"products"`
+			}
+			if err = json.NewDecoder(rr.Body).Decode(&page); err != nil {
+				t.Fatal(fmt.Errorf("Error decoding JSON response: %w", err))
+			}
+			checkEqual(t, len(page.Products), step.expectedProducts, step.name+": Products")
+		}
+	}
+}
@@ -2,10 +2,17 @@ package web
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/Broderick-Westrope/e-gommerce/internal/config"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
 	"github.com/oklog/ulid/v2"
 )
 
@@ -64,6 +71,77 @@ func respondWithError(w http.ResponseWriter, logger config.Logger, statusCode in
 	respondWithJSON(w, logger, statusCode, errResponse)
 }
 
+// Returns the status code of a failed storage call that is made on behalf of a client:
+// a 404 Not Found, 412 Precondition Failed or 409 Conflict for the errors that the client can resolve,
+// and otherwise the status code given by statusFromError.
+func statusFromStorageError(err error) int {
+	var notFoundErr *storage.NotFoundError
+	var conflictErr *storage.VersionConflictError
+	var insufficientErr *storage.InsufficientStockError
+	var stateErr *storage.ReservationStateError
+	var duplicateErr *storage.ConflictError
+	switch {
+	case errors.As(err, &notFoundErr):
+		return http.StatusNotFound
+	case errors.As(err, &conflictErr):
+		return http.StatusPreconditionFailed
+	case errors.As(err, &insufficientErr), errors.As(err, &stateErr), errors.As(err, &duplicateErr):
+		return http.StatusConflict
+	default:
+		return statusFromError(err)
+	}
+}
+
+// Returns a Link header value (RFC 8288) pointing to the page of r that starts after cursor.
+// All other query parameters of r are preserved.
+func nextPageLink(r *http.Request, cursor string) string {
+	query := r.URL.Query()
+	query.Set("after", cursor)
+	next := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	return fmt.Sprintf("<%s>; rel=\"next\"", next.String())
+}
+
+// errMissingIfMatch is returned by parseIfMatch when the request has no If-Match header.
+var errMissingIfMatch = errors.New("Missing header 'If-Match'")
+
+// Returns the strong ETag of a resource with version (eg. "3").
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// Parses the If-Match header of r into the version that the client expects the resource to have.
+// "*" matches any version, and is parsed as storage.AnyVersion.
+// errMissingIfMatch is returned if there is no If-Match header.
+func parseIfMatch(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		return 0, errMissingIfMatch
+	}
+	if value == "*" {
+		return storage.AnyVersion, nil
+	}
+
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid ETag %s in header 'If-Match'", value)
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("Invalid ETag %s in header 'If-Match'", value)
+	}
+	return version, nil
+}
+
+// Responds on w with an error for a request whose If-Match header could not be parsed by parseIfMatch.
+// A missing header is a 428 Precondition Required. An invalid ETag can never match, so it is a 412 Precondition Failed.
+func respondWithIfMatchError(w http.ResponseWriter, logger config.Logger, err error) {
+	if errors.Is(err, errMissingIfMatch) {
+		respondWithError(w, logger, http.StatusPreconditionRequired, err.Error())
+		return
+	}
+	respondWithError(w, logger, http.StatusPreconditionFailed, "Invalid header 'If-Match'", "parse_if_match_error", err.Error())
+}
+
 // Unmarshals the JSON payload stored in the body of r.
 // The result is stored in dst.
 // An error is returned if the JSON payload cannot be unmarshalled.
@@ -89,3 +167,17 @@ func createErrorResponse(logger config.Logger, messages ...string) errorResponse
 	logger.Error(messages[0], args...)
 	return response
 }
+
+// Returns the status code to respond with for an error that has no more specific meaning to the handler.
+// A passed deadline maps to 504 Gateway Timeout and a cancelled request (eg. the client hung up) maps
+// to 503 Service Unavailable. Any other error is a 500 Internal Server Error.
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,135 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/config"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+)
+
+const (
+	// The time that each readiness check is given before it fails.
+	healthCheckTimeout = 2 * time.Second
+	// The least free space that the disk holding the blobs may have for the server to be ready, in bytes.
+	minFreeBlobSpace = 100 << 20
+)
+
+// HealthCheck is a check of a dependency that the server needs to be ready to serve requests.
+type HealthCheck struct {
+	Name string
+	// Check returns an error if the dependency is unavailable. It should return once ctx is done.
+	Check func(ctx context.Context) error
+}
+
+// healthReport is the result of the checks run by a probe.
+type healthReport struct {
+	// Status is "ok" if every check passed, and otherwise "fail".
+	Status string        `json:"status" example:"ok"`
+	Checks []checkReport `json:"checks"`
+}
+
+// checkReport is the result of a single HealthCheck.
+type checkReport struct {
+	Name      string  `json:"name" example:"storage"`
+	Status    string  `json:"status" example:"ok"`
+	LatencyMS float64 `json:"latency_ms" example:"1.25"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Returns the checks of the dependencies in config: that the storage can be reached, that its schema is current
+// (if it has one), and that the disk holding the blobs has space left (if they are kept on disk).
+func healthChecks(config config.Config) []HealthCheck {
+	checks := []HealthCheck{{Name: "storage", Check: config.Storage.Ping}}
+	if config.Migrator != nil {
+		checks = append(checks, HealthCheck{Name: "migrations", Check: config.Migrator.CheckCurrent})
+	}
+	if disk, ok := config.Blobs.(*storage.DiskBlobStore); ok {
+		if _, err := freeDiskSpace(disk.Dir()); !errors.Is(err, errors.ErrUnsupported) {
+			checks = append(checks, HealthCheck{Name: "blob_disk_space", Check: diskSpaceCheck(disk.Dir(), minFreeBlobSpace)})
+		}
+	}
+	return checks
+}
+
+// Returns a check that fails if the disk holding dir has less than minFree bytes of free space.
+func diskSpaceCheck(dir string, minFree uint64) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		free, err := freeDiskSpace(dir)
+		if err != nil {
+			return err
+		}
+		if free < minFree {
+			return fmt.Errorf("%d bytes free in %q, less than the minimum of %d", free, dir, minFree)
+		}
+		return nil
+	}
+}
+
+// Probes returns a middleware that serves the liveness probe at '/healthz' and the readiness probe at '/readyz',
+// as middleware.Heartbeat does for '/ping'. Both respond with a JSON report of the checks they ran.
+//
+// The liveness probe runs no checks, since restarting the server would not fix its dependencies.
+// The readiness probe runs the health checks of srv concurrently, and responds with 503 Service Unavailable
+// if any of them fail.
+func Probes(srv Server) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			switch r.URL.Path {
+			case "/healthz":
+				respondWithJSON(w, srv.Logger(), http.StatusOK, healthReport{Status: "ok", Checks: []checkReport{}})
+			case "/readyz":
+				report := runHealthChecks(r.Context(), srv.HealthChecks())
+				status := http.StatusOK
+				for _, result := range report.Checks {
+					if result.Status != "ok" {
+						status = http.StatusServiceUnavailable
+						srv.Logger().Warn("Readiness check failed", "check", result.Name, "check_error", result.Error)
+					}
+				}
+				respondWithJSON(w, srv.Logger(), status, report)
+			default:
+				next.ServeHTTP(w, r)
+			}
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Runs checks concurrently, giving each of them healthCheckTimeout, and returns their results in order.
+func runHealthChecks(ctx context.Context, checks []HealthCheck) healthReport {
+	report := healthReport{Status: "ok", Checks: make([]checkReport, len(checks))}
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Check(checkCtx)
+			result := checkReport{Name: check.Name, Status: "ok", LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				result.Status, result.Error = "fail", err.Error()
+			}
+			report.Checks[i] = result
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != "ok" {
+			report.Status = "fail"
+		}
+	}
+	return report
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package web
+
+import "errors"
+
+// Returns errors.ErrUnsupported, since the free space of a disk is only found on Linux and macOS.
+func freeDiskSpace(dir string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
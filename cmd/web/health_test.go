@@ -0,0 +1,77 @@
+package web_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/cmd/web"
+)
+
+// Tests that the liveness probe always passes, and that the readiness probe reports the result of every check.
+func TestServer_Probes(t *testing.T) {
+	srv := newTestServer()
+	failing := errors.New("database is down")
+	down := false
+	srv.checks = []web.HealthCheck{
+		{Name: "storage", Check: srv.Storage().Ping},
+		{Name: "database", Check: func(ctx context.Context) error {
+			if down {
+				return failing
+			}
+			return nil
+		}},
+	}
+	srv.MountHandlers()
+
+	type checkResult struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	}
+	tt := []struct {
+		name               string
+		url                string
+		down               bool
+		expectedStatusCode int
+		expectedStatus     string
+		expectedChecks     []checkResult
+	}{
+		{"live", "/healthz", true, http.StatusOK, "ok", []checkResult{}},
+		{
+			"ready", "/readyz", false, http.StatusOK, "ok",
+			[]checkResult{{"storage", "ok", ""}, {"database", "ok", ""}},
+		},
+		{
+			"not ready", "/readyz", true, http.StatusServiceUnavailable, "fail",
+			[]checkResult{{"storage", "ok", ""}, {"database", "fail", failing.Error()}},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			down = tc.down
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+
+			srv.Mux().ServeHTTP(rr, req)
+
+			checkEqual(t, rr.Code, tc.expectedStatusCode, "Status Code")
+			var report struct {
+				Status string        `json:"status"`
+				Checks []checkResult `json:"checks"`
+			}
+			if err = json.NewDecoder(rr.Body).Decode(&report); err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, report.Status, tc.expectedStatus, "Status")
+			checkEqual(t, report.Checks, tc.expectedChecks, "Checks")
+		})
+	}
+}
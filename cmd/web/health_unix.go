@@ -0,0 +1,14 @@
+//go:build linux || darwin
+
+package web
+
+import "syscall"
+
+// Returns the number of bytes of free space that unprivileged users have on the disk holding dir.
+func freeDiskSpace(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
@@ -1,14 +1,22 @@
 package web_test
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"testing"
 
 	"github.com/Broderick-Westrope/e-gommerce/cmd/web"
 	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
 )
 
+// productsResponse is a single page of products returned by the Get Products route.
+type productsResponse struct {
+	Products   []models.Product `json:"products"`
+	NextCursor string           `json:"next_cursor"`
+}
+
 // Check that got and want are equal, and if not, log an error to t.
 // msg should be a short description of what is being tested (eg. "Status Code").
 func checkEqual(t *testing.T, got, want interface{}, msg string) {
@@ -24,7 +32,7 @@ func addProducts(t *testing.T, srv web.Server, products []models.CreateProductRe
 	t.Helper()
 
 	for _, p := range products {
-		_, err := srv.Storage().CreateProduct(&p)
+		_, err := srv.Storage().CreateProduct(context.Background(), &p)
 		if err != nil {
 			t.Error(fmt.Errorf("Error creating product: %w", err))
 		}
@@ -36,7 +44,7 @@ func removeProducts(t *testing.T, srv web.Server, products []models.Product) {
 	t.Helper()
 
 	for _, p := range products {
-		err := srv.Storage().DeleteProduct(p.ID)
+		err := srv.Storage().DeleteProduct(context.Background(), p.ID, storage.AnyVersion)
 		if err != nil {
 			t.Error(fmt.Errorf("Error deleting product: %w", err))
 		}
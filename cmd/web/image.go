@@ -0,0 +1,305 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	// The largest image that can be uploaded, in bytes.
+	maxImageSize = 5 << 20
+	// The largest multipart body that an image can be uploaded in, which leaves room for its headers.
+	maxImageUploadSize = maxImageSize + 64<<10
+	// The name of the multipart form field that holds the uploaded image.
+	imageFormField = "image"
+	// How long clients may cache the content of an image. Images are never modified, only deleted.
+	imageCacheControl = "public, max-age=31536000, immutable"
+)
+
+// The content types of the images that can be uploaded, as sniffed by http.DetectContentType.
+var imageContentTypes = map[string]bool{ //nolint:gochecknoglobals // read-only lookup table
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// imagesResponse is the list of images of a product.
+type imagesResponse struct {
+	Images []models.Image `json:"images"`
+}
+
+// Returns the status code and error messages of a failed image storage call, where notFound describes
+// what was not found, action what failed, and key the log key of the error.
+func imageError(err error, notFound, action, key string) (int, []string) {
+	var notFoundErr *storage.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return http.StatusNotFound, []string{notFound + " not found", key, notFoundErr.Error()}
+	}
+	return statusFromError(err), []string{"Failed to " + action, key, err.Error()}
+}
+
+// Parses the 'id' and 'imageID' URL parameters of r. The messages of the error response are returned on failure.
+func parseImageParams(r *http.Request) (int, int, []string) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return 0, 0, []string{"Invalid parameter 'id'", "atoi_error", err.Error()}
+	}
+	imageID, err := strconv.Atoi(chi.URLParam(r, "imageID"))
+	if err != nil {
+		return 0, 0, []string{"Invalid parameter 'imageID'", "atoi_error", err.Error()}
+	}
+	return id, imageID, nil
+}
+
+// Reads the content of the image form field from the multipart body of r, which must not be larger than maxImageSize.
+// The status code of the error response is returned along with any error.
+func readImageUpload(w http.ResponseWriter, r *http.Request) ([]byte, int, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImageUploadSize)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, http.StatusBadRequest, fmt.Errorf("missing form field '%s'", imageFormField)
+		}
+		if err != nil {
+			return nil, uploadErrorStatus(err), err
+		}
+		if part.FormName() != imageFormField {
+			continue
+		}
+
+		// One byte more than the limit is read to find out whether the image is too large.
+		content, err := io.ReadAll(io.LimitReader(part, maxImageSize+1))
+		if err != nil {
+			return nil, uploadErrorStatus(err), err
+		}
+		if len(content) > maxImageSize {
+			return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("image must be at most %d bytes", maxImageSize)
+		}
+		return content, http.StatusOK, nil
+	}
+}
+
+// Returns the status code of an error reading an uploaded body: 413 Request Entity Too Large if the body
+// is larger than its limit, and otherwise 400 Bad Request.
+func uploadErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+//	@Summary		Upload an image of a product
+//	@Description	Uploads a JPEG, PNG, GIF or WebP image of a product of at most 5 MiB, in the 'image' field of a multipart form.
+//	@Description	The content type is detected from the content of the image, rather than trusted from the request.
+//	@ID				upload-image
+//	@Tags			images
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			id		path		int				true	"Product ID"
+//	@Param			image	formData	file			true	"Image"
+//	@Success		201		{object}	models.Image	"Image"
+//	@Failure		400		{object}	errorResponse	"Invalid parameter or upload"
+//	@Failure		404		{object}	errorResponse	"Product not found"
+//	@Failure		413		{object}	errorResponse	"Image is too large"
+//	@Failure		415		{object}	errorResponse	"Unsupported image type"
+//	@Failure		500		{object}	errorResponse	"Internal Server Error"
+//	@Failure		503		{object}	errorResponse	"Request cancelled"
+//	@Failure		504		{object}	errorResponse	"Request timed out"
+//	@Router			/products/{id}/images [post]
+func handleUploadImage(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			messages := []string{"Invalid parameter 'id'", "atoi_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		content, status, err := readImageUpload(w, r)
+		if err != nil {
+			messages := []string{"Failed to read image", "read_image_error", err.Error()}
+			respondWithError(w, srv.Logger(), status, messages...)
+			return
+		}
+		contentType := http.DetectContentType(content)
+		if !imageContentTypes[contentType] {
+			messages := []string{"Unsupported image type", "validation_error", fmt.Sprintf("unsupported content type %q", contentType)}
+			respondWithError(w, srv.Logger(), http.StatusUnsupportedMediaType, messages...)
+			return
+		}
+
+		checksum := sha256.Sum256(content)
+		image := &models.Image{
+			ProductID:   id,
+			Key:         fmt.Sprintf("products/%d/%s", id, ulid.Make()),
+			ContentType: contentType,
+			Size:        int64(len(content)),
+			Checksum:    hex.EncodeToString(checksum[:]),
+		}
+		err = srv.Blobs().Put(r.Context(), image.Key, bytes.NewReader(content))
+		if err != nil {
+			messages := []string{"Failed to store image", "put_blob_error", err.Error()}
+			respondWithError(w, srv.Logger(), statusFromError(err), messages...)
+			return
+		}
+
+		created, err := srv.Storage().CreateImage(r.Context(), image)
+		if err != nil {
+			// The blob is deleted so that it is not left without an image, even if the request has been cancelled.
+			if blobErr := srv.Blobs().Delete(context.WithoutCancel(r.Context()), image.Key); blobErr != nil {
+				srv.Logger().Error("Failed to delete blob", "delete_blob_error", blobErr.Error())
+			}
+			status, messages := imageError(err, "Product", "create image", "create_image_error")
+			respondWithError(w, srv.Logger(), status, messages...)
+			return
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusCreated, created)
+	}
+}
+
+//	@Summary		Get the images of a product
+//	@Description	Retrieves the metadata of the images of a product, ordered by ID.
+//	@ID				get-images
+//	@Tags			images
+//	@Produce		json
+//	@Param			id	path		int				true	"Product ID"
+//	@Success		200	{object}	imagesResponse	"Images"
+//	@Failure		400	{object}	errorResponse	"Invalid parameter 'id'"
+//	@Failure		404	{object}	errorResponse	"Product not found"
+//	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Failure		503	{object}	errorResponse	"Request cancelled"
+//	@Failure		504	{object}	errorResponse	"Request timed out"
+//	@Router			/products/{id}/images [get]
+func handleGetImages(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			messages := []string{"Invalid parameter 'id'", "atoi_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		images, err := srv.Storage().GetImages(r.Context(), id)
+		if err != nil {
+			status, messages := imageError(err, "Product", "get images", "get_images_error")
+			respondWithError(w, srv.Logger(), status, messages...)
+			return
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusOK, imagesResponse{images})
+	}
+}
+
+//	@Summary		Get the content of an image
+//	@Description	Serves the content of an image of a product. Images never change, so they may be cached indefinitely.
+//	@Description	The ETag is the SHA-256 checksum of the image, and conditional and range requests are supported.
+//	@ID				get-image
+//	@Tags			images
+//	@Produce		image/jpeg,image/png,image/gif,image/webp
+//	@Param			id		path		int				true	"Product ID"
+//	@Param			imageID	path		int				true	"Image ID"
+//	@Success		200		{file}		binary			"Image"
+//	@Header			200		{string}	ETag			"Checksum of the image"
+//	@Header			200		{string}	Cache-Control	"Caching policy"
+//	@Success		304		"Not Modified"
+//	@Failure		400		{object}	errorResponse	"Invalid parameter"
+//	@Failure		404		{object}	errorResponse	"Image not found"
+//	@Failure		500		{object}	errorResponse	"Internal Server Error"
+//	@Failure		503		{object}	errorResponse	"Request cancelled"
+//	@Failure		504		{object}	errorResponse	"Request timed out"
+//	@Router			/products/{id}/images/{imageID} [get]
+func handleGetImage(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, imageID, messages := parseImageParams(r)
+		if messages != nil {
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		image, err := srv.Storage().GetImage(r.Context(), id, imageID)
+		if err != nil {
+			status, messages := imageError(err, "Image", "get image", "get_image_error")
+			respondWithError(w, srv.Logger(), status, messages...)
+			return
+		}
+		blob, err := srv.Blobs().Get(r.Context(), image.Key)
+		if err != nil {
+			status, messages := imageError(err, "Image content", "get image content", "get_blob_error")
+			respondWithError(w, srv.Logger(), status, messages...)
+			return
+		}
+		defer blob.Close()
+
+		w.Header().Set("Content-Type", image.ContentType)
+		w.Header().Set("ETag", strconv.Quote(image.Checksum))
+		w.Header().Set("Cache-Control", imageCacheControl)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		// ServeContent handles conditional and range requests, but needs to seek the content.
+		if content, ok := blob.(io.ReadSeeker); ok {
+			http.ServeContent(w, r, "", image.CreatedAt, content)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.FormatInt(image.Size, 10))
+		if _, err = io.Copy(w, blob); err != nil {
+			srv.Logger().Error("Failed to write image", "write_error", err.Error())
+		}
+	}
+}
+
+//	@Summary		Delete an image of a product
+//	@Description	Deletes the metadata and content of an image of a product.
+//	@ID				delete-image
+//	@Tags			images
+//	@Param			id		path	int	true	"Product ID"
+//	@Param			imageID	path	int	true	"Image ID"
+//	@Success		204
+//	@Failure		400	{object}	errorResponse	"Invalid parameter"
+//	@Failure		404	{object}	errorResponse	"Image not found"
+//	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Failure		503	{object}	errorResponse	"Request cancelled"
+//	@Failure		504	{object}	errorResponse	"Request timed out"
+//	@Router			/products/{id}/images/{imageID} [delete]
+func handleDeleteImage(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, imageID, messages := parseImageParams(r)
+		if messages != nil {
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		image, err := srv.Storage().GetImage(r.Context(), id, imageID)
+		if err == nil {
+			err = srv.Storage().DeleteImage(r.Context(), id, imageID)
+		}
+		if err != nil {
+			status, messages := imageError(err, "Image", "delete image", "delete_image_error")
+			respondWithError(w, srv.Logger(), status, messages...)
+			return
+		}
+		// The image is already gone, so a blob that cannot be deleted is only logged.
+		if err = srv.Blobs().Delete(r.Context(), image.Key); err != nil {
+			srv.Logger().Error("Failed to delete blob", "delete_blob_error", err.Error())
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusNoContent, nil)
+	}
+}
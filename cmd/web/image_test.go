@@ -0,0 +1,205 @@
+package web_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+)
+
+// pngHeader is the start of a PNG file, which is enough for its content type to be detected.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR") //nolint:gochecknoglobals // test fixture
+
+// multipartBody returns a multipart form with content in the field with name, and the content type of the form.
+func multipartBody(t *testing.T, name string, content []byte) ([]byte, string) {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(name, "image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = part.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return body.Bytes(), writer.FormDataContentType()
+}
+
+// Tests uploading, listing, serving and deleting the images of a product.
+// Each step is run in order against the same product.
+func TestServer_ImageRoutes(t *testing.T) {
+	srv := newTestServer()
+	blobs, err := storage.NewDiskBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.blobs = blobs
+	srv.MountHandlers()
+
+	_, err = srv.Storage().CreateProduct(context.Background(), &models.CreateProductRequest{
+		Name:  "Test Product",
+		Price: models.NewMoney(1000, models.DefaultCurrency),
+	})
+	if err != nil {
+		t.Fatal(fmt.Errorf("Error creating product: %w", err))
+	}
+	checksum := sha256.Sum256(pngHeader)
+	etag := strconv.Quote(hex.EncodeToString(checksum[:]))
+
+	upload := func(name string, content []byte) func() ([]byte, string) {
+		return func() ([]byte, string) { return multipartBody(t, name, content) }
+	}
+	steps := []struct {
+		name   string
+		method string
+		url    string
+		// body returns the request body and its content type, if it is not nil.
+		body               func() ([]byte, string)
+		ifNoneMatch        string
+		expectedStatusCode int
+		// expectedHeaders are headers that the response must have.
+		expectedHeaders map[string]string
+	}{
+		{
+			"upload", http.MethodPost, "/v1/api/products/1/images", upload("image", pngHeader), "",
+			http.StatusCreated, nil,
+		},
+		{
+			"upload unsupported type", http.MethodPost, "/v1/api/products/1/images", upload("image", []byte("plain text")), "",
+			http.StatusUnsupportedMediaType, nil,
+		},
+		{
+			"upload too large", http.MethodPost, "/v1/api/products/1/images", upload("image", make([]byte, 5<<20+1)), "",
+			http.StatusRequestEntityTooLarge, nil,
+		},
+		{
+			"upload missing field", http.MethodPost, "/v1/api/products/1/images", upload("file", pngHeader), "",
+			http.StatusBadRequest, nil,
+		},
+		{
+			"upload to missing product", http.MethodPost, "/v1/api/products/9/images", upload("image", pngHeader), "",
+			http.StatusNotFound, nil,
+		},
+		{
+			"list", http.MethodGet, "/v1/api/products/1/images", nil, "",
+			http.StatusOK, map[string]string{"Content-Type": "application/json"},
+		},
+		{
+			"get content", http.MethodGet, "/v1/api/products/1/images/1", nil, "",
+			http.StatusOK, map[string]string{
+				"Content-Type":  "image/png",
+				"ETag":          etag,
+				"Cache-Control": "public, max-age=31536000, immutable",
+			},
+		},
+		{
+			"get unmodified content", http.MethodGet, "/v1/api/products/1/images/1", nil, etag,
+			http.StatusNotModified, nil,
+		},
+		{
+			"get missing image", http.MethodGet, "/v1/api/products/1/images/2", nil, "",
+			http.StatusNotFound, nil,
+		},
+		{
+			"delete", http.MethodDelete, "/v1/api/products/1/images/1", nil, "",
+			http.StatusNoContent, nil,
+		},
+		{
+			"get deleted image", http.MethodGet, "/v1/api/products/1/images/1", nil, "",
+			http.StatusNotFound, nil,
+		},
+	}
+
+	for _, step := range steps {
+		var req *http.Request
+		if step.body != nil {
+			body, contentType := step.body()
+			req = httptest.NewRequest(step.method, step.url, bytes.NewReader(body))
+			req.Header.Set("Content-Type", contentType)
+		} else {
+			req = httptest.NewRequest(step.method, step.url, http.NoBody)
+		}
+		if step.ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", step.ifNoneMatch)
+		}
+		rr := httptest.NewRecorder()
+		srv.Mux().ServeHTTP(rr, req)
+
+		if rr.Code != step.expectedStatusCode {
+			t.Fatalf("%s: expected status code %d, got %d: %s", step.name, step.expectedStatusCode, rr.Code, rr.Body.String())
+		}
+		for key, value := range step.expectedHeaders {
+			if got := rr.Header().Get(key); got != value {
+				t.Errorf("%s: expected header %s to be %q, got %q", step.name, key, value, got)
+			}
+		}
+		if step.name == "get content" && !bytes.Equal(rr.Body.Bytes(), pngHeader) {
+			t.Errorf("%s: expected the uploaded content, got %q", step.name, rr.Body.Bytes())
+		}
+	}
+}
+
+// Tests that the content of a product's images is deleted when the product is purged from the trash.
+func TestServer_ImageRoutes_PurgeProduct(t *testing.T) {
+	srv := newTestServer()
+	blobs, err := storage.NewDiskBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.blobs = blobs
+	srv.MountHandlers()
+
+	ctx := context.Background()
+	_, err = srv.Storage().CreateProduct(ctx, &models.CreateProductRequest{
+		Name:  "Test Product",
+		Price: models.NewMoney(1000, models.DefaultCurrency),
+	})
+	if err != nil {
+		t.Fatal(fmt.Errorf("Error creating product: %w", err))
+	}
+
+	serve := func(method, url string, body []byte, contentType string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, url, bytes.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		req.Header.Set("If-Match", "*")
+		rr := httptest.NewRecorder()
+		srv.Mux().ServeHTTP(rr, req)
+		return rr
+	}
+	body, contentType := multipartBody(t, "image", pngHeader)
+	checkEqual(t, serve(http.MethodPost, "/v1/api/products/1/images", body, contentType).Code, http.StatusCreated, "Upload Status Code")
+	keys, err := srv.Storage().GetImageKeys(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(keys), 1, "Image Keys")
+
+	// The content is kept while the product is in the trash, so that it can be restored.
+	checkEqual(t, serve(http.MethodDelete, "/v1/api/products/1", nil, "").Code, http.StatusNoContent, "Delete Status Code")
+	content, err := blobs.Get(ctx, keys[0])
+	if err != nil {
+		t.Fatal(fmt.Errorf("Error getting blob of deleted product: %w", err))
+	}
+	content.Close()
+
+	checkEqual(t, serve(http.MethodDelete, "/v1/api/products/1?purge=true", nil, "").Code, http.StatusNoContent, "Purge Status Code")
+	var notFoundErr *storage.NotFoundError
+	_, err = blobs.Get(ctx, keys[0])
+	checkEqual(t, errors.As(err, &notFoundErr), true, "Blob Not Found After Purge")
+}
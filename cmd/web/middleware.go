@@ -0,0 +1,144 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// anonymousActor is the actor recorded for changes made by requests without an 'X-Actor' header.
+const anonymousActor = "anonymous"
+
+// Returns a middleware that gives the request context a deadline of timeout.
+// Storage calls made with the request context are cancelled once the deadline passes,
+// and the handler maps the resulting error to a 504 Gateway Timeout (see statusFromError).
+func withDeadline(timeout time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Middleware that stores the storage.Audit of the request in its context, so that the changes it makes are recorded
+// with the actor named in its 'X-Actor' header and the request ID given to it by middleware.RequestID.
+// There is no authentication yet, so the actor is whoever the client claims to be.
+func withAudit(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		actor := r.Header.Get("X-Actor")
+		if actor == "" {
+			actor = anonymousActor
+		}
+		audit := storage.Audit{Actor: actor, RequestID: middleware.GetReqID(r.Context())}
+
+		next.ServeHTTP(w, r.WithContext(storage.WithAudit(r.Context(), audit)))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// lastWriteCookie is the cookie that holds the time of a client's last write, in milliseconds since the Unix epoch.
+const lastWriteCookie = "last_write"
+
+// ReadYourWrites returns a middleware that lets each client read its own writes for window after making them.
+// Successful responses to requests that may change data set the lastWriteCookie, and requests that present it
+// within window read from the primary database (see storage.ReadPrimary), since a replica or the cache may not have
+// the change yet. The reads of other clients are unaffected. It does nothing if window is zero.
+func ReadYourWrites(window time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if window <= 0 {
+			return next
+		}
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if cookie, err := r.Cookie(lastWriteCookie); err == nil {
+				written, err := strconv.ParseInt(cookie.Value, 10, 64)
+				if err == nil && time.Since(time.UnixMilli(written)) < window {
+					r = r.WithContext(storage.ReadPrimary(r.Context()))
+				}
+			}
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+			default:
+				w = &lastWriteRecorder{ResponseWriter: w, window: window}
+			}
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// lastWriteRecorder is a http.ResponseWriter that sets the lastWriteCookie when a successful response is written,
+// which is once the changes made by the request have been committed.
+type lastWriteRecorder struct {
+	http.ResponseWriter
+	window      time.Duration
+	wroteHeader bool
+}
+
+func (w *lastWriteRecorder) WriteHeader(statusCode int) {
+	if !w.wroteHeader && statusCode < http.StatusBadRequest {
+		http.SetCookie(w.ResponseWriter, &http.Cookie{
+			Name:     lastWriteCookie,
+			Value:    strconv.FormatInt(time.Now().UnixMilli(), 10),
+			Path:     "/",
+			MaxAge:   int((w.window + time.Second - 1) / time.Second),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *lastWriteRecorder) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the http.ResponseWriter that w wraps, for http.ResponseController.
+func (w *lastWriteRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// contentTypeException allows requests with Method to paths matching Pattern (see path.Match) to have a body
+// of one of ContentTypes, instead of the content types that allowContentType allows by default.
+type contentTypeException struct {
+	Method       string
+	Pattern      string
+	ContentTypes []string
+}
+
+// Returns a middleware that responds with 415 Unsupported Media Type to requests with a body whose Content-Type
+// is not one of contentTypes, or of the content types of the first exception that matches the request.
+// It runs before routing, so exceptions match the path of the request rather than its route.
+func allowContentType(contentTypes []string, exceptions ...contentTypeException) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		allowDefault := middleware.AllowContentType(contentTypes...)(next)
+		allowExceptions := make([]http.Handler, len(exceptions))
+		for i, e := range exceptions {
+			allowExceptions[i] = middleware.AllowContentType(e.ContentTypes...)(next)
+		}
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			for i, e := range exceptions {
+				if matched, _ := path.Match(e.Pattern, r.URL.Path); matched && r.Method == e.Method {
+					allowExceptions[i].ServeHTTP(w, r)
+					return
+				}
+			}
+			allowDefault.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,106 @@
+package web_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Broderick-Westrope/e-gommerce/cmd/web"
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+)
+
+// cachedServer is a testServer whose storage is cached.
+type cachedServer struct {
+	*testServer
+	storage *storage.Cached
+}
+
+func (srv *cachedServer) Storage() storage.Storage {
+	return srv.storage
+}
+
+// Tests that a client reads from the primary database, rather than the cache, for the read-your-writes window
+// after it makes a write, and that other clients (and failed writes) are unaffected.
+func TestServer_ReadYourWrites(t *testing.T) {
+	base := newTestServer()
+	srv := &cachedServer{testServer: base, storage: storage.NewCached(base.storage, 10, time.Hour)}
+	srv.Mux().Use(web.ReadYourWrites(time.Minute))
+	srv.Mux().Mount("/v1/api/products", web.ProductRoutes(srv))
+
+	ctx := context.Background()
+	for _, name := range []string{"Product 1", "Product 2"} {
+		_, err := srv.Storage().CreateProduct(ctx, &models.CreateProductRequest{Name: name, Price: models.NewMoney(100, "")})
+		if err != nil {
+			t.Fatal(fmt.Errorf("Error creating product: %w", err))
+		}
+	}
+
+	serve := func(method, url string, cookie *http.Cookie, payload any) *httptest.ResponseRecorder {
+		t.Helper()
+		body := new(bytes.Buffer)
+		if payload != nil {
+			if err := json.NewEncoder(body).Encode(payload); err != nil {
+				t.Fatal(fmt.Errorf("Error encoding JSON payload: %w", err))
+			}
+		}
+		req, err := http.NewRequest(method, url, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("If-Match", "*")
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rr := httptest.NewRecorder()
+		srv.Mux().ServeHTTP(rr, req)
+		return rr
+	}
+	lastWrite := func(rr *httptest.ResponseRecorder) *http.Cookie {
+		for _, cookie := range rr.Result().Cookies() {
+			if cookie.Name == "last_write" {
+				return cookie
+			}
+		}
+		return nil
+	}
+	nameOf := func(rr *httptest.ResponseRecorder) string {
+		t.Helper()
+		var product models.Product
+		if err := json.NewDecoder(rr.Body).Decode(&product); err != nil {
+			t.Fatal(fmt.Errorf("Error decoding JSON response: %w", err))
+		}
+		return product.Name
+	}
+
+	// The first product is cached, then changed behind the cache's back, as if only the primary had the change.
+	rr := serve(http.MethodGet, "/v1/api/products/1", nil, nil)
+	checkEqual(t, lastWrite(rr), (*http.Cookie)(nil), "Read Cookie")
+	checkEqual(t, nameOf(rr), "Product 1", "Name")
+	err := base.storage.UpdateProduct(ctx, &models.Product{ID: 1, Name: "Renamed", Version: storage.AnyVersion})
+	if err != nil {
+		t.Fatal(fmt.Errorf("Error updating product: %w", err))
+	}
+
+	rr = serve(http.MethodDelete, "/v1/api/products/99", nil, nil)
+	checkEqual(t, rr.Code, http.StatusNotFound, "Failed Write Status Code")
+	checkEqual(t, lastWrite(rr), (*http.Cookie)(nil), "Failed Write Cookie")
+
+	rr = serve(http.MethodPut, "/v1/api/products/2", nil, models.CreateProductRequest{Name: "Product 2"})
+	checkEqual(t, rr.Code, http.StatusNoContent, "Write Status Code")
+	cookie := lastWrite(rr)
+	if cookie == nil {
+		t.Fatal("Write Cookie: got none")
+	}
+
+	checkEqual(t, nameOf(serve(http.MethodGet, "/v1/api/products/1", cookie, nil)), "Renamed", "Name After Write")
+	checkEqual(t, nameOf(serve(http.MethodGet, "/v1/api/products/1", nil, nil)), "Product 1", "Name For Other Client")
+	expired := &http.Cookie{Name: "last_write", Value: strconv.FormatInt(time.Now().Add(-time.Hour).UnixMilli(), 10)}
+	checkEqual(t, nameOf(serve(http.MethodGet, "/v1/api/products/1", expired, nil)), "Product 1", "Name After Window")
+}
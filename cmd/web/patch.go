@@ -0,0 +1,164 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// The content type of a JSON Merge Patch (RFC 7396).
+	mergePatchContentType = "application/merge-patch+json"
+	// The content type of a JSON Patch (RFC 6902).
+	jsonPatchContentType = "application/json-patch+json"
+	// The largest patch document that is read, in bytes.
+	maxPatchSize = 1 << 20
+)
+
+// Applies the patch document in the body of r, whose type is given by its Content-Type, to the product request doc.
+// The status code of the error response is returned along with any error.
+func applyPatch(w http.ResponseWriter, r *http.Request, doc []byte) ([]byte, int, error) {
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (contentType != mergePatchContentType && contentType != jsonPatchContentType) {
+		return nil, http.StatusUnsupportedMediaType,
+			fmt.Errorf("Content-Type must be %s or %s", mergePatchContentType, jsonPatchContentType)
+	}
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchSize))
+	if err != nil {
+		return nil, uploadErrorStatus(err), err
+	}
+
+	if contentType == mergePatchContentType {
+		// A merge patch must be an object, or it would replace the whole product.
+		if !bytes.HasPrefix(bytes.TrimSpace(patch), []byte("{")) {
+			return nil, http.StatusBadRequest, errors.New("merge patch must be a JSON object")
+		}
+		result, err := jsonpatch.MergePatch(doc, patch)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		return result, http.StatusOK, nil
+	}
+
+	operations, err := jsonpatch.DecodePatch(patch)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	result, err := operations.Apply(doc)
+	if err != nil {
+		// The patch is well-formed, but cannot be applied to the product as it is (eg. a 'test' operation failed).
+		return nil, http.StatusConflict, err
+	}
+	return result, http.StatusOK, nil
+}
+
+//	@Summary		Patch a product
+//	@Description	Partially updates a product with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902), depending on the 'Content-Type'.
+//	@Description	The patch is applied to the fields of 'CreateProductRequest' of the current product, and the result is validated before it is saved.
+//	@Description	The 'If-Match' header must hold the 'ETag' of the product (or '*' to patch any version).
+//	@ID				patch-product
+//	@Tags			products
+//	@Accept			application/merge-patch+json,application/json-patch+json
+//	@Produce		json
+//	@Param			id			path	int		true	"Product ID"
+//	@Param			If-Match	header	string	true	"ETag of the product"
+//	@Param			patch		body	object	true	"Merge patch or JSON Patch operations"
+//	@Success		204
+//	@Header			204	{string}	ETag			"New product version"
+//	@Failure		400	{object}	errorResponse	"Invalid parameter or patch"
+//	@Failure		404	{object}	errorResponse	"Product not found"
+//	@Failure		409	{object}	errorResponse	"Patch cannot be applied to the product, or SKU or slug is already used"
+//	@Failure		412	{object}	errorResponse	"Product has been modified"
+//	@Failure		413	{object}	errorResponse	"Patch is too large"
+//	@Failure		415	{object}	errorResponse	"Unsupported patch type"
+//	@Failure		422	{object}	errorResponse	"Patched product is invalid"
+//	@Failure		428	{object}	errorResponse	"Missing header 'If-Match'"
+//	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Failure		503	{object}	errorResponse	"Request cancelled"
+//	@Failure		504	{object}	errorResponse	"Request timed out"
+//	@Router			/products/{id} [patch]
+func handlePatchProductByID(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			messages := []string{"Invalid parameter 'id'", "atoi_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		version, err := parseIfMatch(r)
+		if err != nil {
+			respondWithIfMatchError(w, srv.Logger(), err)
+			return
+		}
+
+		// The product is read from the primary database, since a replica may not have its latest version.
+		product, err := srv.Storage().GetProduct(storage.ReadPrimary(r.Context()), id)
+		if err != nil {
+			var notFoundErr *storage.NotFoundError
+			if errors.As(err, &notFoundErr) {
+				messages := []string{"Product not found", "get_product_error", notFoundErr.Error()}
+				respondWithError(w, srv.Logger(), http.StatusNotFound, messages...)
+				return
+			}
+			messages := []string{"Failed to get product", "get_product_error", err.Error()}
+			respondWithError(w, srv.Logger(), statusFromError(err), messages...)
+			return
+		}
+		// The patch is applied to the product as it is now, so it is only saved if the product is not modified meanwhile.
+		if version == storage.AnyVersion {
+			version = product.Version
+		}
+		if version != product.Version {
+			operation := fmt.Sprintf("PatchProduct(%d)", id)
+			respondWithUpdateError(w, srv.Logger(), &storage.VersionConflictError{Operation: operation, Expected: version, Actual: product.Version})
+			return
+		}
+
+		doc, err := json.Marshal(product.ToCreateProductRequest())
+		if err != nil {
+			messages := []string{"Failed to patch product", "marshal_product_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusInternalServerError, messages...)
+			return
+		}
+		patched, status, err := applyPatch(w, r, doc)
+		if err != nil {
+			messages := []string{"Failed to apply patch", "apply_patch_error", err.Error()}
+			respondWithError(w, srv.Logger(), status, messages...)
+			return
+		}
+
+		var createProductReq models.CreateProductRequest
+		decoder := json.NewDecoder(bytes.NewReader(patched))
+		decoder.DisallowUnknownFields()
+		if err = decoder.Decode(&createProductReq); err == nil {
+			err = createProductReq.Validate()
+		}
+		if err != nil {
+			messages := []string{"Invalid product", "validate_product_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusUnprocessableEntity, messages...)
+			return
+		}
+
+		updated := createProductReq.ToProduct(id)
+		updated.Version = version
+		err = srv.Storage().UpdateProduct(r.Context(), updated)
+		if err != nil {
+			respondWithUpdateError(w, srv.Logger(), err)
+			return
+		}
+
+		w.Header().Set("ETag", etag(updated.Version))
+		respondWithJSON(w, srv.Logger(), http.StatusNoContent, nil)
+	}
+}
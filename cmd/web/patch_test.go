@@ -0,0 +1,84 @@
+package web_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+)
+
+// Tests patching a product with merge patches and JSON Patches.
+// Each step is run in order against the same product, whose version is incremented by every successful patch.
+func TestServer_PatchProduct(t *testing.T) {
+	srv := newTestServer()
+	srv.MountHandlers()
+
+	_, err := srv.Storage().CreateProduct(context.Background(), &models.CreateProductRequest{
+		Name:          "Test Product",
+		Description:   "Soft cotton",
+		Price:         models.NewMoney(1000, models.DefaultCurrency),
+		StockQuantity: 10,
+	})
+	if err != nil {
+		t.Fatal(fmt.Errorf("Error creating product: %w", err))
+	}
+
+	const mergePatch, jsonPatch = "application/merge-patch+json", "application/json-patch+json"
+	steps := []struct {
+		name               string
+		url                string
+		contentType        string
+		ifMatch            string
+		patch              string
+		expectedStatusCode int
+	}{
+		{"merge stock", "/v1/api/products/1", mergePatch, `"1"`, `{"stock_quantity": 5}`, http.StatusNoContent},
+		{"stale version", "/v1/api/products/1", mergePatch, `"1"`, `{"stock_quantity": 6}`, http.StatusPreconditionFailed},
+		{"missing If-Match", "/v1/api/products/1", mergePatch, "", `{"stock_quantity": 6}`, http.StatusPreconditionRequired},
+		{"missing product", "/v1/api/products/9", mergePatch, "*", `{"stock_quantity": 6}`, http.StatusNotFound},
+		{"plain JSON", "/v1/api/products/1", "application/json", "*", `{"stock_quantity": 6}`, http.StatusUnsupportedMediaType},
+		{"merge array", "/v1/api/products/1", mergePatch, "*", `[]`, http.StatusBadRequest},
+		{"merge invalid price", "/v1/api/products/1", mergePatch, "*", `{"price": "-1.00"}`, http.StatusUnprocessableEntity},
+		{"merge currency", "/v1/api/products/1", mergePatch, "*", `{"currency": "USD"}`, http.StatusUnprocessableEntity},
+		{"merge unknown field", "/v1/api/products/1", mergePatch, "*", `{"colour": "red"}`, http.StatusUnprocessableEntity},
+		{
+			"json patch price", "/v1/api/products/1", jsonPatch, `"2"`,
+			`[{"op": "test", "path": "/stock_quantity", "value": 5}, {"op": "replace", "path": "/price", "value": "12.50"}]`,
+			http.StatusNoContent,
+		},
+		{
+			"json patch failed test", "/v1/api/products/1", jsonPatch, "*",
+			`[{"op": "test", "path": "/stock_quantity", "value": 10}, {"op": "replace", "path": "/stock_quantity", "value": 0}]`,
+			http.StatusConflict,
+		},
+		{"json patch malformed", "/v1/api/products/1", jsonPatch, "*", `{"op": "replace"}`, http.StatusBadRequest},
+		{"merge clear description", "/v1/api/products/1", mergePatch, "*", `{"description": null}`, http.StatusNoContent},
+	}
+
+	for _, step := range steps {
+		req := httptest.NewRequest(http.MethodPatch, step.url, strings.NewReader(step.patch))
+		req.Header.Set("Content-Type", step.contentType)
+		if step.ifMatch != "" {
+			req.Header.Set("If-Match", step.ifMatch)
+		}
+		rr := httptest.NewRecorder()
+
+		srv.Mux().ServeHTTP(rr, req)
+
+		checkEqual(t, rr.Code, step.expectedStatusCode, step.name+": Status Code")
+	}
+
+	product, err := srv.Storage().GetProduct(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, product.Name, "Test Product", "Name")
+	checkEqual(t, product.Description.Valid, false, "Description")
+	checkEqual(t, product.Price, models.NewMoney(1250, models.DefaultCurrency), "Price")
+	checkEqual(t, product.StockQuantity, 5, "Stock Quantity")
+	checkEqual(t, product.Version, 4, "Version")
+}
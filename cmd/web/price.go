@@ -0,0 +1,290 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+func ExchangeRateRoutes(srv Server) *chi.Mux {
+	router := chi.NewRouter()
+
+	router.With(withDeadline(readDeadline)).Get("/", handleGetExchangeRates(srv))
+	router.With(withDeadline(writeDeadline)).Put("/{currency}", handleSetExchangeRate(srv))
+
+	return router
+}
+
+// Returns the currency that the prices of the response to r should be in: the 'currency' query parameter,
+// or else the Accept-Currency header, or else the DefaultCurrency. An error is returned if it is not a currency code.
+func requestCurrency(r *http.Request) (string, error) {
+	currency := r.URL.Query().Get("currency")
+	if currency == "" {
+		currency = r.Header.Get("Accept-Currency")
+	}
+	if currency == "" {
+		return models.DefaultCurrency, nil
+	}
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !models.ValidCurrency(currency) {
+		return "", fmt.Errorf("invalid currency %q", currency)
+	}
+	return currency, nil
+}
+
+// Returns the error messages and status code of a failure to convert prices with storage.ConvertPrices.
+func convertPricesError(err error) (int, []string) {
+	var unsupportedErr *storage.UnsupportedCurrencyError
+	if errors.As(err, &unsupportedErr) {
+		return http.StatusBadRequest, []string{"Unsupported currency", "convert_prices_error", unsupportedErr.Error()}
+	}
+	return statusFromError(err), []string{"Failed to convert prices", "convert_prices_error", err.Error()}
+}
+
+// productPrice is the price of a product in a currency.
+type productPrice struct {
+	// Price is encoded as a decimal string.
+	Price    models.Money `json:"price" swaggertype:"string" example:"14.99"`
+	Currency string       `json:"currency" example:"USD"`
+}
+
+// pricesResponse is the list of prices set for a product.
+type pricesResponse struct {
+	Prices []productPrice `json:"prices"`
+}
+
+//	@Summary		Get the prices of a product
+//	@Description	Retrieves the prices set for a product in currencies other than the default currency.
+//	@Description	In other currencies its price is converted using their exchange rate.
+//	@ID				get-product-prices
+//	@Tags			prices
+//	@Produce		json
+//	@Param			id	path		int				true	"Product ID"
+//	@Success		200	{object}	pricesResponse	"Prices"
+//	@Failure		400	{object}	errorResponse	"Invalid parameter 'id'"
+//	@Failure		404	{object}	errorResponse	"Product not found"
+//	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Failure		503	{object}	errorResponse	"Request cancelled"
+//	@Failure		504	{object}	errorResponse	"Request timed out"
+//	@Router			/products/{id}/prices [get]
+func handleGetProductPrices(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			messages := []string{"Invalid parameter 'id'", "atoi_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		prices, err := srv.Storage().GetPrices(r.Context(), id)
+		if err != nil {
+			var notFoundErr *storage.NotFoundError
+			if errors.As(err, &notFoundErr) {
+				messages := []string{"Product not found", "get_prices_error", notFoundErr.Error()}
+				respondWithError(w, srv.Logger(), http.StatusNotFound, messages...)
+				return
+			}
+			messages := []string{"Failed to get prices", "get_prices_error", err.Error()}
+			respondWithError(w, srv.Logger(), statusFromError(err), messages...)
+			return
+		}
+
+		response := pricesResponse{Prices: make([]productPrice, len(prices))}
+		for i, price := range prices {
+			response.Prices[i] = productPrice{Price: price, Currency: price.Currency}
+		}
+		respondWithJSON(w, srv.Logger(), http.StatusOK, response)
+	}
+}
+
+//	@Summary		Set the price of a product in a currency
+//	@Description	Sets the price of a product in a currency other than the default currency, instead of converting its price.
+//	@ID				set-product-price
+//	@Tags			prices
+//	@Accept			json
+//	@Param			id			path	int		true	"Product ID"
+//	@Param			currency	path	string	true	"ISO 4217 currency code"
+//	@Param			price		body	string	true	"Price as a decimal string (eg. '14.99')"
+//	@Success		204
+//	@Failure		400	{object}	errorResponse	"Invalid parameter or price"
+//	@Failure		404	{object}	errorResponse	"Product not found"
+//	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Failure		503	{object}	errorResponse	"Request cancelled"
+//	@Failure		504	{object}	errorResponse	"Request timed out"
+//	@Router			/products/{id}/prices/{currency} [put]
+func handleSetProductPrice(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			messages := []string{"Invalid parameter 'id'", "atoi_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+		currency, err := parseCurrencyParam(r)
+		if err != nil {
+			messages := []string{"Invalid parameter 'currency'", "validation_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		var price models.Money
+		err = parseJSONBody(r, &price)
+		if err != nil {
+			messages := []string{"Failed to parse JSON payload", "parse_json_body_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+		if price.IsNegative() {
+			messages := []string{"Invalid price", "validation_error", "price must not be negative"}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+		price.Currency = currency
+
+		err = srv.Storage().SetPrice(r.Context(), id, price)
+		if err != nil {
+			var notFoundErr *storage.NotFoundError
+			if errors.As(err, &notFoundErr) {
+				messages := []string{"Product not found", "set_price_error", notFoundErr.Error()}
+				respondWithError(w, srv.Logger(), http.StatusNotFound, messages...)
+				return
+			}
+			messages := []string{"Failed to set price", "set_price_error", err.Error()}
+			respondWithError(w, srv.Logger(), statusFromError(err), messages...)
+			return
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusNoContent, nil)
+	}
+}
+
+//	@Summary		Delete the price of a product in a currency
+//	@Description	Deletes the price set for a product in a currency, so that its price is converted to the currency instead.
+//	@ID				delete-product-price
+//	@Tags			prices
+//	@Param			id			path	int		true	"Product ID"
+//	@Param			currency	path	string	true	"ISO 4217 currency code"
+//	@Success		204
+//	@Failure		400	{object}	errorResponse	"Invalid parameter"
+//	@Failure		404	{object}	errorResponse	"Price not found"
+//	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Failure		503	{object}	errorResponse	"Request cancelled"
+//	@Failure		504	{object}	errorResponse	"Request timed out"
+//	@Router			/products/{id}/prices/{currency} [delete]
+func handleDeleteProductPrice(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			messages := []string{"Invalid parameter 'id'", "atoi_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+		currency, err := parseCurrencyParam(r)
+		if err != nil {
+			messages := []string{"Invalid parameter 'currency'", "validation_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		err = srv.Storage().DeletePrice(r.Context(), id, currency)
+		if err != nil {
+			var notFoundErr *storage.NotFoundError
+			if errors.As(err, &notFoundErr) {
+				messages := []string{"Price not found", "delete_price_error", notFoundErr.Error()}
+				respondWithError(w, srv.Logger(), http.StatusNotFound, messages...)
+				return
+			}
+			messages := []string{"Failed to delete price", "delete_price_error", err.Error()}
+			respondWithError(w, srv.Logger(), statusFromError(err), messages...)
+			return
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusNoContent, nil)
+	}
+}
+
+// Parses the 'currency' URL parameter of r, which must be a currency other than the DefaultCurrency.
+func parseCurrencyParam(r *http.Request) (string, error) {
+	currency := strings.ToUpper(chi.URLParam(r, "currency"))
+	if !models.ValidCurrency(currency) {
+		return "", fmt.Errorf("invalid currency %q", currency)
+	}
+	if currency == models.DefaultCurrency {
+		return "", fmt.Errorf("prices in %s are set on the product", models.DefaultCurrency)
+	}
+	return currency, nil
+}
+
+// exchangeRatesResponse is the list of exchange rates.
+type exchangeRatesResponse struct {
+	Rates []models.ExchangeRate `json:"rates"`
+}
+
+//	@Summary		Get exchange rates
+//	@Description	Retrieves the exchange rates used to convert prices from the default currency.
+//	@ID				get-exchange-rates
+//	@Tags			prices
+//	@Produce		json
+//	@Success		200	{object}	exchangeRatesResponse	"Exchange rates"
+//	@Failure		500	{object}	errorResponse			"Internal Server Error"
+//	@Failure		503	{object}	errorResponse			"Request cancelled"
+//	@Failure		504	{object}	errorResponse			"Request timed out"
+//	@Router			/exchange-rates [get]
+func handleGetExchangeRates(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rates, err := srv.Storage().GetExchangeRates(r.Context())
+		if err != nil {
+			messages := []string{"Failed to get exchange rates", "get_exchange_rates_error", err.Error()}
+			respondWithError(w, srv.Logger(), statusFromError(err), messages...)
+			return
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusOK, exchangeRatesResponse{rates})
+	}
+}
+
+//	@Summary		Set an exchange rate
+//	@Description	Sets the amount of a currency that one unit of the default currency is worth, with at most 8 decimal places.
+//	@ID				set-exchange-rate
+//	@Tags			prices
+//	@Accept			json
+//	@Param			currency	path	string							true	"ISO 4217 currency code"
+//	@Param			rate		body	models.SetExchangeRateRequest	true	"Exchange rate"
+//	@Success		204
+//	@Failure		400	{object}	errorResponse	"Invalid exchange rate"
+//	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Failure		503	{object}	errorResponse	"Request cancelled"
+//	@Failure		504	{object}	errorResponse	"Request timed out"
+//	@Router			/exchange-rates/{currency} [put]
+func handleSetExchangeRate(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req models.SetExchangeRateRequest
+		err := parseJSONBody(r, &req)
+		if err != nil {
+			messages := []string{"Failed to parse JSON payload", "parse_json_body_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+		rate, err := models.ParseExchangeRate(strings.ToUpper(chi.URLParam(r, "currency")), req.Rate)
+		if err != nil {
+			messages := []string{"Invalid exchange rate", "validation_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		err = srv.Storage().SetExchangeRate(r.Context(), rate)
+		if err != nil {
+			messages := []string{"Failed to set exchange rate", "set_exchange_rate_error", err.Error()}
+			respondWithError(w, srv.Logger(), statusFromError(err), messages...)
+			return
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusNoContent, nil)
+	}
+}
@@ -0,0 +1,155 @@
+package web_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+)
+
+// Tests setting exchange rates and prices, and getting products with prices in other currencies.
+// Each step is run in order against the same product, which costs 10.00 in the default currency.
+func TestServer_PriceRoutes(t *testing.T) {
+	srv := newTestServer()
+	srv.MountHandlers()
+
+	productID, err := srv.Storage().CreateProduct(context.Background(), &models.CreateProductRequest{
+		Name:  "Test Product",
+		Price: models.NewMoney(1000, models.DefaultCurrency),
+	})
+	if err != nil {
+		t.Fatal(fmt.Errorf("Error creating product: %w", err))
+	}
+	url := fmt.Sprint("/v1/api/products/", productID)
+
+	steps := []struct {
+		name               string
+		method             string
+		url                string
+		currency           string
+		payload            any
+		expectedStatusCode int
+		// expectedPrice is the price of the product in the response, if it is not empty.
+		expectedPrice string
+	}{
+		{
+			"get without rate", http.MethodGet, url + "?currency=USD", "", nil,
+			http.StatusBadRequest, "",
+		},
+		{
+			"set rate", http.MethodPut, "/v1/api/exchange-rates/usd", "", models.SetExchangeRateRequest{Rate: "0.65"},
+			http.StatusNoContent, "",
+		},
+		{
+			"set default currency rate", http.MethodPut, "/v1/api/exchange-rates/" + models.DefaultCurrency, "",
+			models.SetExchangeRateRequest{Rate: "1"},
+			http.StatusBadRequest, "",
+		},
+		{
+			"set negative rate", http.MethodPut, "/v1/api/exchange-rates/EUR", "", models.SetExchangeRateRequest{Rate: "-0.6"},
+			http.StatusBadRequest, "",
+		},
+		{
+			"get default currency", http.MethodGet, url, "", nil,
+			http.StatusOK, "10.00 " + models.DefaultCurrency,
+		},
+		{
+			"get converted", http.MethodGet, url + "?currency=USD", "", nil,
+			http.StatusOK, "6.50 USD",
+		},
+		{
+			"get converted by header", http.MethodGet, url, "usd", nil,
+			http.StatusOK, "6.50 USD",
+		},
+		{
+			"get invalid currency", http.MethodGet, url + "?currency=dollars", "", nil,
+			http.StatusBadRequest, "",
+		},
+		{
+			"set price", http.MethodPut, url + "/prices/USD", "", "5.99",
+			http.StatusNoContent, "",
+		},
+		{
+			"get overridden", http.MethodGet, url, "USD", nil,
+			http.StatusOK, "5.99 USD",
+		},
+		{
+			"set invalid price", http.MethodPut, url + "/prices/USD", "", "5.999",
+			http.StatusBadRequest, "",
+		},
+		{
+			"set price in default currency", http.MethodPut, url + "/prices/" + models.DefaultCurrency, "", models.NewMoney(599, ""),
+			http.StatusBadRequest, "",
+		},
+		{
+			"set price not found", http.MethodPut, "/v1/api/products/200/prices/USD", "", models.NewMoney(599, ""),
+			http.StatusNotFound, "",
+		},
+		{
+			"get prices", http.MethodGet, url + "/prices", "", nil,
+			http.StatusOK, "",
+		},
+		{
+			"delete price", http.MethodDelete, url + "/prices/USD", "", nil,
+			http.StatusNoContent, "",
+		},
+		{
+			"delete deleted price", http.MethodDelete, url + "/prices/USD", "", nil,
+			http.StatusNotFound, "",
+		},
+		{
+			"get converted after delete", http.MethodGet, url, "USD", nil,
+			http.StatusOK, "6.50 USD",
+		},
+		{
+			"get rates", http.MethodGet, "/v1/api/exchange-rates", "", nil,
+			http.StatusOK, "",
+		},
+	}
+
+	for _, step := range steps {
+		body := new(bytes.Buffer)
+		if step.payload != nil {
+			if err = json.NewEncoder(body).Encode(step.payload); err != nil {
+				t.Fatal(fmt.Errorf("Error encoding JSON payload: %w", err))
+			}
+		}
+		req, err := http.NewRequest(step.method, step.url, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if step.currency != "" {
+			req.Header.Set("Accept-Currency", step.currency)
+		}
+		rr := httptest.NewRecorder()
+
+		srv.Mux().ServeHTTP(rr, req)
+
+		checkEqual(t, rr.Code, step.expectedStatusCode, step.name+": Status Code")
+		if step.expectedPrice != "" {
+			var product models.Product
+			if err = json.NewDecoder(rr.Body).Decode(&product); err != nil {
+				t.Fatal(fmt.Errorf("Error decoding JSON response: %w", err))
+			}
+			checkEqual(t, product.Price.String()+" "+product.Price.Currency, step.expectedPrice, step.name+": Price")
+		}
+	}
+
+	// Each price is listed as a decimal string with its currency.
+	if err = srv.Storage().SetPrice(context.Background(), productID, models.NewMoney(599, "USD")); err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodGet, url+"/prices", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	srv.Mux().ServeHTTP(rr, req)
+	checkEqual(t, strings.TrimSpace(rr.Body.String()), `{"prices":[{"price":"5.99","currency":"USD"}]}`, "Prices")
+}
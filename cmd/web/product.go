@@ -1,58 +1,324 @@
 package web
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Broderick-Westrope/e-gommerce/internal/config"
 	"github.com/Broderick-Westrope/e-gommerce/internal/models"
 	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
 	"github.com/go-chi/chi/v5"
 )
 
+const (
+	// The deadline given to routes that only read products.
+	readDeadline = 5 * time.Second
+	// The deadline given to routes that modify products.
+	writeDeadline = 10 * time.Second
+	// The prefix of the query parameters that filter products by the value of an attribute, as in 'attr.material'.
+	attributeParamPrefix = "attr."
+)
+
 func ProductRoutes(srv Server) *chi.Mux {
 	router := chi.NewRouter()
+	router.Use(withAudit)
 
-	router.Get("/", handleGetProducts(srv))
-	router.Get("/{id}", handleGetProductByID(srv))
-	router.Post("/", handleCreateProduct(srv))
-	router.Put("/{id}", handleUpdateProductByID(srv))
-	router.Delete("/{id}", handleDeleteProductByID(srv))
+	router.With(withDeadline(readDeadline)).Get("/", handleGetProducts(srv))
+	router.With(withDeadline(readDeadline)).Get("/search", handleSearchProducts(srv))
+	router.With(withDeadline(readDeadline)).Get("/trash", handleGetTrashedProducts(srv))
+	router.With(withDeadline(readDeadline)).Get("/by-slug/{slug}", handleGetProductBySlug(srv))
+	router.With(withDeadline(readDeadline)).Get("/by-sku/{sku}", handleGetProductBySKU(srv))
+	router.With(withDeadline(readDeadline)).Get("/{id}", handleGetProductByID(srv))
+	router.With(withDeadline(writeDeadline)).Post("/", handleCreateProduct(srv))
+	router.With(withDeadline(bulkDeadline)).Post("/bulk", handleBulkProducts(srv))
+	router.With(withDeadline(writeDeadline)).Put("/{id}", handleUpdateProductByID(srv))
+	router.With(withDeadline(writeDeadline)).Patch("/{id}", handlePatchProductByID(srv))
+	router.With(withDeadline(writeDeadline)).Delete("/{id}", handleDeleteProductByID(srv))
+	router.With(withDeadline(writeDeadline)).Post("/{id}/restore", handleRestoreProductByID(srv))
+	router.With(withDeadline(readDeadline)).Get("/{id}/history", handleGetProductHistory(srv))
+	router.With(withDeadline(writeDeadline)).Post("/{id}/reservations", handleReserveStock(srv))
+	router.With(withDeadline(writeDeadline)).Delete("/{id}/reservations/{reservationID}", handleReleaseStock(srv))
+	router.With(withDeadline(writeDeadline)).Post("/{id}/reservations/{reservationID}/commit", handleCommitReservation(srv))
+	router.With(withDeadline(readDeadline)).Get("/{id}/prices", handleGetProductPrices(srv))
+	router.With(withDeadline(writeDeadline)).Put("/{id}/prices/{currency}", handleSetProductPrice(srv))
+	router.With(withDeadline(writeDeadline)).Delete("/{id}/prices/{currency}", handleDeleteProductPrice(srv))
+	router.With(withDeadline(writeDeadline)).Put("/{id}/categories", handleSetProductCategories(srv))
+	router.With(withDeadline(writeDeadline)).Put("/{id}/tags", handleSetProductTags(srv))
+	router.With(withDeadline(writeDeadline)).Put("/{id}/attributes", handleSetProductAttributes(srv))
+	router.With(withDeadline(readDeadline)).Get("/{id}/images", handleGetImages(srv))
+	router.With(withDeadline(writeDeadline)).Post("/{id}/images", handleUploadImage(srv))
+	router.With(withDeadline(readDeadline)).Get("/{id}/images/{imageID}", handleGetImage(srv))
+	router.With(withDeadline(writeDeadline)).Delete("/{id}/images/{imageID}", handleDeleteImage(srv))
+	router.With(withDeadline(readDeadline)).Get("/{id}/variants", handleGetVariants(srv))
+	router.With(withDeadline(writeDeadline)).Post("/{id}/variants", handleCreateVariant(srv))
+	router.With(withDeadline(readDeadline)).Get("/{id}/variants/{variantID}", handleGetVariant(srv))
+	router.With(withDeadline(writeDeadline)).Put("/{id}/variants/{variantID}", handleUpdateVariant(srv))
+	router.With(withDeadline(writeDeadline)).Delete("/{id}/variants/{variantID}", handleDeleteVariant(srv))
 
 	return router
 }
 
-//	@Summary		Get all products
-//	@Description	Retrieves all products.
+// productsResponse is a single page of products.
+type productsResponse struct {
+	Products   []models.Product `json:"products"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+//	@Summary		Get products
+//	@Description	Retrieves a page of products matching the filters, in the order given by 'sort'.
+//	@Description	When there are more products the response includes a 'next_cursor' and a 'Link' header pointing to the next page.
+//	@Description	Prices are converted to the requested currency, unless a product has a price set in that currency.
 //	@ID				get-products
 //	@Tags			products
 //	@Produce		json
-//	@Success		200	{array}		models.Product	"Products"
-//	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Param			limit			query		int					false	"Maximum number of products in the page (1-100)"	default(20)
+//	@Param			after			query		string				false	"Cursor returned as 'next_cursor' by the previous page"
+//	@Param			min_price		query		number				false	"Minimum price in the default currency (inclusive)"
+//	@Param			max_price		query		number				false	"Maximum price in the default currency (inclusive)"
+//	@Param			in_stock		query		bool				false	"Only products with (true) or without (false) stock"
+//	@Param			q				query		string				false	"Text that the name or description must contain"
+//	@Param			category		query		string				false	"ID or slug of a category that the products must be in, including its subcategories"
+//	@Param			tag				query		[]string			false	"Tags that the products must all have"	collectionFormat(multi)
+//	@Param			attr.{name}		query		string				false	"Text form of the value that attribute '{name}' must have (eg. 'attr.material=cotton')"
+//	@Param			sort			query		string				false	"Field to sort by, prefixed with '-' for descending order"	Enums(id, -id, name, -name, price, -price, stock_quantity, -stock_quantity)
+//	@Param			currency		query		string				false	"Currency of the prices (overrides 'Accept-Currency')"
+//	@Param			Accept-Currency	header		string				false	"Currency of the prices"
+//	@Success		200				{object}	productsResponse	"Products"
+//	@Header			200				{string}	Link				"Link to the next page"
+//	@Failure		400				{object}	errorResponse		"Invalid parameter"
+//	@Failure		500				{object}	errorResponse		"Internal Server Error"
+//	@Failure		503				{object}	errorResponse		"Request cancelled"
+//	@Failure		504				{object}	errorResponse		"Request timed out"
 //	@Router			/products [get]
 func handleGetProducts(srv Server) http.HandlerFunc {
+	return listProducts(srv, false)
+}
+
+//	@Summary		Get products in the trash
+//	@Description	Retrieves a page of the deleted products that can be restored, with the same parameters as 'get-products'.
+//	@ID				get-trashed-products
+//	@Tags			products
+//	@Produce		json
+//	@Param			limit			query		int					false	"Maximum number of products in the page (1-100)"	default(20)
+//	@Param			after			query		string				false	"Cursor returned as 'next_cursor' by the previous page"
+//	@Param			min_price		query		number				false	"Minimum price in the default currency (inclusive)"
+//	@Param			max_price		query		number				false	"Maximum price in the default currency (inclusive)"
+//	@Param			in_stock		query		bool				false	"Only products with (true) or without (false) stock"
+//	@Param			q				query		string				false	"Text that the name or description must contain"
+//	@Param			category		query		string				false	"ID or slug of a category that the products must be in, including its subcategories"
+//	@Param			tag				query		[]string			false	"Tags that the products must all have"	collectionFormat(multi)
+//	@Param			attr.{name}		query		string				false	"Text form of the value that attribute '{name}' must have (eg. 'attr.material=cotton')"
+//	@Param			sort			query		string				false	"Field to sort by, prefixed with '-' for descending order"	Enums(id, -id, name, -name, price, -price, stock_quantity, -stock_quantity)
+//	@Param			currency		query		string				false	"Currency of the prices (overrides 'Accept-Currency')"
+//	@Param			Accept-Currency	header		string				false	"Currency of the prices"
+//	@Success		200				{object}	productsResponse	"Products"
+//	@Header			200				{string}	Link				"Link to the next page"
+//	@Failure		400				{object}	errorResponse		"Invalid parameter"
+//	@Failure		500				{object}	errorResponse		"Internal Server Error"
+//	@Failure		503				{object}	errorResponse		"Request cancelled"
+//	@Failure		504				{object}	errorResponse		"Request timed out"
+//	@Router			/products/trash [get]
+func handleGetTrashedProducts(srv Server) http.HandlerFunc {
+	return listProducts(srv, true)
+}
+
+// Returns a handler that responds with a page of the products matching the query string,
+// listing the products in the trash instead when trash is true.
+func listProducts(srv Server, trash bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		products, err := srv.Storage().GetProducts()
+		query, param, err := parseProductQuery(r)
 		if err != nil {
+			messages := []string{fmt.Sprintf("Invalid parameter '%s'", param), "parse_query_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+		query.Deleted = trash
+		currency, err := requestCurrency(r)
+		if err != nil {
+			messages := []string{"Invalid parameter 'currency'", "parse_query_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+		if category := r.URL.Query().Get("category"); category != "" {
+			query.CategoryIDs, err = categorySubtree(r.Context(), srv.Storage(), category)
+			if err != nil {
+				messages := []string{"Failed to get categories", "get_categories_error", err.Error()}
+				respondWithError(w, srv.Logger(), statusFromError(err), messages...)
+				return
+			}
+			if query.CategoryIDs == nil {
+				messages := []string{"Invalid parameter 'category'", "parse_query_error", fmt.Sprintf("unknown category %q", category)}
+				respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+				return
+			}
+		}
+
+		page, err := srv.Storage().GetProducts(r.Context(), query)
+		if err != nil {
+			var invalidCursorErr *storage.InvalidCursorError
+			if errors.As(err, &invalidCursorErr) {
+				messages := []string{"Invalid parameter 'after'", "get_products_error", invalidCursorErr.Error()}
+				respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+				return
+			}
 			messages := []string{"Failed to get products", "get_products_error", err.Error()}
-			respondWithError(w, srv.Logger(), http.StatusInternalServerError, messages...)
+			respondWithError(w, srv.Logger(), statusFromError(err), messages...)
+			return
+		}
+
+		if err = storage.ConvertPrices(r.Context(), srv.Storage(), page.Products, currency); err != nil {
+			status, messages := convertPricesError(err)
+			respondWithError(w, srv.Logger(), status, messages...)
 			return
 		}
 
-		respondWithJSON(w, srv.Logger(), http.StatusOK, products)
+		w.Header().Set("Vary", "Accept-Currency")
+		if page.NextCursor != "" {
+			w.Header().Set("Link", nextPageLink(r, page.NextCursor))
+		}
+		respondWithJSON(w, srv.Logger(), http.StatusOK, productsResponse{page.Products, page.NextCursor})
+	}
+}
+
+// Parses the pagination, filter and sort parameters of the Get Products route from the query string of r.
+// If a parameter is invalid, its name is returned along with the error.
+func parseProductQuery(r *http.Request) (*storage.ProductQuery, string, error) {
+	values := r.URL.Query()
+	query := &storage.ProductQuery{After: values.Get("after"), Search: values.Get("q")}
+
+	var err error
+	if limit := values.Get("limit"); limit != "" {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			return nil, "limit", err
+		}
+		if query.Limit < 1 || query.Limit > storage.MaxPageLimit {
+			return nil, "limit", fmt.Errorf("limit must be between 1 and %d", storage.MaxPageLimit)
+		}
+	}
+	for _, param := range []string{"min_price", "max_price"} {
+		value := values.Get(param)
+		if value == "" {
+			continue
+		}
+		var price models.Money
+		price, err = models.ParseMoney(value, models.DefaultCurrency)
+		if err != nil {
+			return nil, param, err
+		}
+		if price.IsNegative() {
+			return nil, param, errors.New("price must not be negative")
+		}
+		if param == "min_price" {
+			query.MinPrice = &price
+		} else {
+			query.MaxPrice = &price
+		}
+	}
+	if inStock := values.Get("in_stock"); inStock != "" {
+		var b bool
+		b, err = strconv.ParseBool(inStock)
+		if err != nil {
+			return nil, "in_stock", err
+		}
+		query.InStock = &b
+	}
+	for _, tag := range values["tag"] {
+		query.Tags = append(query.Tags, models.NormalizeTag(tag))
+	}
+	for param, attribute := range values {
+		name, ok := strings.CutPrefix(param, attributeParamPrefix)
+		if !ok {
+			continue
+		}
+		if !models.ValidAttributeName(name) {
+			return nil, param, fmt.Errorf("invalid attribute name %q", name)
+		}
+		if query.Attributes == nil {
+			query.Attributes = make(map[string]string)
+		}
+		query.Attributes[name] = attribute[0]
+	}
+	query.Sort, err = storage.ParseProductSort(values.Get("sort"))
+	if err != nil {
+		return nil, "sort", err
+	}
+	return query, "", nil
+}
+
+// searchResponse is the list of products that matched a search.
+type searchResponse struct {
+	Results []models.SearchResult `json:"results"`
+}
+
+//	@Summary		Search products
+//	@Description	Retrieves the products whose name or description match the search, ordered by descending relevance.
+//	@Description	Each result has a snippet of the matching text with the matched terms wrapped in <mark> tags.
+//	@ID				search-products
+//	@Tags			products
+//	@Produce		json
+//	@Param			q		query		string			true	"Search text"
+//	@Param			limit	query		int				false	"Maximum number of results (1-100)"	default(20)
+//	@Success		200		{object}	searchResponse	"Search results"
+//	@Failure		400		{object}	errorResponse	"Invalid parameter"
+//	@Failure		500		{object}	errorResponse	"Internal Server Error"
+//	@Failure		503		{object}	errorResponse	"Request cancelled"
+//	@Failure		504		{object}	errorResponse	"Request timed out"
+//	@Router			/products/search [get]
+func handleSearchProducts(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("q")
+		if strings.TrimSpace(q) == "" {
+			messages := []string{"Invalid parameter 'q'", "parse_query_error", "q must not be empty"}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+		limit := storage.DefaultPageLimit
+		if value := r.URL.Query().Get("limit"); value != "" {
+			var err error
+			limit, err = strconv.Atoi(value)
+			if err == nil && (limit < 1 || limit > storage.MaxPageLimit) {
+				err = fmt.Errorf("limit must be between 1 and %d", storage.MaxPageLimit)
+			}
+			if err != nil {
+				messages := []string{"Invalid parameter 'limit'", "parse_query_error", err.Error()}
+				respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+				return
+			}
+		}
+
+		results, err := srv.Storage().SearchProducts(r.Context(), q, limit)
+		if err != nil {
+			messages := []string{"Failed to search products", "search_products_error", err.Error()}
+			respondWithError(w, srv.Logger(), statusFromError(err), messages...)
+			return
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusOK, searchResponse{results})
 	}
 }
 
 //	@Summary		Get a product
 //	@Description	Retrieves a product by ID.
+//	@Description	The 'ETag' header holds the version of the product, which is required to update or delete it.
 //	@ID				get-product
 //	@Tags			products
 //	@Produce		json
-//	@Param			id	path		int				true	"Product ID"
-//	@Success		200	{object}	models.Product	"Product"
-//	@Failure		400	{object}	errorResponse	"Invalid parameter 'id'"
-//	@Failure		404	{object}	errorResponse	"Product not found"
-//	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Param			id				path		int				true	"Product ID"
+//	@Param			currency		query		string			false	"Currency of the price (overrides 'Accept-Currency')"
+//	@Param			Accept-Currency	header		string			false	"Currency of the price"
+//	@Success		200				{object}	models.Product	"Product"
+//	@Header			200				{string}	ETag			"Product version"
+//	@Failure		400				{object}	errorResponse	"Invalid parameter or unsupported currency"
+//	@Failure		404				{object}	errorResponse	"Product not found"
+//	@Failure		500				{object}	errorResponse	"Internal Server Error"
+//	@Failure		503				{object}	errorResponse	"Request cancelled"
+//	@Failure		504				{object}	errorResponse	"Request timed out"
 //	@Router			/products/{id} [get]
 func handleGetProductByID(srv Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -63,21 +329,98 @@ func handleGetProductByID(srv Server) http.HandlerFunc {
 			return
 		}
 
-		product, err := srv.Storage().GetProduct(id)
-		if err != nil {
-			var notFoundErr *storage.NotFoundError
-			if errors.As(err, &notFoundErr) {
-				messages := []string{"Product not found", "get_product_error", notFoundErr.Error()}
-				respondWithError(w, srv.Logger(), http.StatusNotFound, messages...)
-				return
-			}
-			messages := []string{"Failed to get product", "get_product_error", err.Error()}
-			respondWithError(w, srv.Logger(), http.StatusInternalServerError, messages...)
+		respondWithProduct(w, r, srv, func(ctx context.Context) (*models.Product, error) {
+			return srv.Storage().GetProduct(ctx, id)
+		})
+	}
+}
+
+//	@Summary		Get a product by slug
+//	@Description	Retrieves a product by its slug.
+//	@Description	The 'ETag' header holds the version of the product, which is required to update or delete it.
+//	@ID				get-product-by-slug
+//	@Tags			products
+//	@Produce		json
+//	@Param			slug			path		string			true	"Product slug"
+//	@Param			currency		query		string			false	"Currency of the price (overrides 'Accept-Currency')"
+//	@Param			Accept-Currency	header		string			false	"Currency of the price"
+//	@Success		200				{object}	models.Product	"Product"
+//	@Header			200				{string}	ETag			"Product version"
+//	@Failure		400				{object}	errorResponse	"Invalid parameter or unsupported currency"
+//	@Failure		404				{object}	errorResponse	"Product not found"
+//	@Failure		500				{object}	errorResponse	"Internal Server Error"
+//	@Failure		503				{object}	errorResponse	"Request cancelled"
+//	@Failure		504				{object}	errorResponse	"Request timed out"
+//	@Router			/products/by-slug/{slug} [get]
+func handleGetProductBySlug(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		slug := chi.URLParam(r, "slug")
+		respondWithProduct(w, r, srv, func(ctx context.Context) (*models.Product, error) {
+			return srv.Storage().GetProductBySlug(ctx, slug)
+		})
+	}
+}
+
+//	@Summary		Get a product by SKU
+//	@Description	Retrieves a product by its SKU.
+//	@Description	The 'ETag' header holds the version of the product, which is required to update or delete it.
+//	@ID				get-product-by-sku
+//	@Tags			products
+//	@Produce		json
+//	@Param			sku				path		string			true	"Product SKU"
+//	@Param			currency		query		string			false	"Currency of the price (overrides 'Accept-Currency')"
+//	@Param			Accept-Currency	header		string			false	"Currency of the price"
+//	@Success		200				{object}	models.Product	"Product"
+//	@Header			200				{string}	ETag			"Product version"
+//	@Failure		400				{object}	errorResponse	"Invalid parameter or unsupported currency"
+//	@Failure		404				{object}	errorResponse	"Product not found"
+//	@Failure		500				{object}	errorResponse	"Internal Server Error"
+//	@Failure		503				{object}	errorResponse	"Request cancelled"
+//	@Failure		504				{object}	errorResponse	"Request timed out"
+//	@Router			/products/by-sku/{sku} [get]
+func handleGetProductBySKU(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sku := chi.URLParam(r, "sku")
+		respondWithProduct(w, r, srv, func(ctx context.Context) (*models.Product, error) {
+			return srv.Storage().GetProductBySKU(ctx, sku)
+		})
+	}
+}
+
+// Responds on w with the product returned by get, priced in the currency requested by r.
+// The 'ETag' header holds the version of the product.
+func respondWithProduct(w http.ResponseWriter, r *http.Request, srv Server,
+	get func(ctx context.Context) (*models.Product, error)) {
+	currency, err := requestCurrency(r)
+	if err != nil {
+		messages := []string{"Invalid parameter 'currency'", "parse_query_error", err.Error()}
+		respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+		return
+	}
+
+	product, err := get(r.Context())
+	if err != nil {
+		var notFoundErr *storage.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			messages := []string{"Product not found", "get_product_error", notFoundErr.Error()}
+			respondWithError(w, srv.Logger(), http.StatusNotFound, messages...)
 			return
 		}
+		messages := []string{"Failed to get product", "get_product_error", err.Error()}
+		respondWithError(w, srv.Logger(), statusFromError(err), messages...)
+		return
+	}
 
-		respondWithJSON(w, srv.Logger(), http.StatusOK, product)
+	products := []models.Product{*product}
+	if err = storage.ConvertPrices(r.Context(), srv.Storage(), products, currency); err != nil {
+		status, messages := convertPricesError(err)
+		respondWithError(w, srv.Logger(), status, messages...)
+		return
 	}
+
+	w.Header().Set("ETag", etag(product.Version))
+	w.Header().Set("Vary", "Accept-Currency")
+	respondWithJSON(w, srv.Logger(), http.StatusOK, products[0])
 }
 
 //	@Summary		Create a product
@@ -88,7 +431,11 @@ func handleGetProductByID(srv Server) http.HandlerFunc {
 //	@Produce		json
 //	@Param			product	body		models.CreateProductRequest	true	"Product"
 //	@Success		201		{object}	idResponse					"Product ID"
+//	@Failure		400		{object}	errorResponse				"Invalid product"
+//	@Failure		409		{object}	errorResponse				"SKU or slug is already used"
 //	@Failure		500		{object}	errorResponse				"Internal Server Error"
+//	@Failure		503		{object}	errorResponse				"Request cancelled"
+//	@Failure		504		{object}	errorResponse				"Request timed out"
 //	@Router			/products [post]
 func handleCreateProduct(srv Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -99,12 +446,23 @@ func handleCreateProduct(srv Server) http.HandlerFunc {
 			respondWithError(w, srv.Logger(), http.StatusInternalServerError, messages...)
 			return
 		}
+		if err = createProductReq.Validate(); err != nil {
+			messages := []string{"Invalid product", "validate_product_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
 
 		var id int
-		id, err = srv.Storage().CreateProduct(&createProductReq)
+		id, err = srv.Storage().CreateProduct(r.Context(), &createProductReq)
 		if err != nil {
+			var conflictErr *storage.ConflictError
+			if errors.As(err, &conflictErr) {
+				messages := []string{"SKU or slug is already used", "create_product_error", conflictErr.Error()}
+				respondWithError(w, srv.Logger(), http.StatusConflict, messages...)
+				return
+			}
 			messages := []string{"Failed to create product", "create_product_error", err.Error()}
-			respondWithError(w, srv.Logger(), http.StatusInternalServerError, messages...)
+			respondWithError(w, srv.Logger(), statusFromError(err), messages...)
 			return
 		}
 
@@ -113,16 +471,25 @@ func handleCreateProduct(srv Server) http.HandlerFunc {
 }
 
 //	@Summary		Update a product
-//	@Description	Updates a product.
+//	@Description	Updates a product if it has not been modified since it was retrieved.
+//	@Description	The 'If-Match' header must hold the 'ETag' of the product (or '*' to update any version).
 //	@ID				update-product
 //	@Tags			products
 //	@Accept			json
 //	@Produce		json
-//	@Param			id		path	int							true	"Product ID"
-//	@Param			product	body	models.CreateProductRequest	true	"Product"
+//	@Param			id			path	int							true	"Product ID"
+//	@Param			If-Match	header	string						true	"ETag of the product"
+//	@Param			product		body	models.CreateProductRequest	true	"Product"
 //	@Success		204
-//	@Failure		400	{object}	errorResponse	"Invalid parameter 'id'"
+//	@Header			204	{string}	ETag			"New product version"
+//	@Failure		400	{object}	errorResponse	"Invalid parameter 'id' or product"
+//	@Failure		404	{object}	errorResponse	"Product not found"
+//	@Failure		409	{object}	errorResponse	"SKU or slug is already used"
+//	@Failure		412	{object}	errorResponse	"Product has been modified"
+//	@Failure		428	{object}	errorResponse	"Missing header 'If-Match'"
 //	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Failure		503	{object}	errorResponse	"Request cancelled"
+//	@Failure		504	{object}	errorResponse	"Request timed out"
 //	@Router			/products/{id} [put]
 func handleUpdateProductByID(srv Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -133,6 +500,12 @@ func handleUpdateProductByID(srv Server) http.HandlerFunc {
 			return
 		}
 
+		version, err := parseIfMatch(r)
+		if err != nil {
+			respondWithIfMatchError(w, srv.Logger(), err)
+			return
+		}
+
 		var createProductReq models.CreateProductRequest
 		err = parseJSONBody(r, &createProductReq)
 		if err != nil {
@@ -140,33 +513,66 @@ func handleUpdateProductByID(srv Server) http.HandlerFunc {
 			respondWithError(w, srv.Logger(), http.StatusInternalServerError, messages...)
 			return
 		}
+		if err = createProductReq.Validate(); err != nil {
+			messages := []string{"Invalid product", "validate_product_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
 
 		product := createProductReq.ToProduct(id)
-		err = srv.Storage().UpdateProduct(product)
+		product.Version = version
+		err = srv.Storage().UpdateProduct(r.Context(), product)
 		if err != nil {
-			var notFoundErr *storage.NotFoundError
-			if errors.As(err, &notFoundErr) {
-				messages := []string{"Product not found", "update_product_error", notFoundErr.Error()}
-				respondWithError(w, srv.Logger(), http.StatusNotFound, messages...)
-				return
-			}
-			messages := []string{"Failed to update product", "update_product_error", err.Error()}
-			respondWithError(w, srv.Logger(), http.StatusInternalServerError, messages...)
+			respondWithUpdateError(w, srv.Logger(), err)
 			return
 		}
 
+		w.Header().Set("ETag", etag(product.Version))
 		respondWithJSON(w, srv.Logger(), http.StatusNoContent, nil)
 	}
 }
 
+// Responds on w with an error for a product update that failed with err. A product that has been modified
+// is a 412 Precondition Failed, and the 'ETag' header holds its current version.
+func respondWithUpdateError(w http.ResponseWriter, logger config.Logger, err error) {
+	var notFoundErr *storage.NotFoundError
+	var conflictErr *storage.VersionConflictError
+	var duplicateErr *storage.ConflictError
+	switch {
+	case errors.As(err, &notFoundErr):
+		messages := []string{"Product not found", "update_product_error", notFoundErr.Error()}
+		respondWithError(w, logger, http.StatusNotFound, messages...)
+	case errors.As(err, &duplicateErr):
+		messages := []string{"SKU or slug is already used", "update_product_error", duplicateErr.Error()}
+		respondWithError(w, logger, http.StatusConflict, messages...)
+	case errors.As(err, &conflictErr):
+		w.Header().Set("ETag", etag(conflictErr.Actual))
+		messages := []string{"Product has been modified", "update_product_error", conflictErr.Error()}
+		respondWithError(w, logger, http.StatusPreconditionFailed, messages...)
+	default:
+		messages := []string{"Failed to update product", "update_product_error", err.Error()}
+		respondWithError(w, logger, statusFromError(err), messages...)
+	}
+}
+
 //	@Summary		Delete a product
-//	@Description	Deletes a product.
+//	@Description	Moves a product to the trash if it has not been modified since it was retrieved.
+//	@Description	The 'If-Match' header must hold the 'ETag' of the product (or '*' to delete any version).
+//	@Description	Products in the trash can be restored, unless they are deleted permanently with 'purge'.
+//	@Description	Purging a product also deletes its images.
 //	@ID				delete-product
 //	@Tags			products
-//	@Param			id	path	int	true	"Product ID"
+//	@Param			id			path	int		true	"Product ID"
+//	@Param			If-Match	header	string	true	"ETag of the product"
+//	@Param			purge		query	bool	false	"Delete the product permanently, even if it is in the trash"
 //	@Success		204
-//	@Failure		400	{object}	errorResponse	"Invalid parameter 'id'"
+//	@Failure		400	{object}	errorResponse	"Invalid parameter"
+//	@Failure		404	{object}	errorResponse	"Product not found"
+//	@Failure		412	{object}	errorResponse	"Product has been modified"
+//	@Failure		428	{object}	errorResponse	"Missing header 'If-Match'"
 //	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Failure		503	{object}	errorResponse	"Request cancelled"
+//	@Failure		504	{object}	errorResponse	"Request timed out"
 //	@Router			/products/{id} [delete]
 func handleDeleteProductByID(srv Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -177,19 +583,241 @@ func handleDeleteProductByID(srv Server) http.HandlerFunc {
 			return
 		}
 
-		err = srv.Storage().DeleteProduct(id)
+		purge := false
+		if value := r.URL.Query().Get("purge"); value != "" {
+			purge, err = strconv.ParseBool(value)
+			if err != nil {
+				messages := []string{"Invalid parameter 'purge'", "parse_query_error", err.Error()}
+				respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+				return
+			}
+		}
+
+		version, err := parseIfMatch(r)
+		if err != nil {
+			respondWithIfMatchError(w, srv.Logger(), err)
+			return
+		}
+
+		// The keys of the images of a purged product are read with it, so that their content can be deleted too.
+		var imageKeys []string
+		if purge {
+			err = srv.Storage().WithTx(r.Context(), func(tx storage.Storage) error {
+				var err error
+				if imageKeys, err = tx.GetImageKeys(r.Context(), id); err != nil {
+					return err
+				}
+				return tx.PurgeProduct(r.Context(), id, version)
+			})
+		} else {
+			err = srv.Storage().DeleteProduct(r.Context(), id, version)
+		}
 		if err != nil {
 			var notFoundErr *storage.NotFoundError
-			if errors.As(err, &notFoundErr) {
+			var conflictErr *storage.VersionConflictError
+			switch {
+			case errors.As(err, &notFoundErr):
 				messages := []string{"Product not found", "delete_product_error", notFoundErr.Error()}
 				respondWithError(w, srv.Logger(), http.StatusNotFound, messages...)
-				return
+			case errors.As(err, &conflictErr):
+				w.Header().Set("ETag", etag(conflictErr.Actual))
+				messages := []string{"Product has been modified", "delete_product_error", conflictErr.Error()}
+				respondWithError(w, srv.Logger(), http.StatusPreconditionFailed, messages...)
+			default:
+				messages := []string{"Failed to delete product", "delete_product_error", err.Error()}
+				respondWithError(w, srv.Logger(), statusFromError(err), messages...)
 			}
-			messages := []string{"Failed to delete product", "delete_product_error", err.Error()}
-			respondWithError(w, srv.Logger(), http.StatusInternalServerError, messages...)
 			return
 		}
+		// The product is already gone, so a blob that cannot be deleted is only logged.
+		for _, key := range imageKeys {
+			if err = srv.Blobs().Delete(r.Context(), key); err != nil {
+				srv.Logger().Error("Failed to delete blob", "delete_blob_error", err.Error())
+			}
+		}
 
 		respondWithJSON(w, srv.Logger(), http.StatusNoContent, nil)
 	}
 }
+
+//	@Summary		Restore a product
+//	@Description	Restores a product from the trash.
+//	@Description	The 'ETag' header holds the new version of the product.
+//	@ID				restore-product
+//	@Tags			products
+//	@Produce		json
+//	@Param			id				path		int				true	"Product ID"
+//	@Param			currency		query		string			false	"Currency of the price (overrides 'Accept-Currency')"
+//	@Param			Accept-Currency	header		string			false	"Currency of the price"
+//	@Success		200				{object}	models.Product	"Product"
+//	@Header			200				{string}	ETag			"Product version"
+//	@Failure		400				{object}	errorResponse	"Invalid parameter or unsupported currency"
+//	@Failure		404				{object}	errorResponse	"Product not found in the trash"
+//	@Failure		500				{object}	errorResponse	"Internal Server Error"
+//	@Failure		503				{object}	errorResponse	"Request cancelled"
+//	@Failure		504				{object}	errorResponse	"Request timed out"
+//	@Router			/products/{id}/restore [post]
+func handleRestoreProductByID(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			messages := []string{"Invalid parameter 'id'", "atoi_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+		currency, err := requestCurrency(r)
+		if err != nil {
+			messages := []string{"Invalid parameter 'currency'", "parse_query_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		// The product is restored and priced together, so that it is not restored if its price cannot be converted.
+		var product models.Product
+		err = srv.Storage().WithTx(r.Context(), func(tx storage.Storage) error {
+			if err := tx.RestoreProduct(r.Context(), id); err != nil {
+				return err
+			}
+			restored, err := tx.GetProduct(r.Context(), id)
+			if err != nil {
+				return err
+			}
+			products := []models.Product{*restored}
+			if err = storage.ConvertPrices(r.Context(), tx, products, currency); err != nil {
+				return err
+			}
+			product = products[0]
+			return nil
+		})
+		if err != nil {
+			var notFoundErr *storage.NotFoundError
+			var unsupportedErr *storage.UnsupportedCurrencyError
+			switch {
+			case errors.As(err, &notFoundErr):
+				messages := []string{"Product not found in the trash", "restore_product_error", notFoundErr.Error()}
+				respondWithError(w, srv.Logger(), http.StatusNotFound, messages...)
+			case errors.As(err, &unsupportedErr):
+				status, messages := convertPricesError(err)
+				respondWithError(w, srv.Logger(), status, messages...)
+			default:
+				messages := []string{"Failed to restore product", "restore_product_error", err.Error()}
+				respondWithError(w, srv.Logger(), statusFromError(err), messages...)
+			}
+			return
+		}
+
+		w.Header().Set("ETag", etag(product.Version))
+		w.Header().Set("Vary", "Accept-Currency")
+		respondWithJSON(w, srv.Logger(), http.StatusOK, product)
+	}
+}
+
+// historyResponse is a single page of the history of a product.
+type historyResponse struct {
+	// Product is the product as it was at the time given by 'as_of'. It is omitted if 'as_of' is not given,
+	// or if the product did not exist at that time.
+	Product    *models.Product        `json:"product,omitempty"`
+	Changes    []models.ProductChange `json:"changes"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
+
+//	@Summary		Get the history of a product
+//	@Description	Retrieves a page of the changes made to a product, from the most recent.
+//	@Description	Each change records the product before and after it, when it was made, the 'X-Actor' header and the ID of the request that made it.
+//	@Description	When 'as_of' is given, only the changes made up to that time are included, along with the product as it was at that time.
+//	@ID				get-product-history
+//	@Tags			products
+//	@Produce		json
+//	@Param			id		path		int				true	"Product ID"
+//	@Param			limit	query		int				false	"Maximum number of changes in the page (1-100)"	default(20)
+//	@Param			after	query		string			false	"Cursor returned as 'next_cursor' by the previous page"
+//	@Param			as_of	query		string			false	"Time to reconstruct the product at (RFC 3339)"	format(date-time)
+//	@Success		200		{object}	historyResponse	"Product history"
+//	@Header			200		{string}	Link			"Link to the next page"
+//	@Failure		400		{object}	errorResponse	"Invalid parameter"
+//	@Failure		404		{object}	errorResponse	"Product not found"
+//	@Failure		500		{object}	errorResponse	"Internal Server Error"
+//	@Failure		503		{object}	errorResponse	"Request cancelled"
+//	@Failure		504		{object}	errorResponse	"Request timed out"
+//	@Router			/products/{id}/history [get]
+func handleGetProductHistory(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			messages := []string{"Invalid parameter 'id'", "atoi_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		query, param, err := parseHistoryQuery(r)
+		if err != nil {
+			messages := []string{fmt.Sprintf("Invalid parameter '%s'", param), "parse_query_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		page, err := srv.Storage().GetProductHistory(r.Context(), id, query)
+		if err != nil {
+			var invalidCursorErr *storage.InvalidCursorError
+			var notFoundErr *storage.NotFoundError
+			switch {
+			case errors.As(err, &invalidCursorErr):
+				messages := []string{"Invalid parameter 'after'", "get_product_history_error", invalidCursorErr.Error()}
+				respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			case errors.As(err, &notFoundErr):
+				messages := []string{"Product not found", "get_product_history_error", notFoundErr.Error()}
+				respondWithError(w, srv.Logger(), http.StatusNotFound, messages...)
+			default:
+				messages := []string{"Failed to get product history", "get_product_history_error", err.Error()}
+				respondWithError(w, srv.Logger(), statusFromError(err), messages...)
+			}
+			return
+		}
+		response := historyResponse{Changes: page.Changes, NextCursor: page.NextCursor}
+
+		if query.AsOf != nil {
+			// The product as of the time is the result of the last change made up to then.
+			var last *storage.HistoryPage
+			last, err = srv.Storage().GetProductHistory(r.Context(), id, &storage.HistoryQuery{Limit: 1, AsOf: query.AsOf})
+			if err != nil {
+				messages := []string{"Failed to get product history", "get_product_history_error", err.Error()}
+				respondWithError(w, srv.Logger(), statusFromError(err), messages...)
+				return
+			}
+			if len(last.Changes) > 0 {
+				response.Product = last.Changes[0].After
+			}
+		}
+
+		if page.NextCursor != "" {
+			w.Header().Set("Link", nextPageLink(r, page.NextCursor))
+		}
+		respondWithJSON(w, srv.Logger(), http.StatusOK, response)
+	}
+}
+
+// Parses the pagination and 'as_of' parameters of the Get Product History route from the query string of r.
+// If a parameter is invalid, its name is returned along with the error.
+func parseHistoryQuery(r *http.Request) (*storage.HistoryQuery, string, error) {
+	values := r.URL.Query()
+	query := &storage.HistoryQuery{After: values.Get("after")}
+
+	if limit := values.Get("limit"); limit != "" {
+		var err error
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			return nil, "limit", err
+		}
+		if query.Limit < 1 || query.Limit > storage.MaxPageLimit {
+			return nil, "limit", fmt.Errorf("limit must be between 1 and %d", storage.MaxPageLimit)
+		}
+	}
+	if asOf := values.Get("as_of"); asOf != "" {
+		t, err := time.Parse(time.RFC3339Nano, asOf)
+		if err != nil {
+			return nil, "as_of", err
+		}
+		query.AsOf = &t
+	}
+	return query, "", nil
+}
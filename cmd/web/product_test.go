@@ -2,14 +2,17 @@ package web_test
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
 )
 
 // Tests the Get Products route through the server.
@@ -33,13 +36,13 @@ func TestServer_ProductRoutes_GetProducts(t *testing.T) {
 					Name:          "Test Product",
 					Description:   "Test Description",
 					StockQuantity: 10,
-					Price:         1.99,
+					Price:         models.NewMoney(199, models.DefaultCurrency),
 				},
 				{
 					Name:          "Test Product 2",
 					Description:   "",
 					StockQuantity: 20,
-					Price:         2.99,
+					Price:         models.NewMoney(299, models.DefaultCurrency),
 				},
 			},
 			http.StatusOK,
@@ -47,16 +50,20 @@ func TestServer_ProductRoutes_GetProducts(t *testing.T) {
 				{
 					ID:            1,
 					Name:          "Test Product",
+					Slug:          "test-product",
 					Description:   sql.NullString{String: "Test Description", Valid: true},
 					StockQuantity: 10,
-					Price:         1.99,
+					Price:         models.NewMoney(199, models.DefaultCurrency),
+					Version:       1,
 				},
 				{
 					ID:            2,
 					Name:          "Test Product 2",
+					Slug:          "test-product-2",
 					Description:   sql.NullString{String: "", Valid: false},
 					StockQuantity: 20,
-					Price:         2.99,
+					Price:         models.NewMoney(299, models.DefaultCurrency),
+					Version:       1,
 				},
 			},
 		},
@@ -77,15 +84,270 @@ func TestServer_ProductRoutes_GetProducts(t *testing.T) {
 
 			checkEqual(t, rr.Code, tc.expectedStatusCode, "Status Code")
 
-			products := new([]models.Product)
-			err = json.NewDecoder(rr.Body).Decode(products)
+			page := new(productsResponse)
+			err = json.NewDecoder(rr.Body).Decode(page)
 			if err != nil {
 				t.Error(fmt.Errorf("Error decoding JSON response: %w", err))
 			}
 
-			checkEqual(t, len(*products), len(tc.expectedProducts), "Products Length")
+			checkEqual(t, len(page.Products), len(tc.expectedProducts), "Products Length")
 
-			checkEqual(t, *products, tc.expectedProducts, "Products")
+			checkEqual(t, page.Products, tc.expectedProducts, "Products")
+			checkEqual(t, page.NextCursor, "", "Next Cursor")
+		})
+	}
+}
+
+// Tests paging through the Get Products route using the returned cursors.
+func TestServer_ProductRoutes_GetProductsPagination(t *testing.T) {
+	method := http.MethodGet
+	url := "/v1/api/products"
+
+	srv := newTestServer()
+	srv.MountHandlers()
+	addProducts(t, srv, []models.CreateProductRequest{
+		{Name: "Test Product", Price: models.NewMoney(199, models.DefaultCurrency)},
+		{Name: "Test Product 2", Price: models.NewMoney(299, models.DefaultCurrency)},
+		{Name: "Test Product 3", Price: models.NewMoney(399, models.DefaultCurrency)},
+	})
+
+	// Follow the cursors until the last page, collecting the product IDs of each page.
+	var pages [][]int
+	query := "?limit=2"
+	for query != "" {
+		rr := httptest.NewRecorder()
+		req, err := http.NewRequest(method, url+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		srv.Mux().ServeHTTP(rr, req)
+
+		checkEqual(t, rr.Code, http.StatusOK, "Status Code")
+
+		page := new(productsResponse)
+		err = json.NewDecoder(rr.Body).Decode(page)
+		if err != nil {
+			t.Fatal(fmt.Errorf("Error decoding JSON response: %w", err))
+		}
+
+		var ids []int
+		for _, p := range page.Products {
+			ids = append(ids, p.ID)
+		}
+		pages = append(pages, ids)
+
+		query = ""
+		if page.NextCursor != "" {
+			query = "?after=" + page.NextCursor + "&limit=2"
+			checkEqual(t, rr.Header().Get("Link"), fmt.Sprintf("<%s%s>; rel=\"next\"", url, query), "Link Header")
+		}
+	}
+
+	checkEqual(t, pages, [][]int{{1, 2}, {3}}, "Pages")
+
+	tt := []struct {
+		name               string
+		query              string
+		expectedStatusCode int
+	}{
+		{"limit not int", "?limit=two", http.StatusBadRequest},
+		{"limit too small", "?limit=0", http.StatusBadRequest},
+		{"limit too large", "?limit=101", http.StatusBadRequest},
+		{"invalid cursor", "?after=not-a-cursor", http.StatusBadRequest},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest(method, url+tc.query, nil)
+			if err != nil {
+				t.Error(err)
+			}
+
+			srv.Mux().ServeHTTP(rr, req)
+
+			checkEqual(t, rr.Code, tc.expectedStatusCode, "Status Code")
+		})
+	}
+}
+
+// Tests filtering and sorting through the Get Products route.
+func TestServer_ProductRoutes_GetProductsFilterSort(t *testing.T) {
+	method := http.MethodGet
+	url := "/v1/api/products"
+
+	srv := newTestServer()
+	srv.MountHandlers()
+	addProducts(t, srv, []models.CreateProductRequest{
+		{Name: "Red Shirt", Description: "Cotton", Price: models.NewMoney(1999, models.DefaultCurrency), StockQuantity: 5},
+		{Name: "Blue Shirt", Description: "Linen", Price: models.NewMoney(2999, models.DefaultCurrency), StockQuantity: 0},
+		{Name: "Green Hat", Description: "Cotton", Price: models.NewMoney(999, models.DefaultCurrency), StockQuantity: 12},
+		{Name: "Socks", Description: "", Price: models.NewMoney(1999, models.DefaultCurrency), StockQuantity: 3},
+	})
+
+	tt := []struct {
+		name               string
+		query              string
+		expectedStatusCode int
+		expectedIDs        []int
+	}{
+		{"price range", "?min_price=10&max_price=20", http.StatusOK, []int{1, 4}},
+		{"in stock", "?in_stock=true", http.StatusOK, []int{1, 3, 4}},
+		{"out of stock", "?in_stock=false", http.StatusOK, []int{2}},
+		{"search name", "?q=shirt", http.StatusOK, []int{1, 2}},
+		{"search description", "?q=COTTON", http.StatusOK, []int{1, 3}},
+		{"sort by name", "?sort=name", http.StatusOK, []int{2, 3, 1, 4}},
+		{"sort by price descending", "?sort=-price", http.StatusOK, []int{2, 4, 1, 3}},
+		{"sort by stock", "?sort=stock_quantity", http.StatusOK, []int{2, 4, 1, 3}},
+		{"filter and sort", "?in_stock=true&sort=-price&max_price=25", http.StatusOK, []int{4, 1, 3}},
+		{"sort by price descending paged", "?sort=-price&limit=3", http.StatusOK, []int{2, 4, 1, 3}},
+		{"invalid sort", "?sort=description", http.StatusBadRequest, nil},
+		{"invalid min price", "?min_price=cheap", http.StatusBadRequest, nil},
+		{"negative max price", "?max_price=-1", http.StatusBadRequest, nil},
+		{"invalid in stock", "?in_stock=maybe", http.StatusBadRequest, nil},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			// Follow the cursors until the last page, collecting the product IDs of every page.
+			var ids []int
+			query := tc.query
+			for query != "" {
+				rr := httptest.NewRecorder()
+				req, err := http.NewRequest(method, url+query, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				srv.Mux().ServeHTTP(rr, req)
+
+				checkEqual(t, rr.Code, tc.expectedStatusCode, "Status Code")
+				if rr.Code != http.StatusOK {
+					return
+				}
+
+				page := new(productsResponse)
+				err = json.NewDecoder(rr.Body).Decode(page)
+				if err != nil {
+					t.Fatal(fmt.Errorf("Error decoding JSON response: %w", err))
+				}
+				for _, p := range page.Products {
+					ids = append(ids, p.ID)
+				}
+
+				query = ""
+				if page.NextCursor != "" {
+					query = tc.query + "&after=" + page.NextCursor
+				}
+			}
+
+			checkEqual(t, ids, tc.expectedIDs, "Product IDs")
+		})
+	}
+}
+
+// Tests the Search Products route through the server.
+func TestServer_ProductRoutes_SearchProducts(t *testing.T) {
+	method := http.MethodGet
+	url := "/v1/api/products/search"
+
+	srv := newTestServer()
+	srv.MountHandlers()
+	addProducts(t, srv, []models.CreateProductRequest{
+		{Name: "Cotton Shirt", Description: "A shirt made of <organic> cotton.", Price: models.NewMoney(1999, models.DefaultCurrency)},
+		{Name: "Wool Hat", Description: "Keeps you warm.", Price: models.NewMoney(999, models.DefaultCurrency)},
+		{Name: "Socks", Description: "Cotton blend socks.", Price: models.NewMoney(499, models.DefaultCurrency)},
+	})
+
+	type searchResult struct {
+		Product models.Product `json:"product"`
+		Score   float64        `json:"score"`
+		Snippet string         `json:"snippet"`
+	}
+
+	tt := []struct {
+		name               string
+		query              string
+		expectedStatusCode int
+		expectedIDs        []int
+		expectedSnippets   []string
+	}{
+		{
+			"ranked by relevance", "?q=cotton",
+			http.StatusOK,
+			[]int{1, 3},
+			[]string{"A shirt made of &lt;organic&gt; <mark>cotton</mark>.", "<mark>Cotton</mark> blend socks."},
+		},
+		{
+			"name only match", "?q=hat",
+			http.StatusOK,
+			[]int{2},
+			[]string{"Wool <mark>Hat</mark>"},
+		},
+		{
+			"limited", "?q=cotton&limit=1",
+			http.StatusOK,
+			[]int{1},
+			[]string{"A shirt made of &lt;organic&gt; <mark>cotton</mark>."},
+		},
+		{
+			"no matches", "?q=trousers",
+			http.StatusOK,
+			nil,
+			nil,
+		},
+		{
+			"missing query", "",
+			http.StatusBadRequest,
+			nil,
+			nil,
+		},
+		{
+			"invalid limit", "?q=cotton&limit=0",
+			http.StatusBadRequest,
+			nil,
+			nil,
+		},
+		{
+			"non-numeric limit", "?q=cotton&limit=ten",
+			http.StatusBadRequest,
+			nil,
+			nil,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest(method, url+tc.query, nil)
+			if err != nil {
+				t.Error(err)
+			}
+
+			srv.Mux().ServeHTTP(rr, req)
+
+			checkEqual(t, rr.Code, tc.expectedStatusCode, "Status Code")
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			response := new(struct {
+				Results []searchResult `json:"results"`
+			})
+			err = json.NewDecoder(rr.Body).Decode(response)
+			if err != nil {
+				t.Error(fmt.Errorf("Error decoding JSON response: %w", err))
+			}
+
+			var ids []int
+			var snippets []string
+			for _, result := range response.Results {
+				ids = append(ids, result.Product.ID)
+				snippets = append(snippets, result.Snippet)
+			}
+			checkEqual(t, ids, tc.expectedIDs, "Product IDs")
+			checkEqual(t, snippets, tc.expectedSnippets, "Snippets")
 		})
 	}
 }
@@ -97,11 +359,11 @@ func TestServer_ProductRoutes_GetProductByID(t *testing.T) {
 
 	srv := newTestServer()
 	srv.MountHandlers()
-	productID, err := srv.Storage().CreateProduct(&models.CreateProductRequest{
+	productID, err := srv.Storage().CreateProduct(context.Background(), &models.CreateProductRequest{
 		Name:          "Test Product",
 		Description:   "Test Description",
 		StockQuantity: 10,
-		Price:         1.99,
+		Price:         models.NewMoney(199, models.DefaultCurrency),
 	})
 	if err != nil {
 		t.Error(fmt.Errorf("Error creating product: %w", err))
@@ -119,9 +381,11 @@ func TestServer_ProductRoutes_GetProductByID(t *testing.T) {
 			models.Product{
 				ID:            1,
 				Name:          "Test Product",
+				Slug:          "test-product",
 				Description:   sql.NullString{String: "Test Description", Valid: true},
 				StockQuantity: 10,
-				Price:         1.99,
+				Price:         models.NewMoney(199, models.DefaultCurrency),
+				Version:       1,
 			},
 		},
 		{
@@ -156,6 +420,9 @@ func TestServer_ProductRoutes_GetProductByID(t *testing.T) {
 			}
 
 			checkEqual(t, *product, tc.expectedProduct, "Product")
+			if rr.Code == http.StatusOK {
+				checkEqual(t, rr.Header().Get("ETag"), `"1"`, "ETag")
+			}
 		})
 	}
 }
@@ -184,11 +451,23 @@ func TestServer_ProductRoutes_CreateProduct(t *testing.T) {
 				Name:          "Test Product",
 				Description:   "Test Description",
 				StockQuantity: 10,
-				Price:         1.99,
+				Price:         models.NewMoney(199, models.DefaultCurrency),
 			},
 			http.StatusCreated,
 			1,
 		},
+		{
+			"negative price",
+			models.CreateProductRequest{Name: "Test Product", Price: models.NewMoney(-199, models.DefaultCurrency)},
+			http.StatusBadRequest,
+			0,
+		},
+		{
+			"unsupported currency",
+			models.CreateProductRequest{Name: "Test Product", Price: models.NewMoney(199, models.DefaultCurrency), Currency: "XYZ"},
+			http.StatusBadRequest,
+			0,
+		},
 	}
 
 	for _, tc := range tt {
@@ -216,8 +495,11 @@ func TestServer_ProductRoutes_CreateProduct(t *testing.T) {
 			}
 
 			checkEqual(t, id.ID, tc.expectedID, "ID")
+			if rr.Code != http.StatusCreated {
+				return
+			}
 
-			err = srv.Storage().DeleteProduct(id.ID)
+			err = srv.Storage().DeleteProduct(context.Background(), id.ID, storage.AnyVersion)
 			if err != nil {
 				t.Error(fmt.Errorf("Error deleting product: %w", err))
 			}
@@ -233,83 +515,86 @@ func TestServer_ProductRoutes_UpdateProductByID(t *testing.T) {
 	srv := newTestServer()
 	srv.MountHandlers()
 
+	existingProduct := models.CreateProductRequest{
+		Name:          "Test Product",
+		Description:   "Test Description",
+		StockQuantity: 10,
+		Price:         models.NewMoney(199, models.DefaultCurrency),
+	}
+	updatedProduct := models.CreateProductRequest{
+		Name:          "Test Product 2",
+		Description:   "Test Description 2",
+		StockQuantity: 20,
+	}
+
 	tt := []struct {
 		name               string
 		id                 interface{}
-		existingProduct    models.CreateProductRequest
-		updatedProduct     models.CreateProductRequest
+		ifMatch            string
 		expectedStatusCode int
+		expectedETag       string
 	}{
 		{
-			"happy path", 1,
-			models.CreateProductRequest{
-				Name:          "Test Product",
-				Description:   "Test Description",
-				StockQuantity: 10,
-				Price:         1.99,
-			},
-			models.CreateProductRequest{
-				Name:          "Test Product 2",
-				Description:   "Test Description 2",
-				StockQuantity: 20,
-			},
-			http.StatusNoContent,
+			"happy path", nil, `"1"`,
+			http.StatusNoContent, `"2"`,
 		},
 		{
-			"id not int", "not-an-id",
-			models.CreateProductRequest{
-				Name:          "Test Product",
-				Description:   "Test Description",
-				StockQuantity: 10,
-				Price:         1.99,
-			},
-			models.CreateProductRequest{
-				Name:          "Test Product 2",
-				Description:   "Test Description 2",
-				StockQuantity: 20,
-			},
-			http.StatusBadRequest,
+			"any version", nil, "*",
+			http.StatusNoContent, `"2"`,
 		},
 		{
-			"id not found", 200,
-			models.CreateProductRequest{
-				Name:          "Test Product",
-				Description:   "Test Description",
-				StockQuantity: 10,
-				Price:         1.99,
-			},
-			models.CreateProductRequest{
-				Name:          "Test Product 2",
-				Description:   "Test Description 2",
-				StockQuantity: 20,
-			},
-			http.StatusNotFound,
+			"id not int", "not-an-id", `"1"`,
+			http.StatusBadRequest, "",
+		},
+		{
+			"id not found", 200, `"1"`,
+			http.StatusNotFound, "",
+		},
+		{
+			"missing if-match", nil, "",
+			http.StatusPreconditionRequired, "",
+		},
+		{
+			"stale version", nil, `"2"`,
+			http.StatusPreconditionFailed, `"1"`,
+		},
+		{
+			"invalid etag", nil, "version-one",
+			http.StatusPreconditionFailed, "",
 		},
 	}
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			productID, err := srv.Storage().CreateProduct(&tc.existingProduct)
+			productID, err := srv.Storage().CreateProduct(context.Background(), &existingProduct)
 			if err != nil {
 				t.Error(fmt.Errorf("Error creating product: %w", err))
 			}
 			defer removeProducts(t, srv, []models.Product{{ID: productID}})
+			id := tc.id
+			if id == nil {
+				id = productID
+			}
 
 			rr := httptest.NewRecorder()
 			body := new(bytes.Buffer)
-			err = json.NewEncoder(body).Encode(tc.updatedProduct)
+			err = json.NewEncoder(body).Encode(updatedProduct)
 			if err != nil {
 				t.Error(fmt.Errorf("Error encoding JSON payload: %w", err))
 			}
 
-			req, err := http.NewRequest(method, fmt.Sprint(url, tc.id), body)
+			req, err := http.NewRequest(method, fmt.Sprint(url, id), body)
 			if err != nil {
 				t.Error(err)
 			}
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
 
 			srv.Mux().ServeHTTP(rr, req)
 
 			checkEqual(t, rr.Code, tc.expectedStatusCode, "Status Code")
+			checkEqual(t, rr.Header().Get("ETag"), tc.expectedETag, "ETag")
 		})
 	}
 }
@@ -325,50 +610,343 @@ func TestServer_ProductRoutes_DeleteProductByID(t *testing.T) {
 	tt := []struct {
 		name               string
 		id                 interface{}
+		ifMatch            string
 		expectedStatusCode int
 	}{
 		{
-			"happy path", 1,
+			"happy path", nil, `"1"`,
 			http.StatusNoContent,
 		},
 		{
-			"id not int", "not-an-id",
+			"any version", nil, "*",
+			http.StatusNoContent,
+		},
+		{
+			"id not int", "not-an-id", `"1"`,
 			http.StatusBadRequest,
 		},
 		{
-			"id not found", 200,
+			"id not found", 200, `"1"`,
 			http.StatusNotFound,
 		},
+		{
+			"missing if-match", nil, "",
+			http.StatusPreconditionRequired,
+		},
+		{
+			"stale version", nil, `"2"`,
+			http.StatusPreconditionFailed,
+		},
 	}
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			productID, err := srv.Storage().CreateProduct(&models.CreateProductRequest{
+			productID, err := srv.Storage().CreateProduct(context.Background(), &models.CreateProductRequest{
 				Name:          "Test Product",
 				Description:   "Test Description",
 				StockQuantity: 10,
-				Price:         1.99,
+				Price:         models.NewMoney(199, models.DefaultCurrency),
 			})
 			if err != nil {
 				t.Error(fmt.Errorf("Error creating product: %w", err))
 			}
+			id := tc.id
+			if id == nil {
+				id = productID
+			}
 
 			rr := httptest.NewRecorder()
-			req, err := http.NewRequest(method, fmt.Sprint(url, tc.id), nil)
+			req, err := http.NewRequest(method, fmt.Sprint(url, id), nil)
 			if err != nil {
 				t.Error(err)
 			}
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
 
 			srv.Mux().ServeHTTP(rr, req)
 
 			checkEqual(t, rr.Code, tc.expectedStatusCode, "Status Code")
 
+			_, err = srv.Storage().GetProduct(context.Background(), productID)
 			if rr.Code == http.StatusNoContent {
-				_, err = srv.Storage().GetProduct(productID)
 				if err == nil {
 					t.Errorf("Product not deleted")
 				}
+			} else if err != nil {
+				t.Errorf("Product deleted on failed request")
 			}
 		})
 	}
 }
+
+// Tests moving a product to the trash, listing the trash, restoring the product and purging it.
+// Each step is run in order against the same product.
+func TestServer_ProductRoutes_TrashAndRestore(t *testing.T) {
+	srv := newTestServer()
+	srv.MountHandlers()
+
+	productID, err := srv.Storage().CreateProduct(context.Background(), &models.CreateProductRequest{
+		Name:          "Test Product",
+		StockQuantity: 10,
+		Price:         models.NewMoney(199, models.DefaultCurrency),
+	})
+	if err != nil {
+		t.Fatal(fmt.Errorf("Error creating product: %w", err))
+	}
+	url := fmt.Sprint("/v1/api/products/", productID)
+
+	steps := []struct {
+		name               string
+		method             string
+		url                string
+		ifMatch            string
+		expectedStatusCode int
+		expectedETag       string
+		expectedTrashIDs   []int
+	}{
+		{
+			"delete", http.MethodDelete, url, `"1"`,
+			http.StatusNoContent, "", []int{productID},
+		},
+		{
+			"get deleted", http.MethodGet, url, "",
+			http.StatusNotFound, "", []int{productID},
+		},
+		{
+			"update deleted", http.MethodPut, url, "*",
+			http.StatusNotFound, "", []int{productID},
+		},
+		{
+			"delete deleted", http.MethodDelete, url, "*",
+			http.StatusNotFound, "", []int{productID},
+		},
+		{
+			"restore invalid currency", http.MethodPost, url + "/restore?currency=abcd", "",
+			http.StatusBadRequest, "", []int{productID},
+		},
+		{
+			"restore unsupported currency", http.MethodPost, url + "/restore?currency=XYZ", "",
+			http.StatusBadRequest, "", []int{productID},
+		},
+		{
+			"restore", http.MethodPost, url + "/restore", "",
+			http.StatusOK, `"3"`, []int{},
+		},
+		{
+			"restore not deleted", http.MethodPost, url + "/restore", "",
+			http.StatusNotFound, "", []int{},
+		},
+		{
+			"invalid purge", http.MethodDelete, url + "?purge=maybe", "*",
+			http.StatusBadRequest, "", []int{},
+		},
+		{
+			"purge stale version", http.MethodDelete, url + "?purge=true", `"2"`,
+			http.StatusPreconditionFailed, `"3"`, []int{},
+		},
+		{
+			"purge", http.MethodDelete, url + "?purge=true", `"3"`,
+			http.StatusNoContent, "", []int{},
+		},
+		{
+			"restore purged", http.MethodPost, url + "/restore", "",
+			http.StatusNotFound, "", []int{},
+		},
+	}
+
+	for _, step := range steps {
+		rr := httptest.NewRecorder()
+		body := new(bytes.Buffer)
+		if step.method == http.MethodPut {
+			err = json.NewEncoder(body).Encode(models.CreateProductRequest{Name: "Test Product 2"})
+			if err != nil {
+				t.Fatal(fmt.Errorf("Error encoding JSON payload: %w", err))
+			}
+		}
+		req, err := http.NewRequest(step.method, step.url, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if step.ifMatch != "" {
+			req.Header.Set("If-Match", step.ifMatch)
+		}
+
+		srv.Mux().ServeHTTP(rr, req)
+
+		checkEqual(t, rr.Code, step.expectedStatusCode, step.name+": Status Code")
+		checkEqual(t, rr.Header().Get("ETag"), step.expectedETag, step.name+": ETag")
+
+		rr = httptest.NewRecorder()
+		req, err = http.NewRequest(http.MethodGet, "/v1/api/products/trash", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv.Mux().ServeHTTP(rr, req)
+
+		var trash productsResponse
+		if err = json.NewDecoder(rr.Body).Decode(&trash); err != nil {
+			t.Fatal(fmt.Errorf("Error decoding JSON response: %w", err))
+		}
+		ids := []int{}
+		for _, p := range trash.Products {
+			ids = append(ids, p.ID)
+		}
+		checkEqual(t, ids, step.expectedTrashIDs, step.name+": Trash IDs")
+	}
+}
+
+// Tests that the changes made to a product are recorded in its history,
+// and that the product can be reconstructed as it was at a point in time.
+func TestServer_ProductRoutes_GetProductHistory(t *testing.T) {
+	srv := newTestServer()
+	srv.MountHandlers()
+
+	// Each change is made through the server so that it is audited.
+	change := func(method, url, ifMatch string, payload any) {
+		t.Helper()
+		body := new(bytes.Buffer)
+		if payload != nil {
+			if err := json.NewEncoder(body).Encode(payload); err != nil {
+				t.Fatal(fmt.Errorf("Error encoding JSON payload: %w", err))
+			}
+		}
+		req, err := http.NewRequest(method, url, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Actor", "admin")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rr := httptest.NewRecorder()
+		srv.Mux().ServeHTTP(rr, req)
+		if rr.Code >= http.StatusBadRequest {
+			t.Fatalf("%s %s: Status Code %d", method, url, rr.Code)
+		}
+	}
+	change(http.MethodPost, "/v1/api/products", "", models.CreateProductRequest{Name: "Test Product", Price: models.NewMoney(199, models.DefaultCurrency)})
+	time.Sleep(time.Millisecond)
+	created := time.Now()
+	time.Sleep(time.Millisecond)
+	change(http.MethodPut, "/v1/api/products/1", `"1"`, models.CreateProductRequest{Name: "Test Product", Price: models.NewMoney(299, models.DefaultCurrency)})
+	change(http.MethodDelete, "/v1/api/products/1", `"2"`, nil)
+
+	type historyResponse struct {
+		Product    *models.Product        `json:"product"`
+		Changes    []models.ProductChange `json:"changes"`
+		NextCursor string                 `json:"next_cursor"`
+	}
+
+	tt := []struct {
+		name               string
+		url                string
+		expectedStatusCode int
+		expectedOperations []models.ChangeOperation
+		expectedPrice      string
+		expectNextCursor   bool
+	}{
+		{
+			"happy path", "/v1/api/products/1/history",
+			http.StatusOK, []models.ChangeOperation{"delete", "update", "create"}, "", false,
+		},
+		{
+			"limit", "/v1/api/products/1/history?limit=2",
+			http.StatusOK, []models.ChangeOperation{"delete", "update"}, "", true,
+		},
+		{
+			"as of creation", "/v1/api/products/1/history?as_of=" + created.UTC().Format(time.RFC3339Nano),
+			http.StatusOK, []models.ChangeOperation{"create"}, "1.99", false,
+		},
+		{
+			"invalid as of", "/v1/api/products/1/history?as_of=yesterday",
+			http.StatusBadRequest, nil, "", false,
+		},
+		{
+			"invalid cursor", "/v1/api/products/1/history?after=not-a-cursor",
+			http.StatusBadRequest, nil, "", false,
+		},
+		{
+			"id not found", "/v1/api/products/200/history",
+			http.StatusNotFound, nil, "", false,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			srv.Mux().ServeHTTP(rr, req)
+
+			checkEqual(t, rr.Code, tc.expectedStatusCode, "Status Code")
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			var history historyResponse
+			if err = json.NewDecoder(rr.Body).Decode(&history); err != nil {
+				t.Fatal(fmt.Errorf("Error decoding JSON response: %w", err))
+			}
+			var operations []models.ChangeOperation
+			for _, c := range history.Changes {
+				operations = append(operations, c.Operation)
+				checkEqual(t, c.Actor, "admin", "Actor")
+				checkEqual(t, c.RequestID != "", true, "Has Request ID")
+			}
+			checkEqual(t, operations, tc.expectedOperations, "Operations")
+			checkEqual(t, history.NextCursor != "", tc.expectNextCursor, "Has Next Cursor")
+			if tc.expectedPrice != "" {
+				checkEqual(t, history.Product.Price.String(), tc.expectedPrice, "Product Price As Of")
+			} else {
+				checkEqual(t, history.Product == nil, true, "No Product As Of")
+			}
+		})
+	}
+}
+
+// Tests that storage errors caused by the request context are mapped to the correct status code.
+func TestServer_ProductRoutes_ContextErrors(t *testing.T) {
+	method := http.MethodGet
+	url := "/v1/api/products"
+
+	srv := newTestServer()
+	srv.MountHandlers()
+
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tt := []struct {
+		name               string
+		ctx                context.Context
+		expectedStatusCode int
+	}{
+		{
+			"deadline exceeded", expired,
+			http.StatusGatewayTimeout,
+		},
+		{
+			"client cancelled", cancelled,
+			http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(tc.ctx, method, url, nil)
+			if err != nil {
+				t.Error(err)
+			}
+
+			srv.Mux().ServeHTTP(rr, req)
+
+			checkEqual(t, rr.Code, tc.expectedStatusCode, "Status Code")
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// The time that stock is reserved for when the request does not give one.
+	defaultReservationTTL = 15 * time.Minute
+	// The longest time that stock may be reserved for.
+	maxReservationTTL = 24 * time.Hour
+)
+
+//	@Summary		Reserve stock of a product
+//	@Description	Takes stock from a product and holds it for a purchase until the reservation is committed, released or expires.
+//	@Description	Expired reservations return their stock to the product. The reservation expires after 'ttl_seconds' (default 15 minutes, at most 24 hours).
+//	@ID				reserve-stock
+//	@Tags			reservations
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int							true	"Product ID"
+//	@Param			reservation	body		models.ReserveStockRequest	true	"Reservation"
+//	@Success		201			{object}	models.Reservation			"Reservation"
+//	@Failure		400			{object}	errorResponse				"Invalid reservation"
+//	@Failure		404			{object}	errorResponse				"Product not found"
+//	@Failure		409			{object}	errorResponse				"Insufficient stock"
+//	@Failure		500			{object}	errorResponse				"Internal Server Error"
+//	@Failure		503			{object}	errorResponse				"Request cancelled"
+//	@Failure		504			{object}	errorResponse				"Request timed out"
+//	@Router			/products/{id}/reservations [post]
+func handleReserveStock(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			messages := []string{"Invalid parameter 'id'", "atoi_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		var req models.ReserveStockRequest
+		err = parseJSONBody(r, &req)
+		if err != nil {
+			messages := []string{"Failed to parse JSON payload", "parse_json_body_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+		ttl := time.Duration(req.TTLSeconds) * time.Second
+		if req.TTLSeconds == 0 {
+			ttl = defaultReservationTTL
+		}
+		if req.Quantity < 1 || ttl < 0 || ttl > maxReservationTTL {
+			messages := []string{"Invalid reservation", "validation_error",
+				fmt.Sprintf("quantity must be positive and ttl_seconds must be between 0 and %.0f", maxReservationTTL.Seconds())}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		reservation, err := srv.Storage().ReserveStock(r.Context(), id, req.Quantity, ttl)
+		if err != nil {
+			var notFoundErr *storage.NotFoundError
+			var insufficientErr *storage.InsufficientStockError
+			switch {
+			case errors.As(err, &notFoundErr):
+				messages := []string{"Product not found", "reserve_stock_error", notFoundErr.Error()}
+				respondWithError(w, srv.Logger(), http.StatusNotFound, messages...)
+			case errors.As(err, &insufficientErr):
+				messages := []string{"Insufficient stock", "reserve_stock_error", insufficientErr.Error()}
+				respondWithError(w, srv.Logger(), http.StatusConflict, messages...)
+			default:
+				messages := []string{"Failed to reserve stock", "reserve_stock_error", err.Error()}
+				respondWithError(w, srv.Logger(), statusFromError(err), messages...)
+			}
+			return
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusCreated, reservation)
+	}
+}
+
+//	@Summary		Release a reservation
+//	@Description	Releases a pending reservation, returning its stock to the product.
+//	@ID				release-stock
+//	@Tags			reservations
+//	@Param			id				path	int	true	"Product ID"
+//	@Param			reservationID	path	int	true	"Reservation ID"
+//	@Success		204
+//	@Failure		400	{object}	errorResponse	"Invalid parameter"
+//	@Failure		404	{object}	errorResponse	"Reservation not found"
+//	@Failure		409	{object}	errorResponse	"Reservation is not pending"
+//	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Failure		503	{object}	errorResponse	"Request cancelled"
+//	@Failure		504	{object}	errorResponse	"Request timed out"
+//	@Router			/products/{id}/reservations/{reservationID} [delete]
+func handleReleaseStock(srv Server) http.HandlerFunc {
+	return finishReservation(srv, false)
+}
+
+//	@Summary		Commit a reservation
+//	@Description	Commits a pending reservation once its stock has been sold, so that the stock is never returned to the product.
+//	@Description	A reservation that has expired cannot be committed.
+//	@ID				commit-reservation
+//	@Tags			reservations
+//	@Param			id				path	int	true	"Product ID"
+//	@Param			reservationID	path	int	true	"Reservation ID"
+//	@Success		204
+//	@Failure		400	{object}	errorResponse	"Invalid parameter"
+//	@Failure		404	{object}	errorResponse	"Reservation not found"
+//	@Failure		409	{object}	errorResponse	"Reservation is not pending"
+//	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Failure		503	{object}	errorResponse	"Request cancelled"
+//	@Failure		504	{object}	errorResponse	"Request timed out"
+//	@Router			/products/{id}/reservations/{reservationID}/commit [post]
+func handleCommitReservation(srv Server) http.HandlerFunc {
+	return finishReservation(srv, true)
+}
+
+// Returns a handler that commits the reservation in the path if commit is true, and otherwise releases it.
+func finishReservation(srv Server, commit bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			messages := []string{"Invalid parameter 'id'", "atoi_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+		reservationID, err := strconv.Atoi(chi.URLParam(r, "reservationID"))
+		if err != nil {
+			messages := []string{"Invalid parameter 'reservationID'", "atoi_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		action, key := "release stock", "release_stock_error"
+		if commit {
+			action, key = "commit reservation", "commit_reservation_error"
+			err = srv.Storage().CommitReservation(r.Context(), id, reservationID)
+		} else {
+			err = srv.Storage().ReleaseStock(r.Context(), id, reservationID)
+		}
+		if err != nil {
+			var notFoundErr *storage.NotFoundError
+			var stateErr *storage.ReservationStateError
+			switch {
+			case errors.As(err, &notFoundErr):
+				messages := []string{"Reservation not found", key, notFoundErr.Error()}
+				respondWithError(w, srv.Logger(), http.StatusNotFound, messages...)
+			case errors.As(err, &stateErr):
+				messages := []string{fmt.Sprintf("Reservation is %s", stateErr.Status), key, stateErr.Error()}
+				respondWithError(w, srv.Logger(), http.StatusConflict, messages...)
+			default:
+				messages := []string{"Failed to " + action, key, err.Error()}
+				respondWithError(w, srv.Logger(), statusFromError(err), messages...)
+			}
+			return
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusNoContent, nil)
+	}
+}
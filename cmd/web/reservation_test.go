@@ -0,0 +1,119 @@
+package web_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+)
+
+// Tests reserving stock of a product, and releasing and committing the reservations.
+// Each step is run in order against the same product, which starts with 10 in stock.
+func TestServer_ReservationRoutes(t *testing.T) {
+	srv := newTestServer()
+	srv.MountHandlers()
+
+	productID, err := srv.Storage().CreateProduct(context.Background(), &models.CreateProductRequest{
+		Name:          "Test Product",
+		StockQuantity: 10,
+		Price:         models.NewMoney(199, models.DefaultCurrency),
+	})
+	if err != nil {
+		t.Fatal(fmt.Errorf("Error creating product: %w", err))
+	}
+	url := fmt.Sprint("/v1/api/products/", productID, "/reservations")
+
+	steps := []struct {
+		name               string
+		method             string
+		url                string
+		payload            any
+		expectedStatusCode int
+		expectedStock      int
+	}{
+		{
+			"reserve", http.MethodPost, url, models.ReserveStockRequest{Quantity: 6},
+			http.StatusCreated, 4,
+		},
+		{
+			"reserve too many", http.MethodPost, url, models.ReserveStockRequest{Quantity: 5},
+			http.StatusConflict, 4,
+		},
+		{
+			"reserve none", http.MethodPost, url, models.ReserveStockRequest{Quantity: 0},
+			http.StatusBadRequest, 4,
+		},
+		{
+			"reserve too long", http.MethodPost, url, models.ReserveStockRequest{Quantity: 1, TTLSeconds: 90000},
+			http.StatusBadRequest, 4,
+		},
+		{
+			"reserve product not found", http.MethodPost, "/v1/api/products/200/reservations", models.ReserveStockRequest{Quantity: 1},
+			http.StatusNotFound, 4,
+		},
+		{
+			"reserve rest", http.MethodPost, url, models.ReserveStockRequest{Quantity: 4, TTLSeconds: 60},
+			http.StatusCreated, 0,
+		},
+		{
+			"release", http.MethodDelete, url + "/1", nil,
+			http.StatusNoContent, 6,
+		},
+		{
+			"release released", http.MethodDelete, url + "/1", nil,
+			http.StatusConflict, 6,
+		},
+		{
+			"commit", http.MethodPost, url + "/2/commit", nil,
+			http.StatusNoContent, 6,
+		},
+		{
+			"commit committed", http.MethodPost, url + "/2/commit", nil,
+			http.StatusConflict, 6,
+		},
+		{
+			"commit not found", http.MethodPost, url + "/3/commit", nil,
+			http.StatusNotFound, 6,
+		},
+		{
+			"release invalid id", http.MethodDelete, url + "/not-an-id", nil,
+			http.StatusBadRequest, 6,
+		},
+	}
+
+	for _, step := range steps {
+		body := new(bytes.Buffer)
+		if step.payload != nil {
+			if err = json.NewEncoder(body).Encode(step.payload); err != nil {
+				t.Fatal(fmt.Errorf("Error encoding JSON payload: %w", err))
+			}
+		}
+		req, err := http.NewRequest(step.method, step.url, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+
+		srv.Mux().ServeHTTP(rr, req)
+
+		checkEqual(t, rr.Code, step.expectedStatusCode, step.name+": Status Code")
+		if rr.Code == http.StatusCreated {
+			var reservation models.Reservation
+			if err = json.NewDecoder(rr.Body).Decode(&reservation); err != nil {
+				t.Fatal(fmt.Errorf("Error decoding JSON response: %w", err))
+			}
+			checkEqual(t, reservation.Status, models.ReservationPending, step.name+": Status")
+		}
+
+		product, err := srv.Storage().GetProduct(context.Background(), productID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkEqual(t, product.StockQuantity, step.expectedStock, step.name+": Stock")
+	}
+}
@@ -19,8 +19,10 @@ import (
 type Server interface {
 	Mux() *chi.Mux
 	Storage() storage.Storage
+	Blobs() storage.BlobStore
 	Logger() config.Logger
 	RateLimit() int
+	HealthChecks() []HealthCheck
 	MountHandlers()
 }
 
@@ -28,8 +30,12 @@ type Server interface {
 type chiServer struct {
 	mux       *chi.Mux
 	storage   storage.Storage
+	blobs     storage.BlobStore
 	logger    config.Logger
 	rateLimit int
+	checks    []HealthCheck
+	// readYourWrites is how long a client's reads are made from the primary database after it makes a write.
+	readYourWrites time.Duration
 }
 
 // NewServer is a factory function that returns a Server interface based on the mode passed in.
@@ -39,8 +45,12 @@ func NewServer(mode string, config config.Config) Server {
 		return &chiServer{
 			mux:       chi.NewMux(),
 			storage:   config.Storage,
+			blobs:     config.Blobs,
 			logger:    config.Logger,
 			rateLimit: config.RateLimit,
+			checks:    healthChecks(config),
+
+			readYourWrites: config.ReadYourWrites,
 		}
 	}
 	return nil
@@ -54,6 +64,10 @@ func (srv *chiServer) Storage() storage.Storage {
 	return srv.storage
 }
 
+func (srv *chiServer) Blobs() storage.BlobStore {
+	return srv.blobs
+}
+
 func (srv *chiServer) Logger() config.Logger {
 	return srv.logger
 }
@@ -62,6 +76,10 @@ func (srv *chiServer) RateLimit() int {
 	return srv.rateLimit
 }
 
+func (srv *chiServer) HealthChecks() []HealthCheck {
+	return srv.checks
+}
+
 // MountHandlers mounts the routes and middleware to the server.
 // It also sets up the swagger docs, and a walk function to log the routes and middleware.
 //	@title			E-Gommerce API
@@ -81,11 +99,22 @@ func (srv *chiServer) RateLimit() int {
 //	@BasePath	/v1/api
 func (srv *chiServer) MountHandlers() {
 	// Middleware
+	srv.mux.Use(middleware.RequestID)
 	srv.mux.Use(middleware.Logger)
 	srv.mux.Use(middleware.Heartbeat("/ping"))
-	srv.mux.Use(middleware.AllowContentType("application/json"))
+	srv.mux.Use(Probes(srv))
+	srv.mux.Use(allowContentType([]string{"application/json"}, contentTypeException{
+		Method:       http.MethodPost,
+		Pattern:      "/v1/api/products/*/images",
+		ContentTypes: []string{"multipart/form-data"},
+	}, contentTypeException{
+		Method:       http.MethodPatch,
+		Pattern:      "/v1/api/products/*",
+		ContentTypes: []string{mergePatchContentType, jsonPatchContentType},
+	}))
 	srv.mux.Use(middleware.CleanPath)
 	srv.mux.Use(middleware.Recoverer)
+	srv.mux.Use(ReadYourWrites(srv.readYourWrites))
 	srv.mux.Use(middleware.RedirectSlashes)
 	srv.mux.Use(httprate.Limit(
 		srv.rateLimit,
@@ -99,6 +128,8 @@ func (srv *chiServer) MountHandlers() {
 	// Routes
 	srv.mux.Route("/v1", func(r chi.Router) {
 		r.Mount("/api/products", ProductRoutes(srv))
+		r.Mount("/api/exchange-rates", ExchangeRateRoutes(srv))
+		r.Mount("/api/categories", CategoryRoutes(srv))
 	})
 
 	// Walk the router to see the routes and middleware. Must be done after the routes are mounted.
@@ -6,19 +6,22 @@ import (
 	"github.com/Broderick-Westrope/e-gommerce/internal/models"
 	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 // testServer is a mock implementation of the Server interface.
 type testServer struct {
 	mux     *chi.Mux
-	storage *storage.TestStore
+	storage *storage.Memory
+	blobs   storage.BlobStore
 	logger  config.Logger
+	checks  []web.HealthCheck
 }
 
 func newTestServer() *testServer {
 	return &testServer{
 		mux:     chi.NewRouter(),
-		storage: storage.NewTestStore(),
+		storage: storage.NewMemory(),
 		logger:  config.NewLog(),
 	}
 }
@@ -31,6 +34,10 @@ func (srv *testServer) Storage() storage.Storage {
 	return srv.storage
 }
 
+func (srv *testServer) Blobs() storage.BlobStore {
+	return srv.blobs
+}
+
 func (srv *testServer) Logger() config.Logger {
 	return srv.logger
 }
@@ -39,9 +46,17 @@ func (srv *testServer) RateLimit() int {
 	return 100
 }
 
+func (srv *testServer) HealthChecks() []web.HealthCheck {
+	return srv.checks
+}
+
 func (srv *testServer) MountHandlers() {
+	srv.mux.Use(middleware.RequestID)
+	srv.mux.Use(web.Probes(srv))
 	srv.mux.Route("/v1", func(r chi.Router) {
 		r.Mount("/api/products", web.ProductRoutes(srv))
+		r.Mount("/api/exchange-rates", web.ExchangeRateRoutes(srv))
+		r.Mount("/api/categories", web.CategoryRoutes(srv))
 	})
 }
 
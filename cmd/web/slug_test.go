@@ -0,0 +1,106 @@
+package web_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+)
+
+// Tests creating and updating products with SKUs and slugs, and getting products by them.
+// Each step is run in order against the same server.
+func TestServer_SKUAndSlugRoutes(t *testing.T) {
+	srv := newTestServer()
+	srv.MountHandlers()
+
+	price := models.NewMoney(1000, models.DefaultCurrency)
+	steps := []struct {
+		name               string
+		method             string
+		url                string
+		payload            any
+		expectedStatusCode int
+		// expectedSlug is the slug of the product in the response, if it is not empty.
+		expectedSlug string
+	}{
+		{
+			"create", http.MethodPost, "/v1/api/products",
+			models.CreateProductRequest{Name: "Wool Hat", SKU: "HAT-1", Price: price},
+			http.StatusCreated, "",
+		},
+		{
+			"create with same name", http.MethodPost, "/v1/api/products",
+			models.CreateProductRequest{Name: "Wool Hat", Price: price},
+			http.StatusCreated, "",
+		},
+		{
+			"create with used SKU", http.MethodPost, "/v1/api/products",
+			models.CreateProductRequest{Name: "Hat", SKU: "HAT-1", Price: price},
+			http.StatusConflict, "",
+		},
+		{
+			"create with used slug", http.MethodPost, "/v1/api/products",
+			models.CreateProductRequest{Name: "Hat", Slug: "wool-hat", Price: price},
+			http.StatusConflict, "",
+		},
+		{
+			"create with invalid slug", http.MethodPost, "/v1/api/products",
+			models.CreateProductRequest{Name: "Hat", Slug: "Wool Hat", Price: price},
+			http.StatusBadRequest, "",
+		},
+		{
+			"update with used SKU", http.MethodPut, "/v1/api/products/2",
+			models.CreateProductRequest{Name: "Wool Hat", SKU: "HAT-1", Price: price},
+			http.StatusConflict, "",
+		},
+		{
+			"get by slug", http.MethodGet, "/v1/api/products/by-slug/wool-hat-2", nil,
+			http.StatusOK, "wool-hat-2",
+		},
+		{
+			"get by SKU", http.MethodGet, "/v1/api/products/by-sku/HAT-1", nil,
+			http.StatusOK, "wool-hat",
+		},
+		{
+			"get by unknown slug", http.MethodGet, "/v1/api/products/by-slug/scarf", nil,
+			http.StatusNotFound, "",
+		},
+		{
+			"get by unknown SKU", http.MethodGet, "/v1/api/products/by-sku/HAT-9", nil,
+			http.StatusNotFound, "",
+		},
+	}
+
+	for _, step := range steps {
+		body := new(bytes.Buffer)
+		if step.payload != nil {
+			if err := json.NewEncoder(body).Encode(step.payload); err != nil {
+				t.Fatal(fmt.Errorf("Error encoding JSON payload: %w", err))
+			}
+		}
+		req, err := http.NewRequest(step.method, step.url, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if step.method == http.MethodPut {
+			req.Header.Set("If-Match", "*")
+		}
+		rr := httptest.NewRecorder()
+
+		srv.Mux().ServeHTTP(rr, req)
+
+		checkEqual(t, rr.Code, step.expectedStatusCode, step.name+": Status Code")
+		if step.expectedSlug != "" {
+			var product models.Product
+			if err = json.NewDecoder(rr.Body).Decode(&product); err != nil {
+				t.Fatal(fmt.Errorf("Error decoding JSON response: %w", err))
+			}
+			checkEqual(t, product.Slug, step.expectedSlug, step.name+": Slug")
+			checkEqual(t, rr.Header().Get("ETag") != "", true, step.name+": ETag")
+		}
+	}
+}
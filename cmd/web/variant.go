@@ -0,0 +1,240 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+// variantsResponse is the list of variants of a product.
+type variantsResponse struct {
+	Variants []models.Variant `json:"variants"`
+}
+
+// Returns the status code and error messages of a failed variant storage call, where notFound describes
+// what was not found, action what failed, and key the log key of the error.
+func variantError(err error, notFound, action, key string) (int, []string) {
+	var notFoundErr *storage.NotFoundError
+	var conflictErr *storage.ConflictError
+	switch {
+	case errors.As(err, &notFoundErr):
+		return http.StatusNotFound, []string{notFound + " not found", key, notFoundErr.Error()}
+	case errors.As(err, &conflictErr):
+		return http.StatusConflict, []string{"SKU is already used", key, conflictErr.Error()}
+	default:
+		return statusFromError(err), []string{"Failed to " + action, key, err.Error()}
+	}
+}
+
+// Parses the 'id' and 'variantID' URL parameters of r. The messages of the error response are returned on failure.
+func parseVariantParams(r *http.Request) (int, int, []string) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return 0, 0, []string{"Invalid parameter 'id'", "atoi_error", err.Error()}
+	}
+	variantID, err := strconv.Atoi(chi.URLParam(r, "variantID"))
+	if err != nil {
+		return 0, 0, []string{"Invalid parameter 'variantID'", "atoi_error", err.Error()}
+	}
+	return id, variantID, nil
+}
+
+//	@Summary		Get the variants of a product
+//	@Description	Retrieves the variants of a product, ordered by ID. Their prices are in the default currency.
+//	@ID				get-variants
+//	@Tags			variants
+//	@Produce		json
+//	@Param			id	path		int					true	"Product ID"
+//	@Success		200	{object}	variantsResponse	"Variants"
+//	@Failure		400	{object}	errorResponse		"Invalid parameter 'id'"
+//	@Failure		404	{object}	errorResponse		"Product not found"
+//	@Failure		500	{object}	errorResponse		"Internal Server Error"
+//	@Failure		503	{object}	errorResponse		"Request cancelled"
+//	@Failure		504	{object}	errorResponse		"Request timed out"
+//	@Router			/products/{id}/variants [get]
+func handleGetVariants(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			messages := []string{"Invalid parameter 'id'", "atoi_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		variants, err := srv.Storage().GetVariants(r.Context(), id)
+		if err != nil {
+			status, messages := variantError(err, "Product", "get variants", "get_variants_error")
+			respondWithError(w, srv.Logger(), status, messages...)
+			return
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusOK, variantsResponse{variants})
+	}
+}
+
+//	@Summary		Get a variant of a product
+//	@Description	Retrieves a variant of a product by ID. Its price is in the default currency.
+//	@ID				get-variant
+//	@Tags			variants
+//	@Produce		json
+//	@Param			id			path		int				true	"Product ID"
+//	@Param			variantID	path		int				true	"Variant ID"
+//	@Success		200			{object}	models.Variant	"Variant"
+//	@Failure		400			{object}	errorResponse	"Invalid parameter"
+//	@Failure		404			{object}	errorResponse	"Variant not found"
+//	@Failure		500			{object}	errorResponse	"Internal Server Error"
+//	@Failure		503			{object}	errorResponse	"Request cancelled"
+//	@Failure		504			{object}	errorResponse	"Request timed out"
+//	@Router			/products/{id}/variants/{variantID} [get]
+func handleGetVariant(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, variantID, messages := parseVariantParams(r)
+		if messages != nil {
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		variant, err := srv.Storage().GetVariant(r.Context(), id, variantID)
+		if err != nil {
+			status, messages := variantError(err, "Variant", "get variant", "get_variant_error")
+			respondWithError(w, srv.Logger(), status, messages...)
+			return
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusOK, variant)
+	}
+}
+
+//	@Summary		Create a variant of a product
+//	@Description	Creates a variant of a product with its own SKU, option values and stock.
+//	@Description	The variant has the price of the product unless it has its own price, in the default currency.
+//	@ID				create-variant
+//	@Tags			variants
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int						true	"Product ID"
+//	@Param			variant	body		models.VariantRequest	true	"Variant"
+//	@Success		201		{object}	idResponse				"Variant ID"
+//	@Failure		400		{object}	errorResponse			"Invalid variant"
+//	@Failure		404		{object}	errorResponse			"Product not found"
+//	@Failure		409		{object}	errorResponse			"SKU is already used"
+//	@Failure		500		{object}	errorResponse			"Internal Server Error"
+//	@Failure		503		{object}	errorResponse			"Request cancelled"
+//	@Failure		504		{object}	errorResponse			"Request timed out"
+//	@Router			/products/{id}/variants [post]
+func handleCreateVariant(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			messages := []string{"Invalid parameter 'id'", "atoi_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		var req models.VariantRequest
+		err = parseJSONBody(r, &req)
+		if err != nil {
+			messages := []string{"Failed to parse JSON payload", "parse_json_body_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+		if err = req.Validate(); err != nil {
+			messages := []string{"Invalid variant", "validation_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		variantID, err := srv.Storage().CreateVariant(r.Context(), id, &req)
+		if err != nil {
+			status, messages := variantError(err, "Product", "create variant", "create_variant_error")
+			respondWithError(w, srv.Logger(), status, messages...)
+			return
+		}
+
+		respondWithID(w, srv.Logger(), http.StatusCreated, variantID)
+	}
+}
+
+//	@Summary		Update a variant of a product
+//	@Description	Replaces the SKU, option values, price and stock of a variant of a product.
+//	@ID				update-variant
+//	@Tags			variants
+//	@Accept			json
+//	@Param			id			path	int						true	"Product ID"
+//	@Param			variantID	path	int						true	"Variant ID"
+//	@Param			variant		body	models.VariantRequest	true	"Variant"
+//	@Success		204
+//	@Failure		400	{object}	errorResponse	"Invalid parameter or variant"
+//	@Failure		404	{object}	errorResponse	"Variant not found"
+//	@Failure		409	{object}	errorResponse	"SKU is already used"
+//	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Failure		503	{object}	errorResponse	"Request cancelled"
+//	@Failure		504	{object}	errorResponse	"Request timed out"
+//	@Router			/products/{id}/variants/{variantID} [put]
+func handleUpdateVariant(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, variantID, messages := parseVariantParams(r)
+		if messages != nil {
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		var req models.VariantRequest
+		err := parseJSONBody(r, &req)
+		if err != nil {
+			messages := []string{"Failed to parse JSON payload", "parse_json_body_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+		if err = req.Validate(); err != nil {
+			messages := []string{"Invalid variant", "validation_error", err.Error()}
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		err = srv.Storage().UpdateVariant(r.Context(), req.ToVariant(id, variantID))
+		if err != nil {
+			status, messages := variantError(err, "Variant", "update variant", "update_variant_error")
+			respondWithError(w, srv.Logger(), status, messages...)
+			return
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusNoContent, nil)
+	}
+}
+
+//	@Summary		Delete a variant of a product
+//	@Description	Permanently deletes a variant of a product.
+//	@ID				delete-variant
+//	@Tags			variants
+//	@Param			id			path	int	true	"Product ID"
+//	@Param			variantID	path	int	true	"Variant ID"
+//	@Success		204
+//	@Failure		400	{object}	errorResponse	"Invalid parameter"
+//	@Failure		404	{object}	errorResponse	"Variant not found"
+//	@Failure		500	{object}	errorResponse	"Internal Server Error"
+//	@Failure		503	{object}	errorResponse	"Request cancelled"
+//	@Failure		504	{object}	errorResponse	"Request timed out"
+//	@Router			/products/{id}/variants/{variantID} [delete]
+func handleDeleteVariant(srv Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, variantID, messages := parseVariantParams(r)
+		if messages != nil {
+			respondWithError(w, srv.Logger(), http.StatusBadRequest, messages...)
+			return
+		}
+
+		err := srv.Storage().DeleteVariant(r.Context(), id, variantID)
+		if err != nil {
+			status, messages := variantError(err, "Variant", "delete variant", "delete_variant_error")
+			respondWithError(w, srv.Logger(), status, messages...)
+			return
+		}
+
+		respondWithJSON(w, srv.Logger(), http.StatusNoContent, nil)
+	}
+}
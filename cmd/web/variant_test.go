@@ -0,0 +1,135 @@
+package web_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+)
+
+// Tests creating, getting, updating and deleting the variants of a product.
+// Each step is run in order against the same product, which has 2 units of stock.
+func TestServer_VariantRoutes(t *testing.T) {
+	srv := newTestServer()
+	srv.MountHandlers()
+
+	productID, err := srv.Storage().CreateProduct(context.Background(), &models.CreateProductRequest{
+		Name:          "T-Shirt",
+		Price:         models.NewMoney(2000, models.DefaultCurrency),
+		StockQuantity: 2,
+	})
+	if err != nil {
+		t.Fatal(fmt.Errorf("Error creating product: %w", err))
+	}
+	url := fmt.Sprint("/v1/api/products/", productID)
+	price := models.NewMoney(2500, "")
+
+	steps := []struct {
+		name               string
+		method             string
+		url                string
+		payload            any
+		expectedStatusCode int
+		// expectedTotalStock is the total stock of the product in the response, if it is not zero.
+		expectedTotalStock int
+	}{
+		{
+			"create", http.MethodPost, url + "/variants",
+			models.VariantRequest{SKU: "TSHIRT-S", Options: map[string]string{"size": "S"}, StockQuantity: 3},
+			http.StatusCreated, 0,
+		},
+		{
+			"create with price", http.MethodPost, url + "/variants",
+			models.VariantRequest{SKU: "TSHIRT-L", Options: map[string]string{"size": "L"}, Price: &price, StockQuantity: 4},
+			http.StatusCreated, 0,
+		},
+		{
+			"create duplicate sku", http.MethodPost, url + "/variants",
+			models.VariantRequest{SKU: "TSHIRT-S"},
+			http.StatusConflict, 0,
+		},
+		{
+			"create without sku", http.MethodPost, url + "/variants",
+			models.VariantRequest{StockQuantity: 1},
+			http.StatusBadRequest, 0,
+		},
+		{
+			"create for missing product", http.MethodPost, "/v1/api/products/200/variants",
+			models.VariantRequest{SKU: "TSHIRT-M"},
+			http.StatusNotFound, 0,
+		},
+		{
+			"get product", http.MethodGet, url, nil,
+			http.StatusOK, 9,
+		},
+		{
+			"get variants", http.MethodGet, url + "/variants", nil,
+			http.StatusOK, 0,
+		},
+		{
+			"update", http.MethodPut, url + "/variants/1",
+			models.VariantRequest{SKU: "TSHIRT-S", Options: map[string]string{"size": "S"}, StockQuantity: 1},
+			http.StatusNoContent, 0,
+		},
+		{
+			"update to duplicate sku", http.MethodPut, url + "/variants/1",
+			models.VariantRequest{SKU: "TSHIRT-L"},
+			http.StatusConflict, 0,
+		},
+		{
+			"update negative stock", http.MethodPut, url + "/variants/1",
+			models.VariantRequest{SKU: "TSHIRT-S", StockQuantity: -1},
+			http.StatusBadRequest, 0,
+		},
+		{
+			"get updated product", http.MethodGet, url, nil,
+			http.StatusOK, 7,
+		},
+		{
+			"delete", http.MethodDelete, url + "/variants/2", nil,
+			http.StatusNoContent, 0,
+		},
+		{
+			"get deleted", http.MethodGet, url + "/variants/2", nil,
+			http.StatusNotFound, 0,
+		},
+		{
+			"get invalid id", http.MethodGet, url + "/variants/abc", nil,
+			http.StatusBadRequest, 0,
+		},
+		{
+			"get product after delete", http.MethodGet, url, nil,
+			http.StatusOK, 3,
+		},
+	}
+
+	for _, step := range steps {
+		body := new(bytes.Buffer)
+		if step.payload != nil {
+			if err = json.NewEncoder(body).Encode(step.payload); err != nil {
+				t.Fatal(fmt.Errorf("Error encoding JSON payload: %w", err))
+			}
+		}
+		req, err := http.NewRequest(step.method, step.url, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+
+		srv.Mux().ServeHTTP(rr, req)
+
+		checkEqual(t, rr.Code, step.expectedStatusCode, step.name+": Status Code")
+		if step.expectedTotalStock != 0 {
+			var product models.Product
+			if err = json.NewDecoder(rr.Body).Decode(&product); err != nil {
+				t.Fatal(fmt.Errorf("Error decoding JSON response: %w", err))
+			}
+			checkEqual(t, product.TotalStockQuantity, step.expectedTotalStock, step.name+": Total Stock Quantity")
+		}
+	}
+}
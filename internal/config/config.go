@@ -1,13 +1,20 @@
 package config
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"flag"
+	"fmt"
+	"io/fs"
 	"log"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/Broderick-Westrope/e-gommerce/internal/schema"
 	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+	"github.com/Broderick-Westrope/e-gommerce/migrations"
 	"github.com/go-sql-driver/mysql"
 	"github.com/joho/godotenv"
 )
@@ -19,6 +26,14 @@ type Config struct {
 	Logger            Logger
 	Storage           storage.Storage
 	RateLimit         int
+	// Blobs stores the content of product images.
+	Blobs storage.BlobStore
+	// Migrator migrates the schema of the storage engine's database. It is nil for engines without a database.
+	Migrator *schema.Migrator
+	// RequireCurrentSchema is whether the server should refuse to start while there are pending migrations.
+	RequireCurrentSchema bool
+	// ReadYourWrites is how long a client's reads are made from the primary database after it makes a write.
+	ReadYourWrites time.Duration
 }
 
 // New returns a new config struct.
@@ -26,10 +41,21 @@ func New() *Config {
 	addr := flag.String("addr", ":4000", "HTTP network address")
 	readHeaderTimeout := flag.Duration("read-header-timeout", 10*time.Second, "HTTP read header timeout")
 	rateLimit := flag.Int("rate-limit", 10, "requests per minute rate limit")
+	storageEngine := flag.String("storage", "maria", "storage engine: maria, sqlite or memory")
+	dsn := flag.String("dsn", "", "data source name of the database: a MariaDB DSN (overrides the DB_* environment variables) "+
+		"or a SQLite file path (default \""+defaultSQLitePath+"\")")
+	requireCurrentSchema := flag.Bool("require-current-schema", false, "refuse to start while there are pending migrations")
+	snapshot := flag.String("snapshot", "", "JSON file that the memory storage engine is loaded from and saved to (not persisted by default)")
+	cacheSize := flag.Int("cache-size", 0, "maximum number of products held in the read-through cache (disabled when 0)")
+	cacheTTL := flag.Duration("cache-ttl", time.Minute, "how long products are held in the read-through cache")
+	blobDir := flag.String("blob-dir", "blobs", "directory that the content of product images is stored in")
+	readYourWrites := flag.Duration("read-your-writes", time.Second,
+		"how long a client's reads are made from the primary database rather than its replicas or the cache after it makes a write")
 
 	flag.Parse()
 
-	if err := godotenv.Load(); err != nil {
+	// The environment variables may also be set without a .env file.
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
 		log.Fatal(err.Error())
 	}
 
@@ -40,32 +66,139 @@ func New() *Config {
 		logger = NewSlog()
 	}
 
-	db := setupDB(logger)
-	storage := storage.NewMaria(db)
+	// SQLite databases are migrated on startup, unless they are being migrated by the migrate subcommand
+	// or the server must refuse to start while their schema is not current.
+	autoMigrate := flag.Arg(0) != "migrate" && !*requireCurrentSchema
+	store, migrator := setupStorage(logger, *storageEngine, *dsn, *snapshot, autoMigrate)
+	if *cacheSize > 0 {
+		store = storage.NewCached(store, *cacheSize, *cacheTTL)
+	}
+	blobs, err := storage.NewDiskBlobStore(*blobDir)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
 
 	return &Config{
 		Addr:              addr,
 		ReadHeaderTimeout: readHeaderTimeout,
 		Logger:            logger,
-		Storage:           storage,
+		Storage:           store,
+		Blobs:             blobs,
 		RateLimit:         *rateLimit,
+		Migrator:          migrator,
+
+		RequireCurrentSchema: *requireCurrentSchema,
+		ReadYourWrites:       *readYourWrites,
 	}
 }
 
-// setupDB returns a new sql.DB based on the environment variables.
-func setupDB(logger Logger) *sql.DB {
-	dbUsername, dbPassword, dbAddress, dbName := getDBEnvVariables(logger)
+// defaultSQLitePath is the SQLite database file used when no DSN is given.
+const defaultSQLitePath = "e-gommerce.db"
 
-	// Use the mySQL driver and environment variables to create a DSN.
-	mysqlCfg := &mysql.Config{
-		User:                 dbUsername,
-		Passwd:               dbPassword,
-		Addr:                 dbAddress,
-		DBName:               dbName,
-		Net:                  "tcp",
-		AllowNativePasswords: true,
+// setupStorage returns the storage engine with the given name, connected to the database at dsn,
+// along with a migrator for the schema of that database.
+// An empty dsn uses the default database of the engine. SQLite databases are migrated to the latest version
+// if autoMigrate is true.
+// The memory engine has no database (or migrator), and is instead persisted to the snapshot file if it is not empty.
+func setupStorage(logger Logger, engine, dsn, snapshot string, autoMigrate bool) (storage.Storage, *schema.Migrator) {
+	var db *sql.DB
+	var source fs.FS
+	var result storage.Storage
+	var err error
+
+	switch engine {
+	case "maria":
+		var replicas []*sql.DB
+		db, replicas = setupDB(logger, dsn)
+		source = migrations.Maria()
+		maria := storage.NewMariaWithReplicas(db, replicas)
+		maria.SetRetryPolicy(retryPolicy(logger))
+		result = maria
+	case "sqlite":
+		if dsn == "" {
+			dsn = defaultSQLitePath
+		}
+		db, err = storage.OpenSQLite(context.Background(), dsn)
+		if err == nil {
+			source = migrations.SQLite()
+			result, err = storage.NewSQLite(context.Background(), db, autoMigrate)
+		}
+	case "memory":
+		if snapshot == "" {
+			return storage.NewMemory(), nil
+		}
+		result, err = storage.NewMemoryWithSnapshot(snapshot)
+		if err == nil {
+			return result, nil
+		}
+	default:
+		err = fmt.Errorf("Unknown storage engine %q", engine)
+	}
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
 	}
 
+	migrator, err := schema.NewMigrator(db, source)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	return result, migrator
+}
+
+// retryPolicy returns the default retry policy of the storage engines, which logs each retry with logger.
+func retryPolicy(logger Logger) storage.RetryPolicy {
+	policy := storage.DefaultRetryPolicy()
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+		logger.Warn("Retrying database operation after a transient error", "attempt", attempt,
+			"max_attempts", policy.MaxAttempts, "delay", delay.String(), "retry_error", err.Error())
+	}
+	return policy
+}
+
+// setupDB returns a new sql.DB connected to the MariaDB database at dsn, along with one connected to each of its replicas.
+// If dsn is empty, it is created from the environment variables, which also give the addresses of the replicas.
+// The replicas are accessed with the same credentials and database name as the primary database.
+// DATETIME and TIMESTAMP columns are always parsed into time.Time.
+func setupDB(logger Logger, dsn string) (*sql.DB, []*sql.DB) {
+	var mysqlCfg *mysql.Config
+	var replicaAddresses []string
+	if dsn == "" {
+		var dbUsername, dbPassword, dbAddress, dbName string
+		dbUsername, dbPassword, dbAddress, dbName, replicaAddresses = getDBEnvVariables(logger)
+
+		// Use the mySQL driver and environment variables to create a DSN.
+		mysqlCfg = &mysql.Config{
+			User:                 dbUsername,
+			Passwd:               dbPassword,
+			Addr:                 dbAddress,
+			DBName:               dbName,
+			Net:                  "tcp",
+			AllowNativePasswords: true,
+		}
+	} else {
+		var err error
+		mysqlCfg, err = mysql.ParseDSN(dsn)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+	}
+	mysqlCfg.ParseTime = true
+
+	replicas := make([]*sql.DB, 0, len(replicaAddresses))
+	for _, address := range replicaAddresses {
+		replicaCfg := mysqlCfg.Clone()
+		replicaCfg.Addr = address
+		replicas = append(replicas, openDB(logger, replicaCfg))
+	}
+	return openDB(logger, mysqlCfg), replicas
+}
+
+// openDB returns a new sql.DB connected to the MariaDB database given by mysqlCfg.
+func openDB(logger Logger, mysqlCfg *mysql.Config) *sql.DB {
 	db, err := sql.Open("mysql", mysqlCfg.FormatDSN())
 	if err != nil {
 		logger.Error(err.Error())
@@ -80,8 +213,9 @@ func setupDB(logger Logger) *sql.DB {
 }
 
 // getDBEnvVariables returns the database environment variables.
-// The variables are DB_USERNAME, DB_PASSWORD, DB_ADDRESS, and DB_NAME.
-func getDBEnvVariables(logger Logger) (string, string, string, string) {
+// The variables are DB_USERNAME, DB_PASSWORD, DB_ADDRESS, and DB_NAME, and the optional DB_REPLICA_ADDRESSES,
+// which is a comma-separated list of the addresses of the replicas of the database.
+func getDBEnvVariables(logger Logger) (string, string, string, string, []string) {
 	var exists bool
 	var dbUsername, dbPassword, dbAddress, dbName string
 	if dbUsername, exists = os.LookupEnv("DB_USERNAME"); !exists {
@@ -101,5 +235,12 @@ func getDBEnvVariables(logger Logger) (string, string, string, string) {
 		os.Exit(1)
 	}
 
-	return dbUsername, dbPassword, dbAddress, dbName
+	var replicaAddresses []string
+	for _, address := range strings.Split(os.Getenv("DB_REPLICA_ADDRESSES"), ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			replicaAddresses = append(replicaAddresses, address)
+		}
+	}
+
+	return dbUsername, dbPassword, dbAddress, dbName, replicaAddresses
 }
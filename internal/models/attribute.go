@@ -0,0 +1,124 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxTagLength is the length of the longest tag that can be stored.
+	maxTagLength = 64
+	// maxAttributeNameLength is the length of the longest attribute name that can be stored.
+	maxAttributeNameLength = 64
+	// maxAttributeValueLength is the length of the longest attribute value that can be stored, in its text form.
+	maxAttributeValueLength = 255
+)
+
+// attributeNamePattern matches the names of attributes, which are used in query parameters such as 'attr.weight_g'.
+var attributeNamePattern = regexp.MustCompile(`^[a-z0-9_-]+$`) //nolint:gochecknoglobals // compiled once
+
+// AttributeType is the type of the value of a product attribute.
+type AttributeType string
+
+const (
+	AttributeString  AttributeType = "string"
+	AttributeNumber  AttributeType = "number"
+	AttributeBoolean AttributeType = "boolean"
+)
+
+// SetTagsRequest is a struct that defines the tags of a product.
+type SetTagsRequest struct {
+	Tags []string `json:"tags" example:"organic,clearance"`
+}
+
+// Validate returns an error if the tags cannot be set.
+// The tags are normalised first: they are trimmed, lowercased, sorted and deduplicated.
+func (s *SetTagsRequest) Validate() error {
+	tags := make([]string, 0, len(s.Tags))
+	for _, tag := range s.Tags {
+		tag = NormalizeTag(tag)
+		if tag == "" || len(tag) > maxTagLength {
+			return fmt.Errorf("tags must have between 1 and %d characters", maxTagLength)
+		}
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+	s.Tags = slices.Compact(tags)
+	return nil
+}
+
+// NormalizeTag returns tag in the form that it is stored and filtered in.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// SetAttributesRequest is a struct that defines the attributes of a product.
+type SetAttributesRequest struct {
+	// Attributes are the values of the attributes by name. Each value is a string, number or boolean.
+	Attributes map[string]any `json:"attributes"`
+}
+
+// Validate returns an error if the attributes cannot be set.
+func (s *SetAttributesRequest) Validate() error {
+	for name, value := range s.Attributes {
+		if !ValidAttributeName(name) {
+			return fmt.Errorf("attribute names must have between 1 and %d lowercase letters, digits, underscores and hyphens",
+				maxAttributeNameLength)
+		}
+		if _, _, err := EncodeAttribute(value); err != nil {
+			return fmt.Errorf("attribute %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// ValidAttributeName returns whether name can be the name of an attribute.
+func ValidAttributeName(name string) bool {
+	return len(name) <= maxAttributeNameLength && attributeNamePattern.MatchString(name)
+}
+
+// EncodeAttribute returns the type and text form of an attribute value, which must be a string, number or boolean.
+// The text form is what attributes are filtered by: numbers are formatted in their shortest form (eg. "250").
+func EncodeAttribute(value any) (AttributeType, string, error) {
+	var t AttributeType
+	var text string
+	switch v := value.(type) {
+	case string:
+		t, text = AttributeString, v
+	case bool:
+		t, text = AttributeBoolean, strconv.FormatBool(v)
+	case int:
+		t, text = AttributeNumber, strconv.Itoa(v)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", "", errors.New("numbers must be finite")
+		}
+		t, text = AttributeNumber, strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return "", "", errors.New("values must be strings, numbers or booleans")
+	}
+	if len(text) > maxAttributeValueLength {
+		return "", "", fmt.Errorf("values must have at most %d characters", maxAttributeValueLength)
+	}
+	return t, text, nil
+}
+
+// DecodeAttribute returns the attribute value with type t and the given text form, as returned by EncodeAttribute.
+// Numbers are returned as float64, as they are when decoded from JSON.
+func DecodeAttribute(t AttributeType, text string) (any, error) {
+	switch t {
+	case AttributeString:
+		return text, nil
+	case AttributeBoolean:
+		return strconv.ParseBool(text)
+	case AttributeNumber:
+		return strconv.ParseFloat(text, 64)
+	default:
+		return nil, fmt.Errorf("unknown attribute type %q", t)
+	}
+}
@@ -0,0 +1,79 @@
+package models_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+)
+
+// Tests that attribute values are encoded in their text form, and decoded back into the same value.
+func TestEncodeAttribute(t *testing.T) {
+	tt := []struct {
+		name         string
+		value        any
+		expectedType models.AttributeType
+		expectedText string
+		expectedErr  bool
+		// decoded is the value that is expected to be decoded, if it is not the value itself.
+		decoded any
+	}{
+		{"string", "cotton", models.AttributeString, "cotton", false, nil},
+		{"integer", 250, models.AttributeNumber, "250", false, 250.0},
+		{"whole float", 250.0, models.AttributeNumber, "250", false, nil},
+		{"fraction", 0.5, models.AttributeNumber, "0.5", false, nil},
+		{"boolean", true, models.AttributeBoolean, "true", false, nil},
+		{"infinity", math.Inf(1), "", "", true, nil},
+		{"array", []any{"a"}, "", "", true, nil},
+		{"null", nil, "", "", true, nil},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			attributeType, text, err := models.EncodeAttribute(tc.value)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("Error: got %v", err)
+			}
+			if attributeType != tc.expectedType || text != tc.expectedText {
+				t.Errorf("Encoded: got %q %q want %q %q", attributeType, text, tc.expectedType, tc.expectedText)
+			}
+			if err != nil {
+				return
+			}
+			decoded, err := models.DecodeAttribute(attributeType, text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := tc.decoded
+			if expected == nil {
+				expected = tc.value
+			}
+			if decoded != expected {
+				t.Errorf("Decoded: got %#v want %#v", decoded, expected)
+			}
+		})
+	}
+}
+
+// Tests that tags are normalised, and that invalid tags and attribute names are rejected.
+func TestSetTagsAndAttributesRequest_Validate(t *testing.T) {
+	tags := models.SetTagsRequest{Tags: []string{" Organic", "clearance", "organic "}}
+	if err := tags.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tags.Tags, []string{"clearance", "organic"}) {
+		t.Errorf("Tags: got %q", tags.Tags)
+	}
+	tags = models.SetTagsRequest{Tags: []string{"  "}}
+	if err := tags.Validate(); err == nil {
+		t.Errorf("Empty Tag: got nil error")
+	}
+
+	for name, valid := range map[string]bool{"material": true, "weight_g": true, "Material": false, "a.b": false, "": false} {
+		attributes := models.SetAttributesRequest{Attributes: map[string]any{name: "value"}}
+		if err := attributes.Validate(); (err == nil) != valid {
+			t.Errorf("Attribute Name %q: got error %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+// BulkMode is how a bulk request handles the failure of one of its operations.
+type BulkMode string
+
+const (
+	// BulkAtomic rolls back every operation if any of them fails.
+	BulkAtomic BulkMode = "atomic"
+	// BulkBestEffort keeps the operations that succeed, even if others fail.
+	BulkBestEffort BulkMode = "best_effort"
+)
+
+// BulkOperationType is the kind of change made by a bulk operation.
+type BulkOperationType string
+
+const (
+	BulkCreate BulkOperationType = "create"
+	BulkUpdate BulkOperationType = "update"
+	BulkDelete BulkOperationType = "delete"
+)
+
+// BulkOperation is a struct that defines a single create, update or delete in a bulk request.
+type BulkOperation struct {
+	Op BulkOperationType `json:"op" enums:"create,update,delete"`
+	// ID is the product to update or delete.
+	ID int `json:"id,omitempty"`
+	// Version is the version that the product to update or delete is expected to have.
+	// It is omitted to modify the product regardless of its version.
+	Version int `json:"version,omitempty"`
+	// Product is the product to create, or the new fields of the product to update.
+	Product *CreateProductRequest `json:"product,omitempty"`
+}
+
+// BulkRequest is a struct that defines the fields required to make many changes to products at once.
+type BulkRequest struct {
+	// Mode defaults to BulkAtomic.
+	Mode       BulkMode        `json:"mode" enums:"atomic,best_effort"`
+	Operations []BulkOperation `json:"operations"`
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxSlugLength is the length of the longest slug that can be stored.
+const maxSlugLength = 255
+
+// Category is a struct that defines the fields of a product category. Categories form a tree through their parents.
+type Category struct {
+	ID int `json:"id"`
+	// ParentID is the ID of the parent of the category, or nil if it is a root category.
+	ParentID *int   `json:"parent_id"`
+	Name     string `json:"name" example:"T-Shirts"`
+	// Slug identifies the category in URLs, and is unique among every category.
+	Slug string `json:"slug" example:"t-shirts"`
+	// Position orders the category among the categories with the same parent, from the lowest.
+	Position int `json:"position"`
+	// Children are the subcategories of the category, in order. They are only set when listing a subtree.
+	Children []Category `json:"children,omitempty"`
+}
+
+// CategoryRequest is a struct that defines the fields required to create or update a category.
+type CategoryRequest struct {
+	ParentID *int   `json:"parent_id"`
+	Name     string `json:"name" example:"T-Shirts"`
+	// Slug is generated from the name if it is omitted.
+	Slug     string `json:"slug" example:"t-shirts"`
+	Position int    `json:"position"`
+}
+
+// Validate returns an error if the category cannot be created or updated.
+// A missing slug is generated from the name first.
+func (c *CategoryRequest) Validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return errors.New("name must not be empty")
+	}
+	if c.Slug == "" {
+		c.Slug = Slugify(c.Name)
+	}
+	if !ValidSlug(c.Slug) {
+		return fmt.Errorf("slug must have between 1 and %d lowercase letters, digits and single hyphens", maxSlugLength)
+	}
+	return nil
+}
+
+// ToCategory converts a CategoryRequest to a Category with the given id.
+func (c *CategoryRequest) ToCategory(id int) *Category {
+	return &Category{ID: id, ParentID: c.ParentID, Name: c.Name, Slug: c.Slug, Position: c.Position}
+}
+
+// SetCategoriesRequest is a struct that defines the categories that a product is assigned to.
+type SetCategoriesRequest struct {
+	CategoryIDs []int `json:"category_ids"`
+}
+
+// Slugify returns the slug of s: its letters and digits in lowercase, with every other run of characters
+// replaced by a single hyphen. Characters other than ASCII letters and digits are dropped.
+func Slugify(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(r)
+			continue
+		}
+		hyphen = true
+	}
+	result := b.String()
+	if len(result) > maxSlugLength {
+		result = strings.TrimRight(result[:maxSlugLength], "-")
+	}
+	return result
+}
+
+// UniqueSlug returns slug if used returns false for it, and otherwise the first slug that it returns false for
+// among slug with the suffixes "-2", "-3" and so on. slug is shortened to make room for the suffix if needed.
+func UniqueSlug(slug string, used func(slug string) bool) string {
+	result := slug
+	for n := 2; used(result); n++ {
+		suffix := "-" + strconv.Itoa(n)
+		result = strings.TrimRight(slug[:min(len(slug), maxSlugLength-len(suffix))], "-") + suffix
+	}
+	return result
+}
+
+// ValidSlug returns whether s is a slug, as returned by Slugify.
+func ValidSlug(s string) bool {
+	return s != "" && len(s) <= maxSlugLength && Slugify(s) == s
+}
+
+// CategoryTree returns the subtree of the category with rootID, with the children of each category set in order.
+// The roots of every tree are returned if rootID is zero. Categories with the same parent are ordered by position,
+// and then by their order in categories.
+func CategoryTree(categories []Category, rootID int) []Category {
+	children := make(map[int][]Category)
+	var roots []Category
+	for _, c := range categories {
+		switch {
+		case c.ID == rootID:
+			roots = append(roots, c)
+		case c.ParentID == nil:
+			if rootID == 0 {
+				roots = append(roots, c)
+			}
+		default:
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		}
+	}
+
+	var build func(nodes []Category) []Category
+	build = func(nodes []Category) []Category {
+		sort.SliceStable(nodes, func(i, j int) bool {
+			return nodes[i].Position < nodes[j].Position
+		})
+		for i := range nodes {
+			if kids := children[nodes[i].ID]; len(kids) > 0 {
+				nodes[i].Children = build(kids)
+			}
+		}
+		return nodes
+	}
+	if roots == nil {
+		return []Category{}
+	}
+	return build(roots)
+}
+
+// DescendantIDs returns the ID of the category with id and of every category below it in the tree.
+func DescendantIDs(categories []Category, id int) []int {
+	children := make(map[int][]int)
+	for _, c := range categories {
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c.ID)
+		}
+	}
+	result := []int{id}
+	for i := 0; i < len(result); i++ {
+		result = append(result, children[result[i]]...)
+	}
+	return result
+}
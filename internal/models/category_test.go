@@ -0,0 +1,98 @@
+package models_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+)
+
+// Tests that slugs only have lowercase letters, digits and single hyphens between them.
+func TestSlugify(t *testing.T) {
+	tt := []struct {
+		name     string
+		expected string
+	}{
+		{"T-Shirts", "t-shirts"},
+		{"  Shoes & Boots!  ", "shoes-boots"},
+		{"Café--Crème", "caf-cr-me"},
+		{"2024 Sale", "2024-sale"},
+		{"!!!", ""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			slug := models.Slugify(tc.name)
+			if slug != tc.expected {
+				t.Errorf("Slug: got %q want %q", slug, tc.expected)
+			}
+			if slug != "" && !models.ValidSlug(slug) {
+				t.Errorf("Valid: got false want true")
+			}
+		})
+	}
+}
+
+// Tests that unique slugs are suffixed with the first number that is not used, and are kept short enough to store.
+func TestUniqueSlug(t *testing.T) {
+	used := map[string]bool{"hat": true, "hat-2": true, strings.Repeat("a", 255): true}
+	isUsed := func(slug string) bool { return used[slug] }
+
+	tt := []struct {
+		slug     string
+		expected string
+	}{
+		{"scarf", "scarf"},
+		{"hat", "hat-3"},
+		{strings.Repeat("a", 255), strings.Repeat("a", 253) + "-2"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.slug[:min(len(tc.slug), 10)], func(t *testing.T) {
+			slug := models.UniqueSlug(tc.slug, isUsed)
+			if slug != tc.expected {
+				t.Errorf("Slug: got %q want %q", slug, tc.expected)
+			}
+			if !models.ValidSlug(slug) {
+				t.Errorf("Valid: got false want true")
+			}
+		})
+	}
+}
+
+// Tests that trees are built from categories ordered by position, and that descendants are found at any depth.
+func TestCategoryTree(t *testing.T) {
+	parent := func(id int) *int { return &id }
+	categories := []models.Category{
+		{ID: 1, Name: "Clothing", Position: 1},
+		{ID: 2, Name: "Shoes", Position: 0},
+		{ID: 3, ParentID: parent(1), Name: "T-Shirts", Position: 2},
+		{ID: 4, ParentID: parent(1), Name: "Jackets", Position: 1},
+		{ID: 5, ParentID: parent(4), Name: "Raincoats"},
+	}
+
+	roots := models.CategoryTree(categories, 0)
+	var names []string
+	for _, c := range roots {
+		names = append(names, c.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"Shoes", "Clothing"}) {
+		t.Errorf("Roots: got %v", names)
+	}
+	if len(roots[1].Children) != 2 || roots[1].Children[0].Name != "Jackets" || len(roots[1].Children[0].Children) != 1 {
+		t.Errorf("Children: got %+v", roots[1].Children)
+	}
+
+	subtree := models.CategoryTree(categories, 4)
+	if len(subtree) != 1 || subtree[0].ID != 4 || len(subtree[0].Children) != 1 {
+		t.Errorf("Subtree: got %+v", subtree)
+	}
+	if tree := models.CategoryTree(categories, 6); len(tree) != 0 {
+		t.Errorf("Unknown Subtree: got %+v", tree)
+	}
+
+	if ids := models.DescendantIDs(categories, 1); !reflect.DeepEqual(ids, []int{1, 3, 4, 5}) {
+		t.Errorf("Descendants: got %v", ids)
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// ChangeOperation is the kind of change that was made to a product.
+type ChangeOperation string
+
+const (
+	ChangeCreate  ChangeOperation = "create"
+	ChangeUpdate  ChangeOperation = "update"
+	ChangeDelete  ChangeOperation = "delete"
+	ChangeRestore ChangeOperation = "restore"
+	ChangePurge   ChangeOperation = "purge"
+	// ChangeReserve and ChangeRelease are changes to the stock of a product made by reservations.
+	ChangeReserve ChangeOperation = "reserve"
+	ChangeRelease ChangeOperation = "release"
+)
+
+// ProductChange is a struct that defines a change that was made to a product, as recorded in its history.
+type ProductChange struct {
+	ID        int             `json:"id"`
+	ProductID int             `json:"product_id"`
+	Operation ChangeOperation `json:"operation"`
+	// Before is the product before the change. It is nil if the product was created.
+	Before *Product `json:"before"`
+	// After is the product after the change. It is nil if the product was purged.
+	After     *Product  `json:"after"`
+	ChangedAt time.Time `json:"changed_at"`
+	// Actor is who made the change.
+	Actor string `json:"actor"`
+	// RequestID is the ID of the request that made the change.
+	RequestID string `json:"request_id"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Image is a struct that defines the metadata of an image of a product. Its content is kept in a blob store.
+type Image struct {
+	ID        int `json:"id"`
+	ProductID int `json:"product_id"`
+	// Key identifies the content of the image in the blob store.
+	Key         string `json:"-"`
+	ContentType string `json:"content_type" example:"image/png"`
+	// Size is the length of the content in bytes.
+	Size int64 `json:"size"`
+	// Checksum is the hex-encoded SHA-256 hash of the content.
+	Checksum  string    `json:"checksum"`
+	CreatedAt time.Time `json:"created_at"`
+}
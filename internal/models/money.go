@@ -0,0 +1,229 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency that product prices are stored in.
+const DefaultCurrency = "AUD"
+
+// moneyScale is the number of minor units in a major unit. Every supported currency has two decimal places.
+const moneyScale = 100
+
+// Money is an exact amount of a currency.
+// Its amount is encoded in JSON as a decimal string (eg. "1.99"), and the structs that hold it encode its currency
+// in a sibling "currency" field. It is stored in DECIMAL columns of the DefaultCurrency.
+//
+// Amounts are limited to the range of an int64 of minor units, and arithmetic that overflows it returns ErrOverflow.
+type Money struct {
+	// Amount is the number of minor units (eg. cents).
+	Amount int64
+	// Currency is the ISO 4217 code of the currency.
+	Currency string
+}
+
+// ErrOverflow is returned by arithmetic on Money whose result is out of the range of its amounts.
+var ErrOverflow = errors.New("amount is out of range")
+
+// NewMoney returns the Money of the given number of minor units of currency.
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney parses the decimal amount s (eg. "1.99") of currency.
+// An error is returned if s has more decimal places than the currency.
+func ParseMoney(s string, currency string) (Money, error) {
+	if !isDecimal(s) {
+		return Money{}, fmt.Errorf("invalid amount %q", s)
+	}
+	// Decimals are always valid rationals.
+	r, _ := new(big.Rat).SetString(s)
+	r.Mul(r, big.NewRat(moneyScale, 1))
+	if !r.IsInt() || !r.Num().IsInt64() {
+		return Money{}, fmt.Errorf("invalid amount %q: it must have at most two decimal places", s)
+	}
+	return Money{Amount: r.Num().Int64(), Currency: currency}, nil
+}
+
+// isDecimal returns whether s is a plain decimal number, optionally negative (eg. "-1.99", "2", or "0.5").
+func isDecimal(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	whole, fraction, hasPoint := strings.Cut(s, ".")
+	if whole == "" || (hasPoint && fraction == "") {
+		return false
+	}
+	for _, c := range whole + fraction {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the decimal amount of m (eg. "1.99"), without the currency.
+func (m Money) String() string {
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/moneyScale, amount%moneyScale)
+}
+
+// IsNegative returns whether m is less than zero.
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Cmp returns -1, 0 or 1 depending on whether m is less than, equal to, or greater than o.
+// The currencies are not compared.
+func (m Money) Cmp(o Money) int {
+	switch {
+	case m.Amount < o.Amount:
+		return -1
+	case m.Amount > o.Amount:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Add returns the sum of m and o, which must have the same currency.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, &CurrencyMismatchError{m.Currency, o.Currency}
+	}
+	return m.withAmount(new(big.Int).Add(big.NewInt(m.Amount), big.NewInt(o.Amount)))
+}
+
+// Sub returns the difference of m and o, which must have the same currency.
+func (m Money) Sub(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, &CurrencyMismatchError{m.Currency, o.Currency}
+	}
+	return m.withAmount(new(big.Int).Sub(big.NewInt(m.Amount), big.NewInt(o.Amount)))
+}
+
+// Mul returns m multiplied by n (eg. the total of n items that cost m).
+func (m Money) Mul(n int64) (Money, error) {
+	return m.withAmount(new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(n)))
+}
+
+// withAmount returns the given number of minor units of the currency of m, or ErrOverflow if it is out of range.
+func (m Money) withAmount(amount *big.Int) (Money, error) {
+	if !amount.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: amount.Int64(), Currency: m.Currency}, nil
+}
+
+// MulRat returns m multiplied by r in currency, rounded to the nearest minor unit with ties to even (banker's rounding).
+// It is used to convert between currencies, where r is the exchange rate.
+func (m Money) MulRat(r *big.Rat, currency string) Money {
+	product := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), r)
+	return Money{Amount: roundHalfEven(product), Currency: currency}
+}
+
+// roundHalfEven returns r rounded to the nearest integer, with ties rounded to the even integer.
+func roundHalfEven(r *big.Rat) int64 {
+	quo, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	// The remainder has the sign of the numerator, and is compared to half of the (positive) denominator.
+	twice := new(big.Int).Abs(rem)
+	twice.Lsh(twice, 1)
+	switch twice.Cmp(r.Denom()) {
+	case 1:
+		quo.Add(quo, big.NewInt(int64(rem.Sign())))
+	case 0:
+		if quo.Bit(0) == 1 {
+			quo.Add(quo, big.NewInt(int64(rem.Sign())))
+		}
+	}
+	return quo.Int64()
+}
+
+// MarshalJSON encodes the amount of m as a decimal string, without its currency.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON decodes the amount of m from a decimal string or number. The currency of m is kept.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+	amount, err := unmarshalAmount(data)
+	if err != nil {
+		return err
+	}
+	parsed, err := ParseMoney(amount, m.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// unmarshalAmount returns the decimal text of the JSON string or number in data.
+// Numbers are not decoded as floats so that they are exact.
+func unmarshalAmount(data []byte) (string, error) {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		err := json.Unmarshal(data, &s)
+		return s, err
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return "", fmt.Errorf("invalid amount %s", data)
+	}
+	return n.String(), nil
+}
+
+// Scan implements the sql.Scanner interface, scanning a DECIMAL column into the amount of m.
+// The currency of m is kept, or set to the DefaultCurrency if it is empty.
+// Drivers that return DECIMAL columns as floats (eg. SQLite) are rounded to the nearest minor unit.
+func (m *Money) Scan(src any) error {
+	var r *big.Rat
+	switch v := src.(type) {
+	case []byte:
+		r, _ = new(big.Rat).SetString(string(v))
+	case string:
+		r, _ = new(big.Rat).SetString(v)
+	case int64:
+		r = new(big.Rat).SetInt64(v)
+	case float64:
+		r, _ = new(big.Rat).SetString(strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	if r == nil {
+		return fmt.Errorf("cannot scan %T (%v) into Money", src, src)
+	}
+
+	m.Amount = roundHalfEven(r.Mul(r, big.NewRat(moneyScale, 1)))
+	if m.Currency == "" {
+		m.Currency = DefaultCurrency
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface, storing the amount of m as a decimal string.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// CurrencyMismatchError is returned when amounts of different currencies are combined.
+type CurrencyMismatchError struct {
+	// A and B are the currencies of the amounts.
+	A, B string
+}
+
+func (e *CurrencyMismatchError) Error() string {
+	return fmt.Sprintf("cannot combine amounts of %s and %s", e.A, e.B)
+}
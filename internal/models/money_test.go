@@ -0,0 +1,166 @@
+package models_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+)
+
+// Tests that amounts are parsed exactly, and formatted as they were parsed.
+func TestParseMoney(t *testing.T) {
+	tt := []struct {
+		amount   string
+		expected int64
+		valid    bool
+		formats  string
+	}{
+		{"1.99", 199, true, "1.99"},
+		{"2", 200, true, "2.00"},
+		{"0.5", 50, true, "0.50"},
+		{"-10.05", -1005, true, "-10.05"},
+		{"1.999", 0, false, ""},
+		{"1e2", 0, false, ""},
+		{"1/2", 0, false, ""},
+		{"1.", 0, false, ""},
+		{"cheap", 0, false, ""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.amount, func(t *testing.T) {
+			m, err := models.ParseMoney(tc.amount, "AUD")
+			if (err == nil) != tc.valid {
+				t.Fatalf("Error: got %v want valid %v", err, tc.valid)
+			}
+			if !tc.valid {
+				return
+			}
+			if m.Amount != tc.expected {
+				t.Errorf("Amount: got %d want %d", m.Amount, tc.expected)
+			}
+			if m.String() != tc.formats {
+				t.Errorf("String: got %s want %s", m.String(), tc.formats)
+			}
+		})
+	}
+}
+
+// Tests that multiplying by a rate rounds half to even.
+func TestMoney_MulRat(t *testing.T) {
+	tt := []struct {
+		name     string
+		amount   int64
+		rate     string
+		expected int64
+	}{
+		{"exact", 200, "1.5", 300},
+		{"round down", 101, "1.2", 121},
+		{"round up", 107, "1.7", 182},
+		{"tie to even down", 105, "0.5", 52},
+		{"tie to even up", 107, "0.5", 54},
+		{"negative tie", -105, "0.5", -52},
+		{"negative round", -107, "1.7", -182},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rate, _ := new(big.Rat).SetString(tc.rate)
+			got := models.NewMoney(tc.amount, "AUD").MulRat(rate, "USD")
+			if want := models.NewMoney(tc.expected, "USD"); got != want {
+				t.Errorf("got %v %s want %v %s", got, got.Currency, want, want.Currency)
+			}
+		})
+	}
+}
+
+// Tests that the amount of Money is encoded as a decimal string, and decoded from both strings and numbers.
+func TestMoney_JSON(t *testing.T) {
+	b, err := json.Marshal(models.NewMoney(1999, "AUD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `"19.99"` {
+		t.Errorf("Marshal: got %s", b)
+	}
+
+	for _, data := range []string{`"19.99"`, `19.99`} {
+		// The currency is not encoded, so it is kept.
+		m := models.NewMoney(0, "AUD")
+		if err = json.Unmarshal([]byte(data), &m); err != nil {
+			t.Fatalf("Unmarshal %s: %v", data, err)
+		}
+		if m != models.NewMoney(1999, "AUD") {
+			t.Errorf("Unmarshal %s: got %v %s", data, m, m.Currency)
+		}
+	}
+
+	var m models.Money
+	if err = json.Unmarshal([]byte(`19.999`), &m); err == nil {
+		t.Error("Unmarshal: expected an error for too many decimal places")
+	}
+}
+
+// Tests that the currency of a product's price is encoded beside it, and decoded into it.
+func TestProduct_JSON(t *testing.T) {
+	price := models.NewMoney(2199, "USD")
+	product := models.Product{ID: 1, Name: "Shirt", Price: models.NewMoney(1999, "USD")}
+	product.SetVariants([]models.Variant{{ID: 1, ProductID: 1, Price: &price}, {ID: 2, ProductID: 1}})
+
+	b, err := json.Marshal(product)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err = json.Unmarshal(b, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["price"] != "19.99" || fields["currency"] != "USD" {
+		t.Errorf("Marshal: got price %v and currency %v", fields["price"], fields["currency"])
+	}
+
+	var decoded models.Product
+	if err = json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Price != product.Price || *decoded.Variants[0].Price != price || decoded.Variants[1].Price != nil {
+		t.Errorf("Unmarshal: got %+v", decoded)
+	}
+}
+
+// Tests that arithmetic that overflows the range of amounts returns ErrOverflow.
+func TestMoney_Overflow(t *testing.T) {
+	large := models.NewMoney(math.MaxInt64/2+1, "AUD")
+	if _, err := large.Mul(2); !errors.Is(err, models.ErrOverflow) {
+		t.Errorf("Mul: got %v", err)
+	}
+	if _, err := large.Add(large); !errors.Is(err, models.ErrOverflow) {
+		t.Errorf("Add: got %v", err)
+	}
+	if _, err := models.NewMoney(math.MinInt64, "AUD").Sub(models.NewMoney(1, "AUD")); !errors.Is(err, models.ErrOverflow) {
+		t.Errorf("Sub: got %v", err)
+	}
+	total, err := models.NewMoney(199, "AUD").Mul(3)
+	if err != nil || total != models.NewMoney(597, "AUD") {
+		t.Errorf("Mul: got %v %v", total, err)
+	}
+}
+
+// Tests that Money is scanned from the values drivers return for DECIMAL columns.
+func TestMoney_Scan(t *testing.T) {
+	for _, src := range []any{[]byte("19.99"), "19.99", 19.99, int64(20)} {
+		var m models.Money
+		if err := m.Scan(src); err != nil {
+			t.Fatal(err)
+		}
+		want := int64(1999)
+		if src == int64(20) {
+			want = 2000
+		}
+		if m != models.NewMoney(want, models.DefaultCurrency) {
+			t.Errorf("Scan %v: got %v %s", src, m, m.Currency)
+		}
+	}
+}
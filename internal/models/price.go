@@ -0,0 +1,82 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// maxRateDecimals is the number of decimal places that exchange rates are stored with.
+const maxRateDecimals = 8
+
+// ExchangeRate is a struct that defines the value of a currency relative to the DefaultCurrency.
+type ExchangeRate struct {
+	// Currency is the ISO 4217 code of the currency.
+	Currency string `json:"currency" example:"USD"`
+	// Rate is the amount of Currency that one unit of the DefaultCurrency is worth, as a decimal string.
+	Rate string `json:"rate" example:"0.65"`
+}
+
+// SetExchangeRateRequest is a struct that defines the fields required to set an exchange rate.
+type SetExchangeRateRequest struct {
+	Rate string `json:"rate" example:"0.65"`
+}
+
+// ParseExchangeRate returns the ExchangeRate of currency, with the decimal rate in its canonical form.
+// The rate must be positive, with at most 8 decimal places, and the currency must not be the DefaultCurrency.
+func ParseExchangeRate(currency, rate string) (ExchangeRate, error) {
+	if !ValidCurrency(currency) {
+		return ExchangeRate{}, fmt.Errorf("invalid currency %q", currency)
+	}
+	if currency == DefaultCurrency {
+		return ExchangeRate{}, fmt.Errorf("the exchange rate of %s is always 1", DefaultCurrency)
+	}
+	r, err := parseRate(rate)
+	if err != nil {
+		return ExchangeRate{}, err
+	}
+	if r.Sign() <= 0 {
+		return ExchangeRate{}, errors.New("rate must be positive")
+	}
+	return ExchangeRate{Currency: currency, Rate: formatRate(r)}, nil
+}
+
+// Rat returns the rate of e. It must have been created with ParseExchangeRate.
+func (e ExchangeRate) Rat() *big.Rat {
+	r, _ := new(big.Rat).SetString(e.Rate)
+	return r
+}
+
+// parseRate parses a decimal rate with at most maxRateDecimals decimal places.
+func parseRate(s string) (*big.Rat, error) {
+	if !isDecimal(s) {
+		return nil, fmt.Errorf("invalid rate %q", s)
+	}
+	if _, fraction, _ := strings.Cut(s, "."); len(fraction) > maxRateDecimals {
+		return nil, fmt.Errorf("invalid rate %q: it must have at most %d decimal places", s, maxRateDecimals)
+	}
+	// Decimals are always valid rationals.
+	r, _ := new(big.Rat).SetString(s)
+	return r, nil
+}
+
+// formatRate returns the shortest decimal form of the rate r, which must have at most maxRateDecimals decimal places.
+func formatRate(r *big.Rat) string {
+	s := r.FloatString(maxRateDecimals)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// ValidCurrency returns whether code has the form of an ISO 4217 currency code (three uppercase letters).
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -1,25 +1,134 @@
 package models
 
-import "database/sql"
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
 
 // Product is a struct that defines the fields of a product.
 type Product struct {
-	ID            int            `json:"id"`
-	Name          string         `json:"name"`
-	Description   sql.NullString `json:"description"`
-	Price         float64        `json:"price"`
-	StockQuantity int            `json:"stock_quantity"`
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// SKU is the stock keeping unit of the product, which is unique among every product. It is empty if it has none.
+	SKU string `json:"sku,omitempty" example:"TSHIRT"`
+	// Slug identifies the product in URLs, and is unique among every product.
+	Slug        string         `json:"slug" example:"t-shirt"`
+	Description sql.NullString `json:"description"`
+	// Price is encoded as a decimal string, and its currency as the sibling "currency" field (eg. "AUD").
+	Price         Money `json:"price" swaggertype:"string" example:"19.99"`
+	StockQuantity int   `json:"stock_quantity"`
+	// Version is incremented every time the product is updated. It is used for optimistic concurrency control.
+	Version int `json:"version"`
+	// DeletedAt is when the product was moved to the trash. It is nil unless the product is in the trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Variants are the variants of the product, ordered by ID. They are not part of the history of the product.
+	Variants []Variant `json:"variants,omitempty"`
+	// TotalStockQuantity is the stock of the product plus the stock of its variants. It is only set if it has variants.
+	TotalStockQuantity int `json:"total_stock_quantity,omitempty"`
+	// CategoryIDs are the IDs of the categories that the product is assigned to, in ascending order.
+	// They are not part of the history of the product.
+	CategoryIDs []int `json:"category_ids,omitempty"`
+	// Tags are the tags of the product, in ascending order. They are not part of the history of the product.
+	Tags []string `json:"tags,omitempty"`
+	// Attributes are the values of the attributes of the product by name. Each value is a string, number or boolean.
+	// They are not part of the history of the product.
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// productJSON has the fields of a Product without its JSON methods, so that they can encode the rest of its fields.
+type productJSON Product
+
+// MarshalJSON encodes p with the currency of its price in the "currency" field.
+func (p Product) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		productJSON
+		Currency string `json:"currency"`
+	}{productJSON(p), p.Price.Currency})
+}
+
+// UnmarshalJSON decodes p from its encoding by MarshalJSON.
+func (p *Product) UnmarshalJSON(data []byte) error {
+	v := struct {
+		*productJSON
+		Currency string `json:"currency"`
+	}{productJSON: (*productJSON)(p)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	p.Price.Currency = v.Currency
+	return nil
+}
+
+// SetVariants sets the variants of p, and totals their stock.
+func (p *Product) SetVariants(variants []Variant) {
+	p.Variants = nil
+	p.TotalStockQuantity = 0
+	if len(variants) == 0 {
+		return
+	}
+	p.Variants = variants
+	p.TotalStockQuantity = p.StockQuantity
+	for _, v := range variants {
+		p.TotalStockQuantity += v.StockQuantity
+	}
+}
+
+// ProductSlug returns the slug that is generated for a product with name, before any suffix is added
+// to make it unique. Products whose names have no letters or digits are given the slug "product".
+func ProductSlug(name string) string {
+	if slug := Slugify(name); slug != "" {
+		return slug
+	}
+	return "product"
 }
 
 // CreateProductRequest is a struct that defines the fields required to create a product.
 type CreateProductRequest struct {
-	Name          string  `json:"name"`
-	Description   string  `json:"description"`
-	Price         float64 `json:"price"`
-	StockQuantity int     `json:"stock_quantity"`
+	Name string `json:"name"`
+	// SKU is optional, and must be unique among every product.
+	SKU string `json:"sku" example:"TSHIRT"`
+	// Slug must be unique among every product. It is generated from the name if it is omitted when creating
+	// a product, and the current slug is kept if it is omitted when updating one.
+	Slug        string `json:"slug" example:"t-shirt"`
+	Description string `json:"description"`
+	// Price is encoded as a decimal string.
+	Price Money `json:"price" swaggertype:"string" example:"19.99"`
+	// Currency is the currency of the price, which must be the DefaultCurrency. It may be omitted.
+	Currency      string `json:"currency,omitempty" example:"AUD"`
+	StockQuantity int    `json:"stock_quantity"`
+}
+
+// Validate returns an error if the product cannot be created.
+// The price must not be negative, and must be in the DefaultCurrency, which is the only currency that is stored.
+func (c *CreateProductRequest) Validate() error {
+	if len(c.SKU) > maxSKULength {
+		return fmt.Errorf("sku must have at most %d characters", maxSKULength)
+	}
+	if c.Slug != "" && !ValidSlug(c.Slug) {
+		return fmt.Errorf("slug must have between 1 and %d lowercase letters, digits and single hyphens", maxSlugLength)
+	}
+	if c.Price.IsNegative() {
+		return errors.New("price must not be negative")
+	}
+	return checkStoredCurrency(c.Currency, c.Price.Currency)
+}
+
+// checkStoredCurrency returns an error unless each of the currencies of a price is empty or the DefaultCurrency,
+// which is the only currency that is stored.
+func checkStoredCurrency(currencies ...string) error {
+	for _, currency := range currencies {
+		if currency != "" && currency != DefaultCurrency {
+			return fmt.Errorf("price must be in %s", DefaultCurrency)
+		}
+	}
+	return nil
 }
 
 // ToProduct converts a CreateProductRequest to a Product with the given id.
+// The product has the version of a newly created product.
 func (c *CreateProductRequest) ToProduct(id int) *Product {
 	var isValid bool
 	if c.Description == "" {
@@ -30,8 +139,35 @@ func (c *CreateProductRequest) ToProduct(id int) *Product {
 	return &Product{
 		ID:            id,
 		Name:          c.Name,
+		SKU:           c.SKU,
+		Slug:          c.Slug,
 		Description:   sql.NullString{String: c.Description, Valid: isValid},
-		Price:         c.Price,
+		Price:         NewMoney(c.Price.Amount, DefaultCurrency),
 		StockQuantity: c.StockQuantity,
+		Version:       1,
+	}
+}
+
+// ToCreateProductRequest converts a Product to the CreateProductRequest that would update a product to it.
+// A NULL description becomes an empty one, which ToProduct converts back to NULL.
+func (p *Product) ToCreateProductRequest() *CreateProductRequest {
+	return &CreateProductRequest{
+		Name:          p.Name,
+		SKU:           p.SKU,
+		Slug:          p.Slug,
+		Description:   p.Description.String,
+		Price:         p.Price,
+		Currency:      p.Price.Currency,
+		StockQuantity: p.StockQuantity,
 	}
 }
+
+// SearchResult is a struct that defines a product that matched a search, and how well it matched.
+type SearchResult struct {
+	Product Product `json:"product"`
+	// Score is the relevance of the product to the search. Higher scores are more relevant.
+	Score float64 `json:"score"`
+	// Snippet is an excerpt of the product's description (or name) with the matched terms wrapped in <mark> tags.
+	// The rest of the excerpt is HTML-escaped.
+	Snippet string `json:"snippet"`
+}
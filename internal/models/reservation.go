@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// ReservationStatus is the state of a stock reservation.
+type ReservationStatus string
+
+const (
+	// ReservationPending is a reservation whose stock is held until it is committed, released or expires.
+	ReservationPending ReservationStatus = "pending"
+	// ReservationCommitted is a reservation whose stock has been sold. The stock is not returned to the product.
+	ReservationCommitted ReservationStatus = "committed"
+	// ReservationReleased is a reservation whose stock has been returned to the product.
+	ReservationReleased ReservationStatus = "released"
+	// ReservationExpired is a reservation whose stock was returned to the product because it was not committed in time.
+	ReservationExpired ReservationStatus = "expired"
+)
+
+// Reservation is a struct that defines a quantity of the stock of a product that is held for a purchase.
+type Reservation struct {
+	ID        int               `json:"id"`
+	ProductID int               `json:"product_id"`
+	Quantity  int               `json:"quantity"`
+	Status    ReservationStatus `json:"status"`
+	CreatedAt time.Time         `json:"created_at"`
+	// ExpiresAt is when the stock is returned to the product if the reservation has not been committed.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ReserveStockRequest is a struct that defines the fields required to reserve stock of a product.
+type ReserveStockRequest struct {
+	Quantity int `json:"quantity"`
+	// TTLSeconds is how long the reservation is held for. Zero uses the default.
+	TTLSeconds int `json:"ttl_seconds"`
+}
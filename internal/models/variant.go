@@ -0,0 +1,117 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// maxSKULength is the length of the longest SKU that can be stored.
+const maxSKULength = 64
+
+// Variant is a struct that defines the fields of a variant of a product (eg. a size or colour of a t-shirt).
+type Variant struct {
+	ID        int `json:"id"`
+	ProductID int `json:"product_id"`
+	// SKU is the stock keeping unit of the variant, which is unique among every variant.
+	SKU string `json:"sku" example:"TSHIRT-RED-M"`
+	// Options are the values that distinguish the variant from the other variants of the product (eg. size=M).
+	Options map[string]string `json:"options"`
+	// Price overrides the price of the product if it is not nil. It is encoded as a decimal string,
+	// and its currency as the sibling "currency" field.
+	Price         *Money `json:"price,omitempty" swaggertype:"string" example:"21.99"`
+	StockQuantity int    `json:"stock_quantity"`
+}
+
+// variantJSON has the fields of a Variant without its JSON methods, so that they can encode the rest of its fields.
+type variantJSON Variant
+
+// MarshalJSON encodes v with the currency of its price (if it has one) in the "currency" field.
+func (v Variant) MarshalJSON() ([]byte, error) {
+	var currency string
+	if v.Price != nil {
+		currency = v.Price.Currency
+	}
+	return json.Marshal(struct {
+		variantJSON
+		Currency string `json:"currency,omitempty"`
+	}{variantJSON(v), currency})
+}
+
+// UnmarshalJSON decodes v from its encoding by MarshalJSON.
+func (v *Variant) UnmarshalJSON(data []byte) error {
+	w := struct {
+		*variantJSON
+		Currency string `json:"currency"`
+	}{variantJSON: (*variantJSON)(v)}
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	if v.Price != nil {
+		v.Price.Currency = w.Currency
+	}
+	return nil
+}
+
+// VariantRequest is a struct that defines the fields required to create or update a variant.
+type VariantRequest struct {
+	SKU     string            `json:"sku" example:"TSHIRT-RED-M"`
+	Options map[string]string `json:"options"`
+	// Price is encoded as a decimal string. The variant has the price of the product if it is omitted.
+	Price *Money `json:"price,omitempty" swaggertype:"string" example:"21.99"`
+	// Currency is the currency of the price, which must be the DefaultCurrency. It may be omitted.
+	Currency      string `json:"currency,omitempty" example:"AUD"`
+	StockQuantity int    `json:"stock_quantity"`
+}
+
+// Validate returns an error if the variant cannot be created or updated.
+func (v *VariantRequest) Validate() error {
+	if v.SKU == "" || len(v.SKU) > maxSKULength {
+		return fmt.Errorf("sku must have between 1 and %d characters", maxSKULength)
+	}
+	if v.StockQuantity < 0 {
+		return errors.New("stock_quantity must not be negative")
+	}
+	if v.Price != nil {
+		if v.Price.IsNegative() {
+			return errors.New("price must not be negative")
+		}
+		if err := checkStoredCurrency(v.Currency, v.Price.Currency); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ToVariant converts a VariantRequest to a Variant of the product with productID, with the given id.
+func (v *VariantRequest) ToVariant(productID, id int) *Variant {
+	result := &Variant{
+		ID:            id,
+		ProductID:     productID,
+		SKU:           v.SKU,
+		Options:       make(map[string]string, len(v.Options)),
+		StockQuantity: v.StockQuantity,
+	}
+	for name, value := range v.Options {
+		result.Options[name] = value
+	}
+	if v.Price != nil {
+		price := NewMoney(v.Price.Amount, DefaultCurrency)
+		result.Price = &price
+	}
+	return result
+}
+
+// Copy returns a copy of v that shares none of its options or price.
+func (v *Variant) Copy() Variant {
+	result := *v
+	result.Options = make(map[string]string, len(v.Options))
+	for name, value := range v.Options {
+		result.Options[name] = value
+	}
+	if v.Price != nil {
+		price := *v.Price
+		result.Price = &price
+	}
+	return result
+}
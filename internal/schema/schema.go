@@ -0,0 +1,263 @@
+// Package schema applies and reverts versioned migrations of the database schema,
+// tracking which have been applied in the schema_migrations table.
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migration is a struct that defines a single version of the schema.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a struct that defines whether a migration has been applied.
+type MigrationStatus struct {
+	Migration
+	// AppliedAt is when the migration was applied. It is nil if the migration is pending.
+	AppliedAt *time.Time
+}
+
+// Migrator applies and reverts the migrations of a database.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// migrationFile matches the name of a migration file, capturing its version, name and direction.
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`) //nolint:gochecknoglobals // compiled once
+
+// statementSeparator separates the statements of a migration.
+var statementSeparator = regexp.MustCompile(`;\s*(\n|$)`) //nolint:gochecknoglobals // compiled once
+
+// NewMigrator returns a Migrator for db using the migrations in source (see the migrations package for their format).
+// An error is returned if a migration is missing its up or down file, or two migrations have the same version.
+func NewMigrator(db *sql.DB, source fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(match[1])
+		if version < 1 {
+			return nil, fmt.Errorf("Migration %q must have a positive version", entry.Name())
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("Migrations %q and %q have the same version", m.Name, match[2])
+		}
+
+		var b []byte
+		b, err = fs.ReadFile(source, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if match[3] == "up" {
+			m.Up = string(b)
+		} else {
+			m.Down = string(b)
+		}
+	}
+
+	migrator := &Migrator{db: db}
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("Migration %d_%s must have an up and a down file", m.Version, m.Name)
+		}
+		migrator.migrations = append(migrator.migrations, *m)
+	}
+	sort.Slice(migrator.migrations, func(i, j int) bool {
+		return migrator.migrations[i].Version < migrator.migrations[j].Version
+	})
+	return migrator, nil
+}
+
+// Latest returns the version of the last migration, or 0 if there are none.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Current returns the version of the last applied migration, or 0 if none have been applied.
+func (m *Migrator) Current(ctx context.Context) (int, error) {
+	if err := m.createTable(ctx); err != nil {
+		return 0, err
+	}
+	var version sql.NullInt64
+	err := m.db.QueryRowContext(ctx, "SELECT MAX(version) FROM schema_migrations").Scan(&version)
+	return int(version.Int64), err
+}
+
+// CheckCurrent returns an error if the schema is not at the latest version, as there are pending migrations.
+func (m *Migrator) CheckCurrent(ctx context.Context) error {
+	current, err := m.Current(ctx)
+	if err != nil {
+		return err
+	}
+	if current != m.Latest() {
+		return fmt.Errorf("The schema is at version %d but the latest is %d; run \"migrate up\"", current, m.Latest())
+	}
+	return nil
+}
+
+// Status returns the status of every migration, in order.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.createTable(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := m.db.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		result[i].Migration = migration
+		if appliedAt, ok := applied[migration.Version]; ok {
+			result[i].AppliedAt = &appliedAt
+		}
+	}
+	return result, nil
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.Latest())
+}
+
+// Down reverts the last applied migration. It does nothing if no migrations have been applied.
+func (m *Migrator) Down(ctx context.Context) error {
+	current, err := m.Current(ctx)
+	if err != nil || current == 0 {
+		return err
+	}
+	previous := 0
+	for _, migration := range m.migrations {
+		if migration.Version < current {
+			previous = migration.Version
+		}
+	}
+	return m.To(ctx, previous)
+}
+
+// To applies or reverts migrations until the schema is at version. Version 0 reverts every migration.
+func (m *Migrator) To(ctx context.Context, version int) error {
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("Migration %d does not exist", version)
+	}
+	current, err := m.Current(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range m.migrations {
+		if migration.Version > current && migration.Version <= version {
+			if err = m.apply(ctx, migration, true); err != nil {
+				return err
+			}
+		}
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version <= current && migration.Version > version {
+			if err = m.apply(ctx, migration, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// index returns the index of the migration with version, or -1 if there is none.
+func (m *Migrator) index(version int) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// createTable creates the schema_migrations table if it does not exist.
+func (m *Migrator) createTable(ctx context.Context) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`
+	_, err := m.db.ExecContext(ctx, query)
+	return err
+}
+
+// apply runs the up (or down) statements of migration and records it as applied (or not).
+// They are run in a transaction, but note that MariaDB implicitly commits schema changes.
+func (m *Migrator) apply(ctx context.Context, migration Migration, up bool) error {
+	script := migration.Down
+	if up {
+		script = migration.Up
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // the error is irrelevant once committed
+
+	for _, stmt := range statementSeparator.Split(script, -1) {
+		if strings.TrimSpace(stmt) == "" {
+			continue
+		}
+		if _, err = tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("Error in migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+			migration.Version, migration.Name, time.Now().UTC())
+	} else {
+		_, err = tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", migration.Version)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
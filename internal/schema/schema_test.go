@@ -0,0 +1,143 @@
+package schema_test
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/schema"
+
+	// sqlite is the database/sql driver that the migrations are tested against.
+	_ "modernc.org/sqlite"
+)
+
+// Check that got and want are equal, and if not, log an error to t.
+// msg should be a short description of what is being tested (eg. "Version").
+func checkEqual(t *testing.T, got, want interface{}, msg string) {
+	t.Helper()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: got %v want %v", msg, got, want)
+	}
+}
+
+// testMigrations are two migrations, the second of which has multiple statements.
+var testMigrations = fstest.MapFS{ //nolint:gochecknoglobals // read-only test data
+	"0001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id INTEGER PRIMARY KEY);")},
+	"0001_create_a.down.sql": {Data: []byte("DROP TABLE a;")},
+	"0002_create_b.up.sql":   {Data: []byte("CREATE TABLE b (id INTEGER PRIMARY KEY);\nCREATE TABLE c (id INTEGER);\n")},
+	"0002_create_b.down.sql": {Data: []byte("DROP TABLE c;\nDROP TABLE b;\n")},
+	"README.md":              {Data: []byte("Not a migration.")},
+}
+
+// Returns the names of the tables in db, excluding schema_migrations.
+func tables(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name != 'schema_migrations' ORDER BY name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// Tests migrating up, down and to specific versions.
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	migrator, err := schema.NewMigrator(db, testMigrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, migrator.Latest(), 2, "Latest")
+
+	tt := []struct {
+		name            string
+		migrate         func() error
+		expectedVersion int
+		expectedTables  []string
+	}{
+		{"up", func() error { return migrator.Up(ctx) }, 2, []string{"a", "b", "c"}},
+		{"up again", func() error { return migrator.Up(ctx) }, 2, []string{"a", "b", "c"}},
+		{"down", func() error { return migrator.Down(ctx) }, 1, []string{"a"}},
+		{"to 0", func() error { return migrator.To(ctx, 0) }, 0, nil},
+		{"down at 0", func() error { return migrator.Down(ctx) }, 0, nil},
+		{"to 2", func() error { return migrator.To(ctx, 2) }, 2, []string{"a", "b", "c"}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err = tc.migrate(); err != nil {
+				t.Fatal(err)
+			}
+
+			var version int
+			version, err = migrator.Current(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, version, tc.expectedVersion, "Version")
+			checkEqual(t, migrator.CheckCurrent(ctx) == nil, tc.expectedVersion == migrator.Latest(), "Current")
+			checkEqual(t, tables(t, db), tc.expectedTables, "Tables")
+		})
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(statuses), 2, "Statuses Length")
+	for _, status := range statuses {
+		checkEqual(t, status.AppliedAt != nil, true, "Applied")
+	}
+
+	if err = migrator.To(ctx, 3); err == nil {
+		t.Error("Migrating to a missing version did not fail")
+	}
+}
+
+// Tests that invalid sets of migrations are rejected.
+func TestNewMigrator_Invalid(t *testing.T) {
+	tt := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{"missing down", fstest.MapFS{"0001_a.up.sql": {Data: []byte("SELECT 1;")}}},
+		{"duplicate version", fstest.MapFS{
+			"0001_a.up.sql":   {Data: []byte("SELECT 1;")},
+			"0001_a.down.sql": {Data: []byte("SELECT 1;")},
+			"0001_b.up.sql":   {Data: []byte("SELECT 1;")},
+			"0001_b.down.sql": {Data: []byte("SELECT 1;")},
+		}},
+		{"zero version", fstest.MapFS{
+			"0000_a.up.sql":   {Data: []byte("SELECT 1;")},
+			"0000_a.down.sql": {Data: []byte("SELECT 1;")},
+		}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := schema.NewMigrator(nil, tc.files); err == nil {
+				t.Error("Invalid migrations were not rejected")
+			}
+		})
+	}
+}
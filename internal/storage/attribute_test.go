@@ -0,0 +1,83 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+)
+
+// Tests that the tags and attributes of products are set, returned with them, and filtered by, by every storage engine.
+func TestStorage_TagsAndAttributes(t *testing.T) {
+	for _, engine := range testEngines() {
+		t.Run(engine.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := engine.storage(t)
+			for i := 0; i < 3; i++ {
+				_, err := s.CreateProduct(ctx, &models.CreateProductRequest{Name: "Test Product", Price: models.NewMoney(1000, "")})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := s.SetProductTags(ctx, 1, []string{"organic", "clearance", "organic"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SetProductTags(ctx, 2, []string{"organic"}); err != nil {
+				t.Fatal(err)
+			}
+			attributes := map[string]any{"material": "cotton", "weight_g": 250, "recycled": true}
+			if err := s.SetProductAttributes(ctx, 1, attributes); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SetProductAttributes(ctx, 2, map[string]any{"material": "wool", "weight_g": 0.5}); err != nil {
+				t.Fatal(err)
+			}
+			err := s.SetProductTags(ctx, 9, []string{"organic"})
+			checkEqual(t, errors.As(err, new(*storage.NotFoundError)), true, "Set Tags Of Unknown Product")
+			err = s.SetProductAttributes(ctx, 1, map[string]any{"dimensions": []any{1, 2}})
+			checkEqual(t, err != nil, true, "Set Invalid Attribute")
+
+			product, err := s.GetProduct(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, product.Tags, []string{"clearance", "organic"}, "Tags")
+			checkEqual(t, product.Attributes, map[string]any{"material": "cotton", "weight_g": 250.0, "recycled": true}, "Attributes")
+
+			filter := func(query *storage.ProductQuery) []int {
+				page, err := s.GetProducts(ctx, query)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids := []int{}
+				for _, p := range page.Products {
+					ids = append(ids, p.ID)
+				}
+				return ids
+			}
+			checkEqual(t, filter(&storage.ProductQuery{Tags: []string{"organic"}}), []int{1, 2}, "Tag Filter")
+			checkEqual(t, filter(&storage.ProductQuery{Tags: []string{"organic", "clearance"}}), []int{1}, "Tags Filter")
+			checkEqual(t, filter(&storage.ProductQuery{Attributes: map[string]string{"material": "cotton"}}), []int{1}, "Attribute Filter")
+			checkEqual(t, filter(&storage.ProductQuery{Attributes: map[string]string{"weight_g": "250", "recycled": "true"}}), []int{1},
+				"Typed Attribute Filter")
+			checkEqual(t, filter(&storage.ProductQuery{Attributes: map[string]string{"weight_g": "0.5", "material": "cotton"}}), []int{},
+				"Attributes Filter")
+
+			if err = s.SetProductTags(ctx, 1, nil); err != nil {
+				t.Fatal(err)
+			}
+			if err = s.SetProductAttributes(ctx, 1, nil); err != nil {
+				t.Fatal(err)
+			}
+			product, err = s.GetProduct(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, len(product.Tags), 0, "Cleared Tags")
+			checkEqual(t, len(product.Attributes), 0, "Cleared Attributes")
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// BlobStore is an interface that defines the methods that a store of binary objects (blobs) must implement.
+// Every method takes the context of the calling request so that slow operations are cancelled when it is done.
+//
+// Blobs are identified by keys, which are relative slash-separated paths (eg. "products/1/01HF...").
+// Put replaces the blob with key if there is one, and readers of the blob never see a partially written blob.
+// Get returns a NotFoundError if there is no blob with key, and the caller must close the blob it returns.
+// Deleting a blob that does not exist is not an error.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// DiskBlobStore is an implementation of the BlobStore interface that keeps each blob in a file below a directory.
+// The blobs returned by Get are *os.File, so they can also be seeked.
+type DiskBlobStore struct {
+	dir string
+}
+
+// NewDiskBlobStore returns a DiskBlobStore that keeps blobs below dir, which is created if it does not exist.
+func NewDiskBlobStore(dir string) (*DiskBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DiskBlobStore{dir: dir}, nil
+}
+
+// Dir returns the directory that the blobs are kept below.
+func (d *DiskBlobStore) Dir() string {
+	return d.dir
+}
+
+// Put writes the blob with key from r.
+func (d *DiskBlobStore) Put(ctx context.Context, key string, r io.Reader) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a failed write never leaves a truncated blob.
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get opens the blob with key.
+func (d *DiskBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	path, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, &NotFoundError{Operation: fmt.Sprintf("DiskBlobStore.Get(%q)", key)}
+	}
+	return f, err
+}
+
+// Delete deletes the blob with key.
+func (d *DiskBlobStore) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path returns the path of the file of the blob with key. An error is returned if key is not a relative path
+// that stays below the directory of d.
+func (d *DiskBlobStore) path(key string) (string, error) {
+	local := filepath.FromSlash(key)
+	if !filepath.IsLocal(local) {
+		return "", fmt.Errorf("Invalid blob key %q", key)
+	}
+	return filepath.Join(d.dir, local), nil
+}
@@ -0,0 +1,54 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+)
+
+// Tests that blobs are written, replaced, read and deleted, and that keys cannot escape the directory.
+func TestDiskBlobStore(t *testing.T) {
+	ctx := context.Background()
+	blobs, err := storage.NewDiskBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(key string) string {
+		blob, err := blobs.Get(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer blob.Close()
+		b, err := io.ReadAll(blob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	if err = blobs.Put(ctx, "products/1/a", strings.NewReader("first")); err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, read("products/1/a"), "first", "Content")
+	if err = blobs.Put(ctx, "products/1/a", strings.NewReader("second")); err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, read("products/1/a"), "second", "Replaced Content")
+
+	if err = blobs.Delete(ctx, "products/1/a"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = blobs.Get(ctx, "products/1/a")
+	checkEqual(t, errors.As(err, new(*storage.NotFoundError)), true, "Get Deleted Blob")
+	checkEqual(t, blobs.Delete(ctx, "products/1/a"), nil, "Delete Deleted Blob")
+
+	for _, key := range []string{"../escape", "/absolute", ""} {
+		err = blobs.Put(ctx, key, strings.NewReader("content"))
+		checkEqual(t, err != nil, true, "Put Invalid Key "+key)
+	}
+}
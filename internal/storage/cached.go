@@ -0,0 +1,312 @@
+package storage
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"golang.org/x/sync/singleflight"
+)
+
+// cacheLoadTimeout is how long a product may take to be read from the backend when it is not cached.
+const cacheLoadTimeout = 10 * time.Second
+
+// Cached is an implementation of the Storage interface that caches the products returned by GetProduct
+// from another Storage, the backend. Every other method is passed through to the backend.
+//
+// The cache holds up to a fixed number of products, evicting the least recently used, and each product expires
+// after a fixed TTL. Concurrent misses for the same product are collapsed into a single call to the backend.
+// Reads with a context returned by ReadPrimary bypass the cache, and the cache is filled from the primary database,
+// since a product read from a replica that has not caught up with a change would be cached after it is invalidated.
+// A product is invalidated whenever it is changed through Cached, or when the transaction that changed it
+// is committed, so changes made to the backend directly are only seen once the cached product expires.
+type Cached struct {
+	Storage
+	cache *productCache
+	// changed holds the IDs of the products changed in a transaction, which are invalidated after it is done.
+	// It is nil outside of a transaction, where the cache is not used.
+	changed *[]int
+}
+
+// CacheStats is a struct that holds the counters of a Cached storage.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	// Size is the number of products in the cache.
+	Size int
+}
+
+// NewCached returns a Cached storage that caches up to size products from backend for ttl.
+func NewCached(backend Storage, size int, ttl time.Duration) *Cached {
+	return &Cached{
+		Storage: backend,
+		cache: &productCache{
+			size:    size,
+			ttl:     ttl,
+			entries: make(map[int]*list.Element),
+			order:   list.New(),
+		},
+	}
+}
+
+// Stats returns the counters of the cache.
+func (c *Cached) Stats() CacheStats {
+	return CacheStats{
+		Hits:   c.cache.hits.Load(),
+		Misses: c.cache.misses.Load(),
+		Size:   c.cache.len(),
+	}
+}
+
+// GetProduct returns the product with id from the cache, or from the backend if it is not cached.
+// Products are read from the backend in transactions, since they may have uncommitted changes,
+// and with a context returned by ReadPrimary, since the cached product may be out of date.
+func (c *Cached) GetProduct(ctx context.Context, id int) (*models.Product, error) {
+	if c.changed != nil || readsPrimary(ctx) {
+		return c.Storage.GetProduct(ctx, id)
+	}
+	if p := c.cache.get(id); p != nil {
+		c.cache.hits.Add(1)
+		return p, nil
+	}
+	c.cache.misses.Add(1)
+
+	// The generation is read before the product so that a product read before it is invalidated is not cached.
+	generation := c.cache.generation.Load()
+	loaded := c.cache.loads.DoChan(strconv.Itoa(id), func() (any, error) {
+		// The load is shared by every call that is collapsed into it, so it is not cancelled with the call
+		// that started it, and instead has a timeout of its own.
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cacheLoadTimeout)
+		defer cancel()
+		p, err := c.Storage.GetProduct(ReadPrimary(loadCtx), id)
+		if err != nil {
+			return nil, err
+		}
+		c.cache.put(p, generation)
+		return p, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-loaded:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		// The product is shared by every call that was collapsed into this one.
+		return copyProduct(result.Val.(*models.Product)), nil
+	}
+}
+
+// UpdateProduct updates the product in the backend and invalidates it.
+func (c *Cached) UpdateProduct(ctx context.Context, product *models.Product) error {
+	defer c.invalidate(product.ID)
+	return c.Storage.UpdateProduct(ctx, product)
+}
+
+// DeleteProduct deletes the product with id in the backend and invalidates it.
+func (c *Cached) DeleteProduct(ctx context.Context, id int, version int) error {
+	defer c.invalidate(id)
+	return c.Storage.DeleteProduct(ctx, id, version)
+}
+
+// RestoreProduct restores the product with id in the backend and invalidates it.
+func (c *Cached) RestoreProduct(ctx context.Context, id int) error {
+	defer c.invalidate(id)
+	return c.Storage.RestoreProduct(ctx, id)
+}
+
+// PurgeProduct purges the product with id in the backend and invalidates it.
+func (c *Cached) PurgeProduct(ctx context.Context, id int, version int) error {
+	defer c.invalidate(id)
+	return c.Storage.PurgeProduct(ctx, id, version)
+}
+
+// ReserveStock reserves stock of the product with productID in the backend and invalidates it.
+func (c *Cached) ReserveStock(ctx context.Context, productID, quantity int, ttl time.Duration) (*models.Reservation, error) {
+	defer c.invalidate(productID)
+	return c.Storage.ReserveStock(ctx, productID, quantity, ttl)
+}
+
+// ReleaseStock releases the reservation in the backend and invalidates its product.
+func (c *Cached) ReleaseStock(ctx context.Context, productID, reservationID int) error {
+	defer c.invalidate(productID)
+	return c.Storage.ReleaseStock(ctx, productID, reservationID)
+}
+
+// CommitReservation commits the reservation in the backend and invalidates its product.
+// Committing does not change the product, but an expired reservation returns its stock instead.
+func (c *Cached) CommitReservation(ctx context.Context, productID, reservationID int) error {
+	defer c.invalidate(productID)
+	return c.Storage.CommitReservation(ctx, productID, reservationID)
+}
+
+// CreateVariant creates a variant of the product with productID in the backend and invalidates the product.
+func (c *Cached) CreateVariant(ctx context.Context, productID int, variant *models.VariantRequest) (int, error) {
+	defer c.invalidate(productID)
+	return c.Storage.CreateVariant(ctx, productID, variant)
+}
+
+// UpdateVariant updates the variant in the backend and invalidates its product.
+func (c *Cached) UpdateVariant(ctx context.Context, variant *models.Variant) error {
+	defer c.invalidate(variant.ProductID)
+	return c.Storage.UpdateVariant(ctx, variant)
+}
+
+// DeleteVariant deletes the variant in the backend and invalidates its product.
+func (c *Cached) DeleteVariant(ctx context.Context, productID, variantID int) error {
+	defer c.invalidate(productID)
+	return c.Storage.DeleteVariant(ctx, productID, variantID)
+}
+
+// SetProductCategories sets the categories of the product with productID in the backend and invalidates it.
+func (c *Cached) SetProductCategories(ctx context.Context, productID int, categoryIDs []int) error {
+	defer c.invalidate(productID)
+	return c.Storage.SetProductCategories(ctx, productID, categoryIDs)
+}
+
+// SetProductTags sets the tags of the product with productID in the backend and invalidates it.
+func (c *Cached) SetProductTags(ctx context.Context, productID int, tags []string) error {
+	defer c.invalidate(productID)
+	return c.Storage.SetProductTags(ctx, productID, tags)
+}
+
+// SetProductAttributes sets the attributes of the product with productID in the backend and invalidates it.
+func (c *Cached) SetProductAttributes(ctx context.Context, productID int, attributes map[string]any) error {
+	defer c.invalidate(productID)
+	return c.Storage.SetProductAttributes(ctx, productID, attributes)
+}
+
+// DeleteCategory deletes the category with id in the backend and invalidates every product,
+// since any of them may have been assigned to it.
+func (c *Cached) DeleteCategory(ctx context.Context, id int) error {
+	defer c.invalidate(allProducts)
+	return c.Storage.DeleteCategory(ctx, id)
+}
+
+// WithTx calls fn with a view of c in a transaction of the backend. The products changed by fn are invalidated
+// once the transaction is done, and the view always reads products from the backend.
+func (c *Cached) WithTx(ctx context.Context, fn func(tx Storage) error) error {
+	if c.changed != nil {
+		return c.Storage.WithTx(ctx, func(tx Storage) error {
+			return fn(&Cached{Storage: tx, cache: c.cache, changed: c.changed})
+		})
+	}
+
+	var changed []int
+	defer func() {
+		for _, id := range changed {
+			c.cache.invalidate(id)
+		}
+	}()
+	return c.Storage.WithTx(ctx, func(tx Storage) error {
+		return fn(&Cached{Storage: tx, cache: c.cache, changed: &changed})
+	})
+}
+
+// allProducts is passed to invalidate to invalidate every product. Product IDs start from 1.
+const allProducts = 0
+
+// invalidate removes the product with id from the cache, or after the transaction is done in a transaction.
+func (c *Cached) invalidate(id int) {
+	if c.changed != nil {
+		*c.changed = append(*c.changed, id)
+		return
+	}
+	c.cache.invalidate(id)
+}
+
+// productCache is a concurrency-safe LRU cache of products with a TTL.
+type productCache struct {
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[int]*list.Element
+	// order holds the entries from the most to the least recently used.
+	order *list.List
+	// generation is incremented by every invalidation.
+	generation atomic.Uint64
+
+	loads  singleflight.Group
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// cacheEntry is a product held by a productCache.
+type cacheEntry struct {
+	product   *models.Product
+	expiresAt time.Time
+}
+
+// get returns a copy of the cached product with id, or nil if it is not cached or has expired.
+func (pc *productCache) get(id int) *models.Product {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	elem, ok := pc.entries[id]
+	if !ok {
+		return nil
+	}
+	entry, _ := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		pc.remove(elem)
+		return nil
+	}
+	pc.order.MoveToFront(elem)
+	return copyProduct(entry.product)
+}
+
+// put caches a copy of p, evicting the least recently used product if the cache is full.
+// p is not cached if the cache has been invalidated since generation, as it may be stale.
+func (pc *productCache) put(p *models.Product, generation uint64) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if pc.generation.Load() != generation {
+		return
+	}
+	entry := &cacheEntry{product: copyProduct(p), expiresAt: time.Now().Add(pc.ttl)}
+	if elem, ok := pc.entries[p.ID]; ok {
+		elem.Value = entry
+		pc.order.MoveToFront(elem)
+		return
+	}
+	pc.entries[p.ID] = pc.order.PushFront(entry)
+	for len(pc.entries) > pc.size {
+		pc.remove(pc.order.Back())
+	}
+}
+
+// invalidate removes the product with id from the cache, or every product if id is allProducts.
+func (pc *productCache) invalidate(id int) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.generation.Add(1)
+	if id == allProducts {
+		clear(pc.entries)
+		pc.order.Init()
+		return
+	}
+	if elem, ok := pc.entries[id]; ok {
+		pc.remove(elem)
+	}
+}
+
+// remove removes elem from the cache. The caller must hold mu.
+func (pc *productCache) remove(elem *list.Element) {
+	entry, _ := elem.Value.(*cacheEntry)
+	delete(pc.entries, entry.product.ID)
+	pc.order.Remove(elem)
+}
+
+// len returns the number of products in the cache, including those that have expired but not been removed.
+func (pc *productCache) len() int {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return len(pc.entries)
+}
@@ -0,0 +1,205 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+)
+
+// countingStorage is a Storage that counts the calls to GetProduct, and blocks them until release is closed.
+type countingStorage struct {
+	storage.Storage
+	gets    atomic.Int64
+	release chan struct{}
+}
+
+func (s *countingStorage) GetProduct(ctx context.Context, id int) (*models.Product, error) {
+	s.gets.Add(1)
+	<-s.release
+	return s.Storage.GetProduct(ctx, id)
+}
+
+// newCountingStorage returns a countingStorage of a Memory holding the given number of products, with IDs from 1.
+func newCountingStorage(t *testing.T, products int) *countingStorage {
+	t.Helper()
+
+	backend := &countingStorage{Storage: storage.NewMemory(), release: make(chan struct{})}
+	close(backend.release)
+	for i := 0; i < products; i++ {
+		_, err := backend.CreateProduct(context.Background(), &models.CreateProductRequest{Name: "Test Product", StockQuantity: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return backend
+}
+
+// Tests that Cached reads products from the cache until they are changed, evicted or expired.
+func TestCached_GetProduct(t *testing.T) {
+	ctx := context.Background()
+
+	tt := []struct {
+		name string
+		// act is called between two reads of the product with ID 1.
+		act func(t *testing.T, c *storage.Cached)
+		ttl time.Duration
+		// expectedGets is the number of reads of the backend, including those made by act.
+		expectedGets int64
+	}{
+		{"hit", func(t *testing.T, c *storage.Cached) {}, time.Minute, 1},
+		{"read from primary", func(t *testing.T, c *storage.Cached) {
+			if _, err := c.GetProduct(storage.ReadPrimary(ctx), 1); err != nil {
+				t.Fatal(err)
+			}
+		}, time.Minute, 2},
+		{"expired", func(t *testing.T, c *storage.Cached) { time.Sleep(20 * time.Millisecond) }, 10 * time.Millisecond, 2},
+		{"evicted", func(t *testing.T, c *storage.Cached) {
+			for _, id := range []int{2, 3} {
+				if _, err := c.GetProduct(ctx, id); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}, time.Minute, 4},
+		{"updated", func(t *testing.T, c *storage.Cached) {
+			product, err := c.GetProduct(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			product.StockQuantity = 5
+			if err = c.UpdateProduct(ctx, product); err != nil {
+				t.Fatal(err)
+			}
+		}, time.Minute, 2},
+		{"reserved", func(t *testing.T, c *storage.Cached) {
+			if _, err := c.ReserveStock(ctx, 1, 5, time.Minute); err != nil {
+				t.Fatal(err)
+			}
+		}, time.Minute, 2},
+		{"updated in transaction", func(t *testing.T, c *storage.Cached) {
+			err := c.WithTx(ctx, func(tx storage.Storage) error {
+				product, err := tx.GetProduct(ctx, 1)
+				if err != nil {
+					return err
+				}
+				product.StockQuantity = 5
+				return tx.UpdateProduct(ctx, product)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}, time.Minute, 2},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			backend := newCountingStorage(t, 3)
+			c := storage.NewCached(backend, 2, tc.ttl)
+
+			if _, err := c.GetProduct(ctx, 1); err != nil {
+				t.Fatal(err)
+			}
+			tc.act(t, c)
+			product, err := c.GetProduct(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := backend.Storage.GetProduct(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checkEqual(t, product, want, "Product")
+			checkEqual(t, backend.gets.Load(), tc.expectedGets, "Backend Reads")
+		})
+	}
+}
+
+// Tests that concurrent misses for the same product are collapsed into one read of the backend.
+func TestCached_GetProductConcurrent(t *testing.T) {
+	ctx := context.Background()
+	backend := newCountingStorage(t, 1)
+	backend.release = make(chan struct{})
+	c := storage.NewCached(backend, 10, time.Minute)
+
+	const readers = 10
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetProduct(ctx, 1); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	// Wait for the first reader to reach the backend, and give the others time to join it.
+	for backend.gets.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(backend.release)
+	wg.Wait()
+
+	checkEqual(t, backend.gets.Load(), int64(1), "Backend Reads")
+	stats := c.Stats()
+	checkEqual(t, stats.Hits+stats.Misses, uint64(readers), "Reads")
+	checkEqual(t, stats.Size, 1, "Size")
+}
+
+// Tests that cancelling the call that started a read of the backend only fails that call,
+// and not the others that were collapsed into it.
+func TestCached_GetProductCancelled(t *testing.T) {
+	backend := newCountingStorage(t, 1)
+	backend.release = make(chan struct{})
+	c := storage.NewCached(backend, 10, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := c.GetProduct(ctx, 1)
+		first <- err
+	}()
+	for backend.gets.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	second := make(chan error, 1)
+	go func() {
+		_, err := c.GetProduct(context.Background(), 1)
+		second <- err
+	}()
+	// Give the second call time to join the first.
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+	checkEqual(t, errors.Is(<-first, context.Canceled), true, "Cancelled Call")
+	close(backend.release)
+	checkEqual(t, <-second, nil, "Collapsed Call")
+	checkEqual(t, backend.gets.Load(), int64(1), "Backend Reads")
+}
+
+// Tests that the cache of a store with replicas is filled from the primary database, so that a product that is
+// invalidated is not cached again as a replica that has not caught up with the change has it.
+func TestCached_GetProductWithReplicas(t *testing.T) {
+	ctx := context.Background()
+	primary := newProductDB(t, "Primary")
+	replica := newProductDB(t, "Replica")
+	m := storage.NewMariaWithReplicas(primary, []*sql.DB{replica})
+	t.Cleanup(func() { m.Close() })
+	c := storage.NewCached(m, 10, time.Minute)
+
+	for _, name := range []string{"Get Product", "Get Cached Product"} {
+		product, err := c.GetProduct(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkEqual(t, product.Name, "Primary", name)
+	}
+	checkEqual(t, c.Stats().Hits, uint64(1), "Hits")
+}
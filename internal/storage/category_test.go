@@ -0,0 +1,113 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+)
+
+// Tests that categories are created, moved, assigned to products, filtered on and deleted consistently
+// by every storage engine.
+func TestStorage_Categories(t *testing.T) {
+	for _, engine := range testEngines() {
+		t.Run(engine.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := engine.storage(t)
+			for i := 0; i < 3; i++ {
+				_, err := s.CreateProduct(ctx, &models.CreateProductRequest{Name: "Test Product", Price: models.NewMoney(1000, "")})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			create := func(parentID *int, slug string, position int) int {
+				id, err := s.CreateCategory(ctx, &models.CategoryRequest{ParentID: parentID, Name: slug, Slug: slug, Position: position})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return id
+			}
+			clothing := create(nil, "clothing", 1)
+			shoes := create(nil, "shoes", 0)
+			shirts := create(&clothing, "shirts", 0)
+			jackets := create(&clothing, "jackets", 0)
+
+			_, err := s.CreateCategory(ctx, &models.CategoryRequest{Name: "Shoes", Slug: "shoes"})
+			checkEqual(t, errors.As(err, new(*storage.ConflictError)), true, "Create Duplicate Slug")
+			unknown := 100
+			_, err = s.CreateCategory(ctx, &models.CategoryRequest{ParentID: &unknown, Name: "Hats", Slug: "hats"})
+			checkEqual(t, errors.As(err, new(*storage.UnknownCategoryError)), true, "Create With Unknown Parent")
+
+			// Jackets cannot be moved below itself, and clothing cannot be moved below its child.
+			err = s.UpdateCategory(ctx, &models.Category{ID: jackets, ParentID: &jackets, Name: "Jackets", Slug: "jackets"})
+			checkEqual(t, errors.As(err, new(*storage.CategoryCycleError)), true, "Move Below Itself")
+			err = s.UpdateCategory(ctx, &models.Category{ID: clothing, ParentID: &shirts, Name: "Clothing", Slug: "clothing"})
+			checkEqual(t, errors.As(err, new(*storage.CategoryCycleError)), true, "Move Below Child")
+			coats := &models.Category{ID: jackets, ParentID: &shirts, Name: "Coats", Slug: "coats", Position: 3}
+			if err = s.UpdateCategory(ctx, coats); err != nil {
+				t.Fatal(err)
+			}
+			category, err := s.GetCategory(ctx, jackets)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, *category, *coats, "Updated Category")
+
+			categories, err := s.GetCategories(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []int
+			for _, c := range categories {
+				ids = append(ids, c.ID)
+			}
+			checkEqual(t, ids, []int{shoes, shirts, clothing, jackets}, "Category Order")
+
+			if err = s.SetProductCategories(ctx, 1, []int{jackets, shoes, shoes}); err != nil {
+				t.Fatal(err)
+			}
+			if err = s.SetProductCategories(ctx, 2, []int{clothing}); err != nil {
+				t.Fatal(err)
+			}
+			err = s.SetProductCategories(ctx, 3, []int{unknown})
+			checkEqual(t, errors.As(err, new(*storage.UnknownCategoryError)), true, "Assign Unknown Category")
+			err = s.SetProductCategories(ctx, 4, []int{shoes})
+			checkEqual(t, errors.As(err, new(*storage.NotFoundError)), true, "Assign Unknown Product")
+			product, err := s.GetProduct(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, product.CategoryIDs, []int{shoes, jackets}, "Product Categories")
+
+			page, err := s.GetProducts(ctx, &storage.ProductQuery{CategoryIDs: []int{shirts, jackets}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, len(page.Products), 1, "Filtered Products")
+			checkEqual(t, page.Products[0].ID, 1, "Filtered Product ID")
+
+			// Deleting shirts moves its child to clothing, and unassigns it.
+			if err = s.DeleteCategory(ctx, shirts); err != nil {
+				t.Fatal(err)
+			}
+			err = s.DeleteCategory(ctx, shirts)
+			checkEqual(t, errors.As(err, new(*storage.NotFoundError)), true, "Delete Deleted Category")
+			category, err = s.GetCategory(ctx, jackets)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, *category.ParentID, clothing, "Parent After Delete")
+			if err = s.DeleteCategory(ctx, jackets); err != nil {
+				t.Fatal(err)
+			}
+			product, err = s.GetProduct(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, product.CategoryIDs, []int{shoes}, "Product Categories After Delete")
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package storage
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+)
 
 // NotFoundError is an error that is returned when a resource is not found.
 type NotFoundError struct {
@@ -10,3 +14,99 @@ type NotFoundError struct {
 func (e *NotFoundError) Error() string {
 	return fmt.Sprintf("Not found: %s", e.Operation)
 }
+
+// VersionConflictError is an error that is returned when a resource cannot be modified
+// because its version is not the expected version (ie. it has been modified by someone else).
+type VersionConflictError struct {
+	Operation string
+	// Expected is the version that the resource was expected to have.
+	Expected int
+	// Actual is the current version of the resource.
+	Actual int
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("Version conflict: %s: expected version %d but found %d", e.Operation, e.Expected, e.Actual)
+}
+
+// InvalidCursorError is an error that is returned when a pagination cursor cannot be decoded.
+type InvalidCursorError struct {
+	Cursor string
+}
+
+func (e *InvalidCursorError) Error() string {
+	return fmt.Sprintf("Invalid cursor: %q", e.Cursor)
+}
+
+// InvalidSortError is an error that is returned when products cannot be sorted as requested.
+type InvalidSortError struct {
+	Sort string
+}
+
+func (e *InvalidSortError) Error() string {
+	return fmt.Sprintf("Invalid sort: %q", e.Sort)
+}
+
+// InsufficientStockError is an error that is returned when more stock of a product is requested than it has.
+type InsufficientStockError struct {
+	Operation string
+	Requested int
+	// Available is the stock that the product has.
+	Available int
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("Insufficient stock: %s: requested %d but %d available", e.Operation, e.Requested, e.Available)
+}
+
+// ReservationStateError is an error that is returned when a reservation cannot be committed or released
+// because it is no longer pending.
+type ReservationStateError struct {
+	Operation string
+	Status    models.ReservationStatus
+}
+
+func (e *ReservationStateError) Error() string {
+	return fmt.Sprintf("Reservation is %s: %s", e.Status, e.Operation)
+}
+
+// UnsupportedCurrencyError is an error that is returned when a price cannot be converted to a currency
+// because it has no exchange rate.
+type UnsupportedCurrencyError struct {
+	Currency string
+}
+
+func (e *UnsupportedCurrencyError) Error() string {
+	return fmt.Sprintf("Unsupported currency: %s has no exchange rate", e.Currency)
+}
+
+// ConflictError is an error that is returned when a resource cannot be created or modified
+// because it would have the same value as another resource in a field that must be unique.
+type ConflictError struct {
+	Operation string
+	Field     string
+	Value     string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("Conflict: %s: %s %q is already used", e.Operation, e.Field, e.Value)
+}
+
+// UnknownCategoryError is an error that is returned when a category that is referred to does not exist.
+type UnknownCategoryError struct {
+	ID int
+}
+
+func (e *UnknownCategoryError) Error() string {
+	return fmt.Sprintf("Unknown category: %d", e.ID)
+}
+
+// CategoryCycleError is an error that is returned when a category would be moved below itself in the tree.
+type CategoryCycleError struct {
+	ID       int
+	ParentID int
+}
+
+func (e *CategoryCycleError) Error() string {
+	return fmt.Sprintf("Category cycle: category %d cannot be moved below category %d, which is in its subtree", e.ID, e.ParentID)
+}
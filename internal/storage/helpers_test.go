@@ -0,0 +1,35 @@
+package storage_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+)
+
+// Check that got and want are equal, and if not, log an error to t.
+// msg should be a short description of what is being tested (eg. "Product").
+func checkEqual(t *testing.T, got, want interface{}, msg string) {
+	t.Helper()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: got %v want %v", msg, got, want)
+	}
+}
+
+// testEngine is a storage engine that tests are run against.
+type testEngine struct {
+	name string
+	// storage returns a new, empty storage of the engine, which is closed when t is done.
+	storage func(t *testing.T) storage.Storage
+}
+
+// Returns every storage engine that can be tested without an external database.
+func testEngines() []testEngine {
+	return []testEngine{
+		{"memory", func(t *testing.T) storage.Storage { return storage.NewMemory() }},
+		{"sqlite", func(t *testing.T) storage.Storage { return newSQLite(t) }},
+		{"cached", func(t *testing.T) storage.Storage { return storage.NewCached(newSQLite(t), 10, time.Minute) }},
+	}
+}
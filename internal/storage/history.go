@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+)
+
+// Audit is a struct that defines who is making changes, and in which request.
+// It is recorded in the history of every product that is changed with a context holding it (see WithAudit).
+type Audit struct {
+	Actor     string
+	RequestID string
+}
+
+// auditKey is the context key of the Audit of a context.
+type auditKey struct{}
+
+// WithAudit returns a copy of ctx that holds audit.
+func WithAudit(ctx context.Context, audit Audit) context.Context {
+	return context.WithValue(ctx, auditKey{}, audit)
+}
+
+// auditFrom returns the Audit held by ctx, or the zero Audit if there is none.
+func auditFrom(ctx context.Context) Audit {
+	audit, _ := ctx.Value(auditKey{}).(Audit)
+	return audit
+}
+
+// newProductChange returns the change made to the product with id by operation at the current time,
+// audited with the Audit held by ctx.
+func newProductChange(ctx context.Context, operation models.ChangeOperation, id int, before, after *models.Product) *models.ProductChange {
+	audit := auditFrom(ctx)
+	return &models.ProductChange{
+		ProductID: id,
+		Operation: operation,
+		Before:    before,
+		After:     after,
+		ChangedAt: now(),
+		Actor:     audit.Actor,
+		RequestID: audit.RequestID,
+	}
+}
+
+// HistoryQuery is a struct that defines which page of the history of a product to retrieve.
+// Changes are ordered from the most recent.
+type HistoryQuery struct {
+	// Limit is the maximum number of changes in the page. Zero means DefaultPageLimit.
+	Limit int
+	// After is the opaque cursor of the previous page (see HistoryPage.NextCursor).
+	// The page starts from the most recent change when it is empty.
+	After string
+	// AsOf excludes the changes that were made after it.
+	AsOf *time.Time
+}
+
+// HistoryPage is a struct that holds a single page of the history of a product.
+type HistoryPage struct {
+	Changes []models.ProductChange
+	// NextCursor is used as HistoryQuery.After to retrieve the next page. It is empty on the last page.
+	NextCursor string
+}
+
+// historySort is the sort of history cursors, which distinguishes them from the cursors of product pages.
+const historySort = "history"
+
+// limit returns the page size of q, substituting and clamping it to the allowed range.
+func (q *HistoryQuery) limit() int {
+	if q == nil {
+		return pageLimit(0)
+	}
+	return pageLimit(q.Limit)
+}
+
+// after decodes the After cursor of q into the ID of the last change of the previous page.
+// Zero is returned when there is none.
+func (q *HistoryQuery) after() (int, error) {
+	if q == nil || q.After == "" {
+		return 0, nil
+	}
+	c, err := decodeCursor(q.After)
+	if err != nil {
+		return 0, err
+	}
+	if c.Sort != historySort || c.Value != nil || c.ID <= 0 {
+		return 0, &InvalidCursorError{Cursor: q.After}
+	}
+	return c.ID, nil
+}
+
+// matches returns whether change c passes the filters of q, and is after its cursor (the ID after).
+func (q *HistoryQuery) matches(c *models.ProductChange, after int) bool {
+	if after != 0 && c.ID >= after {
+		return false
+	}
+	return q == nil || q.AsOf == nil || !c.ChangedAt.After(*q.AsOf)
+}
+
+// newHistoryCursor returns the encoded cursor of change c in a page of history.
+func newHistoryCursor(c *models.ProductChange) string {
+	return cursor{ID: c.ID, Sort: historySort}.encode()
+}
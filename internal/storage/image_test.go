@@ -0,0 +1,74 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+)
+
+// Tests that the metadata of images is created, listed and deleted consistently by every storage engine.
+func TestStorage_Images(t *testing.T) {
+	for _, engine := range testEngines() {
+		t.Run(engine.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := engine.storage(t)
+			_, err := s.CreateProduct(ctx, &models.CreateProductRequest{Name: "Test Product", Price: models.NewMoney(1000, "")})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			image := &models.Image{ProductID: 1, Key: "products/1/a", ContentType: "image/png", Size: 10, Checksum: "abc"}
+			created, err := s.CreateImage(ctx, image)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, created.ID, 1, "Image ID")
+			checkEqual(t, created.CreatedAt.IsZero(), false, "Created At")
+			_, err = s.CreateImage(ctx, &models.Image{ProductID: 2, Key: "products/2/a"})
+			checkEqual(t, errors.As(err, new(*storage.NotFoundError)), true, "Create Image Of Unknown Product")
+
+			got, err := s.GetImage(ctx, 1, created.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, *got, *created, "Image")
+			images, err := s.GetImages(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, images, []models.Image{*created}, "Images")
+			_, err = s.GetImage(ctx, 2, created.ID)
+			checkEqual(t, errors.As(err, new(*storage.NotFoundError)), true, "Get Image Of Other Product")
+
+			if err = s.DeleteImage(ctx, 1, created.ID); err != nil {
+				t.Fatal(err)
+			}
+			err = s.DeleteImage(ctx, 1, created.ID)
+			checkEqual(t, errors.As(err, new(*storage.NotFoundError)), true, "Delete Deleted Image")
+
+			// The keys of the images of a product in the trash are still returned, until it is purged.
+			if _, err = s.CreateImage(ctx, &models.Image{ProductID: 1, Key: "products/1/b", ContentType: "image/png"}); err != nil {
+				t.Fatal(err)
+			}
+			if err = s.DeleteProduct(ctx, 1, storage.AnyVersion); err != nil {
+				t.Fatal(err)
+			}
+			keys, err := s.GetImageKeys(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, keys, []string{"products/1/b"}, "Image Keys In Trash")
+			if err = s.PurgeProduct(ctx, 1, storage.AnyVersion); err != nil {
+				t.Fatal(err)
+			}
+			keys, err = s.GetImageKeys(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, keys, []string{}, "Image Keys After Purge")
+		})
+	}
+}
@@ -1,124 +1,171 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
 
 	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/go-sql-driver/mysql"
 )
 
+// mariaDeadlock is the error number of a deadlock in MariaDB (ER_LOCK_DEADLOCK).
+// InnoDB rolls back the transaction that is chosen as the deadlock victim, so it is safe to retry.
+const mariaDeadlock = 1213
+
+// mariaLockWaitTimeout is the error number of a timeout waiting for a row lock in MariaDB (ER_LOCK_WAIT_TIMEOUT).
+// Only the statement that timed out is rolled back, but the whole transaction is rolled back before it is retried.
+const mariaLockWaitTimeout = 1205
+
+// mariaDuplicate is the error number of a duplicate entry for a unique index in MariaDB (ER_DUP_ENTRY).
+const mariaDuplicate = 1062
+
 // Maria is an implementation of the Storage interface using MariaDB.
+//
+// Every statement is run on the primary database, except that GetProduct and GetProducts read from its replicas
+// (in turn) if it has any. Replicas may lag behind the primary, so reads are not routed to them within a
+// transaction or with a context returned by ReadPrimary (eg. for a caller that has just made a write).
 type Maria struct {
-	DB *sql.DB
+	sqlStore
+	// replicas are read-only copies of the primary database (sqlStore.db). It is empty if there are none.
+	replicas []*sql.DB
+	// next is incremented to choose the replica of each read. It is shared by every view of the store,
+	// including those within WithTx.
+	next *atomic.Uint64
 }
 
 func NewMaria(db *sql.DB) *Maria {
+	return NewMariaWithReplicas(db, nil)
+}
+
+// NewMariaWithReplicas returns a Maria store that writes to the primary database and reads from its replicas.
+func NewMariaWithReplicas(primary *sql.DB, replicas []*sql.DB) *Maria {
 	return &Maria{
-		DB: db,
+		sqlStore: sqlStore{
+			db: primary, name: "Maria", forUpdate: " FOR UPDATE", retryable: isMariaTransient,
+			retry: DefaultRetryPolicy(), duplicate: isMariaDuplicate,
+		},
+		replicas: replicas,
+		next:     new(atomic.Uint64),
 	}
 }
 
-// GetProduct returns a product by id.
-func (m Maria) GetProduct(id int) (*models.Product, error) {
-	query := `
-	SELECT * 
-	FROM products 
-	WHERE id = ?`
-	row := m.DB.QueryRow(query, id)
+// SetRetryPolicy sets the policy that operations that fail with a transient error are retried with.
+// It replaces DefaultRetryPolicy, and must be called before m is used.
+func (m *Maria) SetRetryPolicy(policy RetryPolicy) {
+	m.retry = policy
+}
 
-	result := &models.Product{}
-	err := row.Scan(&result.ID, &result.Name, &result.Description, &result.Price, &result.StockQuantity)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, &NotFoundError{Operation: fmt.Sprintf("Maria.GetProduct(%d)", id)}
-		}
-		return nil, err
-	}
-	return result, nil
+// WithTx calls fn with a view of m in a transaction, which is committed if fn succeeds and rolled back otherwise.
+// Transactions that fail with a transient error (see isMariaTransient) are retried, so fn may be called more than once.
+func (m Maria) WithTx(ctx context.Context, fn func(tx Storage) error) error {
+	return m.withTx(ctx, func(tx sqlStore) error {
+		return fn(Maria{sqlStore: tx, replicas: m.replicas, next: m.next})
+	})
 }
 
-// GetProducts returns all products.
-func (m Maria) GetProducts() (*[]models.Product, error) {
-	query := `
-	SELECT *
-	FROM products`
-	rows, err := m.DB.Query(query)
-	if err != nil {
-		return nil, err
+// GetProduct returns a product by its ID, unless it is in the trash. It is read from a replica if possible,
+// and is read again if that fails with a transient error.
+func (m Maria) GetProduct(ctx context.Context, id int) (*models.Product, error) {
+	var result *models.Product
+	err := m.retryIdempotent(ctx, func() error {
+		var err error
+		result, err = m.reader(ctx).GetProduct(ctx, id)
+		return err
+	})
+	return result, err
+}
+
+// GetProducts returns a page of the products that match query. It is read from a replica if possible,
+// and is read again if that fails with a transient error.
+func (m Maria) GetProducts(ctx context.Context, query *ProductQuery) (*ProductPage, error) {
+	var result *ProductPage
+	err := m.retryIdempotent(ctx, func() error {
+		var err error
+		result, err = m.reader(ctx).GetProducts(ctx, query)
+		return err
+	})
+	return result, err
+}
+
+// Ping checks that the primary database and each of its replicas can be reached.
+func (m Maria) Ping(ctx context.Context) error {
+	errs := []error{m.sqlStore.Ping(ctx)}
+	for _, replica := range m.replicas {
+		errs = append(errs, replica.PingContext(ctx))
 	}
-	defer rows.Close()
+	return errors.Join(errs...)
+}
 
-	result := &[]models.Product{}
-	for rows.Next() {
-		row := models.Product{}
-		err = rows.Scan(&row.ID, &row.Name, &row.Description, &row.Price, &row.StockQuantity)
-		if err != nil {
-			return nil, err
-		}
-		*result = append(*result, row)
+// Close closes the primary database and its replicas.
+func (m Maria) Close() error {
+	if m.tx != nil {
+		return nil
 	}
-	if err = rows.Err(); err != nil {
-		return nil, err
+	errs := []error{m.sqlStore.Close()}
+	for _, replica := range m.replicas {
+		errs = append(errs, replica.Close())
 	}
-
-	return result, nil
+	return errors.Join(errs...)
 }
 
-// CreateProduct creates a product.
-func (m Maria) CreateProduct(product *models.CreateProductRequest) (int, error) {
-	query := `
-	INSERT INTO products (name, description, price, stock_quantity)
-	VALUES (?, ?, ?, ?)`
-	result, err := m.DB.Exec(query, product.Name, product.Description, product.Price, product.StockQuantity)
-	if err != nil {
-		return 0, err
+// reader returns the view of m that reads with ctx are run on: one of its replicas, or m itself if reads
+// must see the latest writes.
+func (m Maria) reader(ctx context.Context) sqlStore {
+	if len(m.replicas) == 0 || m.tx != nil || readsPrimary(ctx) {
+		return m.sqlStore
 	}
-	var id int64
-	id, err = result.LastInsertId()
-	return int(id), err
+	view := m.sqlStore
+	view.db = m.replicas[m.next.Add(1)%uint64(len(m.replicas))]
+	return view
 }
 
-// UpdateProduct updates a product.
-func (m Maria) UpdateProduct(product *models.Product) error {
-	query := `
-	UPDATE products
-	SET name = ?, description = ?, price = ?, stock_quantity = ?
-	WHERE id = ?`
-	result, err := m.DB.Exec(query, product.Name, product.Description, product.Price, product.StockQuantity, product.ID)
-	if err != nil {
-		return err
+// isMariaTransient returns whether err was caused by a deadlock, a lock wait timeout or a broken connection,
+// which may not happen again if the operation that failed is retried.
+func isMariaTransient(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mariaDeadlock || mysqlErr.Number == mariaLockWaitTimeout
 	}
+	return errors.Is(err, mysql.ErrInvalidConn) || errors.Is(err, driver.ErrBadConn)
+}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("Error getting rows affected: %s", err.Error())
-	}
-	if rowsAffected == 0 {
-		return &NotFoundError{Operation: fmt.Sprintf("Maria.UpdateProduct(%d)", product.ID)}
-	}
-	return nil
+// isMariaDuplicate returns whether err was caused by a duplicate entry for a unique index.
+func isMariaDuplicate(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mariaDuplicate
 }
 
-// DeleteProduct deletes a product by id.
-func (m Maria) DeleteProduct(id int) error {
-	query := `
-	DELETE FROM products
-	WHERE id = ?`
-	result, err := m.DB.Exec(query, id)
+// SearchProducts returns up to limit products that match the search query, ordered by descending relevance.
+// It uses the FULLTEXT index over the name and description of products in natural language mode.
+func (m Maria) SearchProducts(ctx context.Context, query string, limit int) ([]models.SearchResult, error) {
+	stmt := `
+	SELECT ` + productColumns + `, MATCH (name, description) AGAINST (? IN NATURAL LANGUAGE MODE) AS score
+	FROM products
+	WHERE MATCH (name, description) AGAINST (? IN NATURAL LANGUAGE MODE) AND deleted_at IS NULL
+	ORDER BY score DESC, id
+	LIMIT ?`
+	rows, err := m.conn().QueryContext(ctx, stmt, query, query, limit)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("Error getting rows affected: %s", err.Error())
+	terms := searchTerms(query)
+	result := []models.SearchResult{}
+	for rows.Next() {
+		var row *models.Product
+		var score float64
+		row, err = scanProduct(rows, &score)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, newSearchResult(row, terms, score))
 	}
-	if rowsAffected == 0 {
-		return &NotFoundError{Operation: fmt.Sprintf("Maria.DeleteProduct(%d)", id)}
+	if err = rows.Err(); err != nil {
+		return nil, err
 	}
-	return nil
-}
-
-func (m Maria) Close() error {
-	return m.DB.Close()
+	return result, nil
 }
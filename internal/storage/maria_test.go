@@ -0,0 +1,68 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+)
+
+// Returns a SQLite database holding a single product with name, to stand in for a MariaDB database.
+// The SQL that Maria reads products with is also understood by SQLite.
+func newProductDB(t *testing.T, name string) *sql.DB {
+	t.Helper()
+
+	ctx := context.Background()
+	db, err := storage.OpenSQLite(ctx, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := storage.NewSQLite(ctx, db, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.CreateProduct(ctx, &models.CreateProductRequest{Name: name, Price: models.NewMoney(1000, "")}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// Tests that product reads are routed to the replicas unless they must see the latest writes.
+func TestMaria_ReplicaRouting(t *testing.T) {
+	ctx := context.Background()
+	primary := newProductDB(t, "Primary")
+	replica := newProductDB(t, "Replica")
+	m := storage.NewMariaWithReplicas(primary, []*sql.DB{replica})
+	t.Cleanup(func() { m.Close() })
+
+	readFrom := func(ctx context.Context, s storage.Storage) string {
+		product, err := s.GetProduct(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return product.Name
+	}
+	checkEqual(t, readFrom(ctx, m), "Replica", "Get Product")
+	page, err := m.GetProducts(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, page.Products[0].Name, "Replica", "Get Products")
+	checkEqual(t, readFrom(storage.ReadPrimary(ctx), m), "Primary", "Get Product From Primary")
+
+	// A write does not route the reads of other callers to the primary, which only those that made it need.
+	if err = m.SetExchangeRate(ctx, models.ExchangeRate{Currency: "USD", Rate: "0.65"}); err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, readFrom(ctx, m), "Replica", "Get Product After Write")
+
+	err = m.WithTx(ctx, func(tx storage.Storage) error {
+		checkEqual(t, readFrom(ctx, tx), "Primary", "Get Product In Transaction")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,1445 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+)
+
+// Memory is an implementation of the Storage interface that keeps products in memory.
+// It is safe for concurrent use, and never shares its products with callers: products are copied in and out.
+// Each method fails with the context error if ctx is already done, mirroring a cancelled database query.
+//
+// If Memory has a snapshot path, it is loaded from that JSON file when created and saved to it when closed.
+type Memory struct {
+	mu   sync.RWMutex
+	data memoryData
+	// snapshotPath is the file that the data is persisted to. It is empty if the data is not persisted.
+	snapshotPath string
+
+	// inTx is whether the store is the view of a transaction (see WithTx).
+	inTx bool
+	// shared is whether the slices of data are shared with the store that the transaction is a view of.
+	// They are copied before the first write (see writable), so that the store is unchanged if the transaction fails.
+	shared bool
+}
+
+// memoryData is the state of a Memory store, which is also the format of its snapshots.
+type memoryData struct {
+	// NextID is the ID of the next product to be created. IDs are never reused, even after a product is deleted.
+	NextID int `json:"next_id"`
+	// Products is ordered by ascending ID.
+	Products []models.Product `json:"products"`
+	// History is every change made to the products, ordered by ascending ID. The ID of each change is its index plus one.
+	History []models.ProductChange `json:"history"`
+	// Reservations is every reservation of the products, ordered by ascending ID.
+	Reservations []models.Reservation `json:"reservations"`
+	// LastReservationID is the ID of the most recently created reservation.
+	LastReservationID int `json:"last_reservation_id"`
+	// Prices is every price set for the products, ordered by product ID and then currency.
+	Prices []memoryPrice `json:"prices"`
+	// ExchangeRates is every exchange rate, ordered by currency.
+	ExchangeRates []models.ExchangeRate `json:"exchange_rates"`
+	// Variants is every variant of the products, ordered by ascending ID.
+	Variants []models.Variant `json:"variants"`
+	// LastVariantID is the ID of the most recently created variant.
+	LastVariantID int `json:"last_variant_id"`
+	// Categories is every category, ordered by ascending ID.
+	Categories []models.Category `json:"categories"`
+	// LastCategoryID is the ID of the most recently created category.
+	LastCategoryID int `json:"last_category_id"`
+	// ProductCategories is every assignment of a product to a category, ordered by product ID and then category ID.
+	ProductCategories []memoryProductCategory `json:"product_categories"`
+	// ProductTags is every tag of the products, ordered by product ID and then tag.
+	ProductTags []memoryProductTag `json:"product_tags"`
+	// ProductAttributes is every attribute of the products, ordered by product ID and then name.
+	ProductAttributes []memoryProductAttribute `json:"product_attributes"`
+	// Images is the metadata of every image of the products, ordered by ascending ID.
+	Images []memoryImage `json:"images"`
+	// LastImageID is the ID of the most recently created image.
+	LastImageID int `json:"last_image_id"`
+}
+
+// memoryImage is the metadata of an image of a product. The key of the image is not encoded with the rest of it,
+// so it is encoded separately in snapshots.
+type memoryImage struct {
+	models.Image
+	BlobKey string `json:"blob_key"`
+}
+
+// memoryProductCategory is the assignment of a product to a category.
+type memoryProductCategory struct {
+	ProductID  int `json:"product_id"`
+	CategoryID int `json:"category_id"`
+}
+
+// memoryProductTag is a tag of a product.
+type memoryProductTag struct {
+	ProductID int    `json:"product_id"`
+	Tag       string `json:"tag"`
+}
+
+// memoryProductAttribute is an attribute of a product, with its value in the text form of models.EncodeAttribute.
+type memoryProductAttribute struct {
+	ProductID int                  `json:"product_id"`
+	Name      string               `json:"name"`
+	Type      models.AttributeType `json:"type"`
+	Value     string               `json:"value"`
+}
+
+// memoryPrice is the price of a product in a currency other than the DefaultCurrency.
+type memoryPrice struct {
+	ProductID int          `json:"product_id"`
+	Price     models.Money `json:"price"`
+}
+
+// memoryPriceJSON has the fields of a memoryPrice without its JSON methods.
+type memoryPriceJSON memoryPrice
+
+// MarshalJSON encodes p with the currency of its price in the "currency" field, as models.Product does.
+func (p memoryPrice) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		memoryPriceJSON
+		Currency string `json:"currency"`
+	}{memoryPriceJSON(p), p.Price.Currency})
+}
+
+// UnmarshalJSON decodes p from its encoding by MarshalJSON.
+func (p *memoryPrice) UnmarshalJSON(data []byte) error {
+	v := struct {
+		*memoryPriceJSON
+		Currency string `json:"currency"`
+	}{memoryPriceJSON: (*memoryPriceJSON)(p)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	p.Price.Currency = v.Currency
+	return nil
+}
+
+// clone returns a copy of d that shares none of its slices.
+// The products and variants in the slices are never modified through pointers, so they are not copied.
+func (d memoryData) clone() memoryData {
+	return memoryData{
+		NextID:            d.NextID,
+		Products:          append([]models.Product{}, d.Products...),
+		History:           append([]models.ProductChange{}, d.History...),
+		Reservations:      append([]models.Reservation{}, d.Reservations...),
+		LastReservationID: d.LastReservationID,
+		Prices:            append([]memoryPrice{}, d.Prices...),
+		ExchangeRates:     append([]models.ExchangeRate{}, d.ExchangeRates...),
+		Variants:          append([]models.Variant{}, d.Variants...),
+		LastVariantID:     d.LastVariantID,
+		Categories:        append([]models.Category{}, d.Categories...),
+		LastCategoryID:    d.LastCategoryID,
+		ProductCategories: append([]memoryProductCategory{}, d.ProductCategories...),
+		ProductTags:       append([]memoryProductTag{}, d.ProductTags...),
+		ProductAttributes: append([]memoryProductAttribute{}, d.ProductAttributes...),
+		Images:            append([]memoryImage{}, d.Images...),
+		LastImageID:       d.LastImageID,
+	}
+}
+
+// NewMemory returns an empty Memory store that is not persisted.
+func NewMemory() *Memory {
+	return &Memory{
+		data: memoryData{
+			NextID:            1,
+			Products:          []models.Product{},
+			History:           []models.ProductChange{},
+			Reservations:      []models.Reservation{},
+			Prices:            []memoryPrice{},
+			ExchangeRates:     []models.ExchangeRate{},
+			Variants:          []models.Variant{},
+			Categories:        []models.Category{},
+			ProductCategories: []memoryProductCategory{},
+			ProductTags:       []memoryProductTag{},
+			ProductAttributes: []memoryProductAttribute{},
+			Images:            []memoryImage{},
+		},
+	}
+}
+
+// NewMemoryWithSnapshot returns a Memory store that is persisted to the JSON file at path.
+// The store is loaded from the file if it exists, and is otherwise empty.
+func NewMemoryWithSnapshot(path string) (*Memory, error) {
+	m := NewMemory()
+	m.snapshotPath = path
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &m.data); err != nil {
+		return nil, fmt.Errorf("Error decoding snapshot %q: %w", path, err)
+	}
+	return m, nil
+}
+
+// index returns the index of the product with id, or -1 if there is none.
+// The caller must hold the lock.
+func (m *Memory) index(id int) int {
+	i := sort.Search(len(m.data.Products), func(i int) bool {
+		return m.data.Products[i].ID >= id
+	})
+	if i < len(m.data.Products) && m.data.Products[i].ID == id {
+		return i
+	}
+	return -1
+}
+
+// GetProduct returns a product by id, unless it is in the trash.
+func (m *Memory) GetProduct(ctx context.Context, id int) (*models.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i := m.index(id)
+	if i < 0 || m.data.Products[i].DeletedAt != nil {
+		return nil, &NotFoundError{Operation: fmt.Sprintf("Memory.GetProduct(%d)", id)}
+	}
+	product := m.data.Products[i]
+	product.SetVariants(m.variantsOf(id))
+	product.CategoryIDs = m.categoriesOf(id)
+	product.Tags = m.tagsOf(id)
+	product.Attributes = m.attributesOf(id)
+	return &product, nil
+}
+
+// GetProductBySlug returns a product by its slug, unless it is in the trash.
+func (m *Memory) GetProductBySlug(ctx context.Context, slug string) (*models.Product, error) {
+	return m.getProductBy(ctx, fmt.Sprintf("Memory.GetProductBySlug(%q)", slug), func(p *models.Product) bool {
+		return p.Slug == slug
+	})
+}
+
+// GetProductBySKU returns a product by its SKU, unless it is in the trash.
+func (m *Memory) GetProductBySKU(ctx context.Context, sku string) (*models.Product, error) {
+	return m.getProductBy(ctx, fmt.Sprintf("Memory.GetProductBySKU(%q)", sku), func(p *models.Product) bool {
+		return sku != "" && p.SKU == sku
+	})
+}
+
+// getProductBy returns the product that match returns true for, unless it is in the trash.
+func (m *Memory) getProductBy(ctx context.Context, operation string, match func(p *models.Product) bool) (*models.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	id := 0
+	for i := range m.data.Products {
+		if m.data.Products[i].DeletedAt == nil && match(&m.data.Products[i]) {
+			id = m.data.Products[i].ID
+			break
+		}
+	}
+	m.mu.RUnlock()
+	if id == 0 {
+		return nil, &NotFoundError{Operation: operation}
+	}
+	return m.GetProduct(ctx, id)
+}
+
+// GetProducts returns a page of the products that match the filters of query, in the order of its sort.
+func (m *Memory) GetProducts(ctx context.Context, query *ProductQuery) (*ProductPage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	after, err := query.after()
+	if err != nil {
+		return nil, err
+	}
+	limit := query.limit()
+	var order ProductSort
+	if query != nil {
+		order = query.Sort
+	}
+
+	m.mu.RLock()
+	var products []models.Product
+	for _, product := range m.data.Products {
+		product.CategoryIDs = m.categoriesOf(product.ID)
+		product.Tags = m.tagsOf(product.ID)
+		product.Attributes = m.attributesOf(product.ID)
+		if query.matches(&product) {
+			product.SetVariants(m.variantsOf(product.ID))
+			products = append(products, product)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.SliceStable(products, func(i, j int) bool {
+		return compareProducts(&products[i], &products[j], order) < 0
+	})
+
+	result := &ProductPage{Products: []models.Product{}}
+	for i := range products {
+		if after.ID != 0 && compareProducts(&products[i], after.product(order.field()), order) <= 0 {
+			continue
+		}
+		if len(result.Products) == limit {
+			result.NextCursor = newCursor(&result.Products[limit-1], order).encode()
+			break
+		}
+		result.Products = append(result.Products, products[i])
+	}
+	return result, nil
+}
+
+// SearchProducts returns up to limit products that match the search query, ordered by descending relevance.
+// Relevance is naively scored by counting the occurrences of each query term (see scoreProduct).
+func (m *Memory) SearchProducts(ctx context.Context, query string, limit int) ([]models.SearchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	terms := searchTerms(query)
+	result := []models.SearchResult{}
+	for _, product := range m.data.Products {
+		if product.DeletedAt != nil {
+			continue
+		}
+		if score := scoreProduct(&product, terms); score > 0 {
+			result = append(result, newSearchResult(&product, terms, score))
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Score > result[j].Score
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// CreateProduct creates a product.
+func (m *Memory) CreateProduct(ctx context.Context, product *models.CreateProductRequest) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.writable()
+
+	p := product.ToProduct(m.data.NextID)
+	if p.Slug == "" {
+		p.Slug = models.UniqueSlug(models.ProductSlug(p.Name), func(slug string) bool {
+			return m.productWith(0, "", slug) != ""
+		})
+	}
+	if field := m.productWith(0, p.SKU, p.Slug); field != "" {
+		return 0, productConflict("Memory.CreateProduct", field, p)
+	}
+	m.data.NextID++
+	m.data.Products = append(m.data.Products, *p)
+	m.recordChange(newProductChange(ctx, models.ChangeCreate, p.ID, nil, p))
+	return p.ID, nil
+}
+
+// UpdateProduct updates a product if it has the expected version, and increments its version.
+// Products in the trash cannot be updated.
+func (m *Memory) UpdateProduct(ctx context.Context, product *models.Product) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	operation := fmt.Sprintf("Memory.UpdateProduct(%d)", product.ID)
+	i, before := m.find(product.ID)
+	if err := checkVersion(operation, before, product.Version, false); err != nil {
+		return err
+	}
+	// The product is stored as a copy, so that only its version is set on the caller's product.
+	stored := *product
+	// An empty slug keeps the current one, so that the URLs of the product do not change.
+	if stored.Slug == "" {
+		stored.Slug = before.Slug
+	}
+	if field := m.productWith(stored.ID, stored.SKU, stored.Slug); field != "" {
+		return productConflict(operation, field, &stored)
+	}
+	m.writable()
+	stored.Version = before.Version + 1
+	stored.DeletedAt = nil
+	// The variants, categories, tags and attributes of the product are stored separately,
+	// and cannot be changed by updating the product.
+	stored.SetVariants(nil)
+	stored.CategoryIDs = nil
+	stored.Tags = nil
+	stored.Attributes = nil
+	m.data.Products[i] = stored
+	m.recordChange(newProductChange(ctx, models.ChangeUpdate, stored.ID, before, &stored))
+	product.Version = stored.Version
+	return nil
+}
+
+// DeleteProduct moves a product to the trash if it has the expected version, and increments its version.
+func (m *Memory) DeleteProduct(ctx context.Context, id int, version int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, before := m.find(id)
+	if err := checkVersion(fmt.Sprintf("Memory.DeleteProduct(%d)", id), before, version, false); err != nil {
+		return err
+	}
+	m.writable()
+	deletedAt := now()
+	p := &m.data.Products[i]
+	p.DeletedAt = &deletedAt
+	p.Version++
+	m.recordChange(newProductChange(ctx, models.ChangeDelete, id, before, p))
+	return nil
+}
+
+// RestoreProduct restores a product from the trash, and increments its version.
+func (m *Memory) RestoreProduct(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, before := m.find(id)
+	if before == nil || before.DeletedAt == nil {
+		return &NotFoundError{Operation: fmt.Sprintf("Memory.RestoreProduct(%d)", id)}
+	}
+	m.writable()
+	p := &m.data.Products[i]
+	p.DeletedAt = nil
+	p.Version++
+	m.recordChange(newProductChange(ctx, models.ChangeRestore, id, before, p))
+	return nil
+}
+
+// PurgeProduct permanently deletes a product if it has the expected version, whether or not it is in the trash.
+func (m *Memory) PurgeProduct(ctx context.Context, id int, version int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, before := m.find(id)
+	if err := checkVersion(fmt.Sprintf("Memory.PurgeProduct(%d)", id), before, version, true); err != nil {
+		return err
+	}
+	m.writable()
+	m.data.Products = append(m.data.Products[:i], m.data.Products[i+1:]...)
+	// The reservations of the product are deleted with it, as they are by the foreign key of the SQL databases.
+	reservations := m.data.Reservations[:0]
+	for _, r := range m.data.Reservations {
+		if r.ProductID != id {
+			reservations = append(reservations, r)
+		}
+	}
+	m.data.Reservations = reservations
+	prices := m.data.Prices[:0]
+	for _, p := range m.data.Prices {
+		if p.ProductID != id {
+			prices = append(prices, p)
+		}
+	}
+	m.data.Prices = prices
+	variants := m.data.Variants[:0]
+	for _, v := range m.data.Variants {
+		if v.ProductID != id {
+			variants = append(variants, v)
+		}
+	}
+	m.data.Variants = variants
+	m.data.ProductCategories = slices.DeleteFunc(m.data.ProductCategories, func(pc memoryProductCategory) bool {
+		return pc.ProductID == id
+	})
+	m.data.ProductTags = slices.DeleteFunc(m.data.ProductTags, func(pt memoryProductTag) bool {
+		return pt.ProductID == id
+	})
+	m.data.ProductAttributes = slices.DeleteFunc(m.data.ProductAttributes, func(pa memoryProductAttribute) bool {
+		return pa.ProductID == id
+	})
+	m.data.Images = slices.DeleteFunc(m.data.Images, func(image memoryImage) bool {
+		return image.ProductID == id
+	})
+	m.recordChange(newProductChange(ctx, models.ChangePurge, id, before, nil))
+	return nil
+}
+
+// ReserveStock takes quantity from the stock of a product and holds it in a reservation that expires after ttl.
+// The expired reservations of the product are released first, returning their stock to it.
+func (m *Memory) ReserveStock(ctx context.Context, productID, quantity int, ttl time.Duration) (*models.Reservation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	operation := fmt.Sprintf("Memory.ReserveStock(%d)", productID)
+	i, before := m.find(productID)
+	if before == nil || before.DeletedAt != nil {
+		return nil, &NotFoundError{Operation: operation}
+	}
+	createdAt := now()
+	expired := 0
+	for _, r := range m.data.Reservations {
+		if r.ProductID == productID && r.Status == models.ReservationPending && !r.ExpiresAt.After(createdAt) {
+			expired += r.Quantity
+		}
+	}
+	if before.StockQuantity+expired < quantity {
+		return nil, &InsufficientStockError{Operation: operation, Requested: quantity, Available: before.StockQuantity + expired}
+	}
+
+	m.writable()
+	for j := range m.data.Reservations {
+		r := &m.data.Reservations[j]
+		if r.ProductID == productID && r.Status == models.ReservationPending && !r.ExpiresAt.After(createdAt) {
+			r.Status = models.ReservationExpired
+		}
+	}
+	p := &m.data.Products[i]
+	p.StockQuantity += expired - quantity
+	p.Version++
+	m.data.LastReservationID++
+	reservation := models.Reservation{
+		ID:        m.data.LastReservationID,
+		ProductID: productID,
+		Quantity:  quantity,
+		Status:    models.ReservationPending,
+		CreatedAt: createdAt,
+		ExpiresAt: createdAt.Add(ttl),
+	}
+	m.data.Reservations = append(m.data.Reservations, reservation)
+	m.recordChange(newProductChange(ctx, models.ChangeReserve, productID, before, p))
+	return &reservation, nil
+}
+
+// ReleaseStock releases a pending reservation of a product, returning its stock to the product.
+func (m *Memory) ReleaseStock(ctx context.Context, productID, reservationID int) error {
+	operation := fmt.Sprintf("Memory.ReleaseStock(%d)", reservationID)
+	return m.finishReservation(ctx, operation, productID, reservationID, models.ReservationReleased)
+}
+
+// CommitReservation commits a pending reservation of a product, so that its stock is never returned to the product.
+func (m *Memory) CommitReservation(ctx context.Context, productID, reservationID int) error {
+	operation := fmt.Sprintf("Memory.CommitReservation(%d)", reservationID)
+	return m.finishReservation(ctx, operation, productID, reservationID, models.ReservationCommitted)
+}
+
+// finishReservation changes a pending reservation of a product to status, returning its stock to the product
+// unless it is committed. If the reservation has expired, it is marked as expired (and its stock returned)
+// and a ReservationStateError is returned instead.
+func (m *Memory) finishReservation(
+	ctx context.Context, operation string, productID, reservationID int, status models.ReservationStatus,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j := sort.Search(len(m.data.Reservations), func(j int) bool {
+		return m.data.Reservations[j].ID >= reservationID
+	})
+	if j == len(m.data.Reservations) || m.data.Reservations[j].ID != reservationID ||
+		m.data.Reservations[j].ProductID != productID {
+		return &NotFoundError{Operation: operation}
+	}
+	if current := m.data.Reservations[j].Status; current != models.ReservationPending {
+		return &ReservationStateError{Operation: operation, Status: current}
+	}
+	var stateErr error
+	if !m.data.Reservations[j].ExpiresAt.After(now()) {
+		status = models.ReservationExpired
+		stateErr = &ReservationStateError{Operation: operation, Status: status}
+	}
+
+	m.writable()
+	r := &m.data.Reservations[j]
+	r.Status = status
+	if status != models.ReservationCommitted {
+		i, before := m.find(productID)
+		p := &m.data.Products[i]
+		p.StockQuantity += r.Quantity
+		p.Version++
+		m.recordChange(newProductChange(ctx, models.ChangeRelease, productID, before, p))
+	}
+	return stateErr
+}
+
+// WithTx calls fn with a view of m in a transaction, which replaces the data of m if fn succeeds.
+// If fn fails, the view is discarded, leaving m as it was. m is locked until the transaction is done.
+func (m *Memory) WithTx(ctx context.Context, fn func(tx Storage) error) error {
+	if m.inTx {
+		return fn(m)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tx := &Memory{data: m.data, inTx: true, shared: true}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	// The commit fails if the context is done, as it would for a database.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.data = tx.data
+	return nil
+}
+
+// writable copies the data of m before it is first modified in a transaction. The caller must hold the lock.
+func (m *Memory) writable() {
+	if m.shared {
+		m.data = m.data.clone()
+		m.shared = false
+	}
+}
+
+// productWith returns "sku" if a product other than the one with exceptID has sku, or else "slug" if one has slug,
+// and otherwise returns "". Products in the trash are included, and empty values are never used.
+// The caller must hold the lock.
+func (m *Memory) productWith(exceptID int, sku, slug string) string {
+	result := ""
+	for i := range m.data.Products {
+		p := &m.data.Products[i]
+		if p.ID == exceptID {
+			continue
+		}
+		if sku != "" && p.SKU == sku {
+			return "sku"
+		}
+		if slug != "" && p.Slug == slug {
+			result = "slug"
+		}
+	}
+	return result
+}
+
+// productConflict returns the ConflictError of operation for the duplicated field of product.
+func productConflict(operation, field string, product *models.Product) error {
+	value := product.Slug
+	if field == "sku" {
+		value = product.SKU
+	}
+	return &ConflictError{Operation: operation, Field: field, Value: value}
+}
+
+// find returns the index of the product with id and a copy of it, even if it is in the trash.
+// A nil product is returned if there is none. The caller must hold the lock.
+func (m *Memory) find(id int) (int, *models.Product) {
+	i := m.index(id)
+	if i < 0 {
+		return i, nil
+	}
+	product := m.data.Products[i]
+	return i, &product
+}
+
+// recordChange appends change to the history, copying the products it refers to. The caller must hold the lock.
+func (m *Memory) recordChange(change *models.ProductChange) {
+	change.ID = len(m.data.History) + 1
+	change.Before = copyProduct(change.Before)
+	change.After = copyProduct(change.After)
+	m.data.History = append(m.data.History, *change)
+}
+
+// copyProduct returns a copy of p that shares none of its variants, or nil if p is nil.
+func copyProduct(p *models.Product) *models.Product {
+	if p == nil {
+		return nil
+	}
+	result := *p
+	result.CategoryIDs = slices.Clone(p.CategoryIDs)
+	result.Tags = slices.Clone(p.Tags)
+	result.Attributes = maps.Clone(p.Attributes)
+	if p.Variants != nil {
+		result.Variants = make([]models.Variant, len(p.Variants))
+		for i := range p.Variants {
+			result.Variants[i] = p.Variants[i].Copy()
+		}
+	}
+	return &result
+}
+
+// GetProductHistory returns a page of the changes made to the product with id, from the most recent.
+// A NotFoundError is returned if the product has never existed.
+func (m *Memory) GetProductHistory(ctx context.Context, id int, query *HistoryQuery) (*HistoryPage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	after, err := query.after()
+	if err != nil {
+		return nil, err
+	}
+	limit := query.limit()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	exists := m.index(id) >= 0
+	result := &HistoryPage{Changes: []models.ProductChange{}}
+	for i := len(m.data.History) - 1; i >= 0; i-- {
+		change := m.data.History[i]
+		if change.ProductID != id {
+			continue
+		}
+		exists = true
+		if !query.matches(&change, after) {
+			continue
+		}
+		if len(result.Changes) == limit {
+			result.NextCursor = newHistoryCursor(&result.Changes[limit-1])
+			break
+		}
+		change.Before = copyProduct(change.Before)
+		change.After = copyProduct(change.After)
+		result.Changes = append(result.Changes, change)
+	}
+	if !exists {
+		return nil, &NotFoundError{Operation: fmt.Sprintf("Memory.GetProductHistory(%d)", id)}
+	}
+	return result, nil
+}
+
+// GetPrices returns the prices set for a product in currencies other than the DefaultCurrency, ordered by currency.
+func (m *Memory) GetPrices(ctx context.Context, productID int) ([]models.Money, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, p := m.find(productID); p == nil || p.DeletedAt != nil {
+		return nil, &NotFoundError{Operation: fmt.Sprintf("Memory.GetPrices(%d)", productID)}
+	}
+	result := []models.Money{}
+	for _, p := range m.data.Prices {
+		if p.ProductID == productID {
+			result = append(result, p.Price)
+		}
+	}
+	return result, nil
+}
+
+// SetPrice sets the price of a product in the currency of price, replacing any price it had in that currency.
+func (m *Memory) SetPrice(ctx context.Context, productID int, price models.Money) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, p := m.find(productID); p == nil || p.DeletedAt != nil {
+		return &NotFoundError{Operation: fmt.Sprintf("Memory.SetPrice(%d)", productID)}
+	}
+	m.writable()
+	i, found := m.priceIndex(productID, price.Currency)
+	if found {
+		m.data.Prices[i].Price = price
+		return nil
+	}
+	m.data.Prices = append(m.data.Prices[:i], append([]memoryPrice{{productID, price}}, m.data.Prices[i:]...)...)
+	return nil
+}
+
+// DeletePrice deletes the price of a product in currency, so that its price is converted to currency instead.
+func (m *Memory) DeletePrice(ctx context.Context, productID int, currency string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, p := m.find(productID)
+	i, found := m.priceIndex(productID, currency)
+	if p == nil || p.DeletedAt != nil || !found {
+		return &NotFoundError{Operation: fmt.Sprintf("Memory.DeletePrice(%d, %s)", productID, currency)}
+	}
+	m.writable()
+	m.data.Prices = append(m.data.Prices[:i], m.data.Prices[i+1:]...)
+	return nil
+}
+
+// priceIndex returns the index of the price of the product with productID in currency, and whether there is one.
+// If there is not, the index is where it would be inserted. The caller must hold the lock.
+func (m *Memory) priceIndex(productID int, currency string) (int, bool) {
+	i := sort.Search(len(m.data.Prices), func(i int) bool {
+		p := m.data.Prices[i]
+		return p.ProductID > productID || (p.ProductID == productID && p.Price.Currency >= currency)
+	})
+	found := i < len(m.data.Prices) && m.data.Prices[i].ProductID == productID && m.data.Prices[i].Price.Currency == currency
+	return i, found
+}
+
+// GetExchangeRates returns every exchange rate, ordered by currency.
+func (m *Memory) GetExchangeRates(ctx context.Context) ([]models.ExchangeRate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]models.ExchangeRate{}, m.data.ExchangeRates...), nil
+}
+
+// SetExchangeRate sets the exchange rate of a currency, replacing the rate it had.
+func (m *Memory) SetExchangeRate(ctx context.Context, rate models.ExchangeRate) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.writable()
+
+	i, found := m.rateIndex(rate.Currency)
+	if found {
+		m.data.ExchangeRates[i] = rate
+		return nil
+	}
+	m.data.ExchangeRates = append(m.data.ExchangeRates[:i], append([]models.ExchangeRate{rate}, m.data.ExchangeRates[i:]...)...)
+	return nil
+}
+
+// rateIndex returns the index of the exchange rate of currency, and whether there is one.
+// If there is not, the index is where it would be inserted. The caller must hold the lock.
+func (m *Memory) rateIndex(currency string) (int, bool) {
+	i := sort.Search(len(m.data.ExchangeRates), func(i int) bool {
+		return m.data.ExchangeRates[i].Currency >= currency
+	})
+	return i, i < len(m.data.ExchangeRates) && m.data.ExchangeRates[i].Currency == currency
+}
+
+// GetPriceList returns the exchange rate of currency, and the prices set in currency for the products with productIDs.
+func (m *Memory) GetPriceList(ctx context.Context, currency string, productIDs []int) (*PriceList, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := &PriceList{Currency: currency, Overrides: make(map[int]models.Money)}
+	if i, found := m.rateIndex(currency); found {
+		result.Rate = m.data.ExchangeRates[i].Rat()
+	}
+	for _, id := range productIDs {
+		if i, found := m.priceIndex(id, currency); found {
+			result.Overrides[id] = m.data.Prices[i].Price
+		}
+	}
+	return result, nil
+}
+
+// GetVariants returns the variants of a product, ordered by ID.
+func (m *Memory) GetVariants(ctx context.Context, productID int) ([]models.Variant, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, p := m.find(productID); p == nil || p.DeletedAt != nil {
+		return nil, &NotFoundError{Operation: fmt.Sprintf("Memory.GetVariants(%d)", productID)}
+	}
+	result := m.variantsOf(productID)
+	if result == nil {
+		return []models.Variant{}, nil
+	}
+	return result, nil
+}
+
+// GetVariant returns a variant of a product by id.
+func (m *Memory) GetVariant(ctx context.Context, productID, variantID int) (*models.Variant, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i, err := m.variantIndex(fmt.Sprintf("Memory.GetVariant(%d)", variantID), productID, variantID)
+	if err != nil {
+		return nil, err
+	}
+	result := m.data.Variants[i].Copy()
+	return &result, nil
+}
+
+// CreateVariant creates a variant of a product.
+func (m *Memory) CreateVariant(ctx context.Context, productID int, variant *models.VariantRequest) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	operation := fmt.Sprintf("Memory.CreateVariant(%d)", productID)
+	if _, p := m.find(productID); p == nil || p.DeletedAt != nil {
+		return 0, &NotFoundError{Operation: operation}
+	}
+	if m.skuUsed(variant.SKU, 0) {
+		return 0, &ConflictError{Operation: operation, Field: "sku", Value: variant.SKU}
+	}
+	m.writable()
+	m.data.LastVariantID++
+	v := variant.ToVariant(productID, m.data.LastVariantID)
+	m.data.Variants = append(m.data.Variants, *v)
+	return v.ID, nil
+}
+
+// UpdateVariant updates a variant of a product.
+func (m *Memory) UpdateVariant(ctx context.Context, variant *models.Variant) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	operation := fmt.Sprintf("Memory.UpdateVariant(%d)", variant.ID)
+	i, err := m.variantIndex(operation, variant.ProductID, variant.ID)
+	if err != nil {
+		return err
+	}
+	if m.skuUsed(variant.SKU, variant.ID) {
+		return &ConflictError{Operation: operation, Field: "sku", Value: variant.SKU}
+	}
+	m.writable()
+	m.data.Variants[i] = variant.Copy()
+	return nil
+}
+
+// DeleteVariant deletes a variant of a product by id.
+func (m *Memory) DeleteVariant(ctx context.Context, productID, variantID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, err := m.variantIndex(fmt.Sprintf("Memory.DeleteVariant(%d)", variantID), productID, variantID)
+	if err != nil {
+		return err
+	}
+	m.writable()
+	m.data.Variants = append(m.data.Variants[:i], m.data.Variants[i+1:]...)
+	return nil
+}
+
+// variantsOf returns copies of the variants of the product with productID, or nil if it has none.
+// The caller must hold the lock.
+func (m *Memory) variantsOf(productID int) []models.Variant {
+	var result []models.Variant
+	for i := range m.data.Variants {
+		if m.data.Variants[i].ProductID == productID {
+			result = append(result, m.data.Variants[i].Copy())
+		}
+	}
+	return result
+}
+
+// variantIndex returns the index of the variant with variantID of the product with productID.
+// A NotFoundError is returned if there is none, or if the product is in the trash. The caller must hold the lock.
+func (m *Memory) variantIndex(operation string, productID, variantID int) (int, error) {
+	if _, p := m.find(productID); p == nil || p.DeletedAt != nil {
+		return 0, &NotFoundError{Operation: operation}
+	}
+	i := sort.Search(len(m.data.Variants), func(i int) bool {
+		return m.data.Variants[i].ID >= variantID
+	})
+	if i == len(m.data.Variants) || m.data.Variants[i].ID != variantID || m.data.Variants[i].ProductID != productID {
+		return 0, &NotFoundError{Operation: operation}
+	}
+	return i, nil
+}
+
+// skuUsed returns whether a variant other than the one with exceptID has sku. The caller must hold the lock.
+func (m *Memory) skuUsed(sku string, exceptID int) bool {
+	for i := range m.data.Variants {
+		if m.data.Variants[i].SKU == sku && m.data.Variants[i].ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetCategories returns every category, ordered by position and then ID.
+func (m *Memory) GetCategories(ctx context.Context) ([]models.Category, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]models.Category, len(m.data.Categories))
+	for i, c := range m.data.Categories {
+		result[i] = copyCategory(c)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Position < result[j].Position
+	})
+	return result, nil
+}
+
+// GetCategory returns a category by id.
+func (m *Memory) GetCategory(ctx context.Context, id int) (*models.Category, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i := m.categoryIndex(id)
+	if i < 0 {
+		return nil, &NotFoundError{Operation: fmt.Sprintf("Memory.GetCategory(%d)", id)}
+	}
+	result := copyCategory(m.data.Categories[i])
+	return &result, nil
+}
+
+// CreateCategory creates a category.
+func (m *Memory) CreateCategory(ctx context.Context, category *models.CategoryRequest) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkParent(0, category.ParentID); err != nil {
+		return 0, err
+	}
+	if m.slugUsed(category.Slug, 0) {
+		operation := fmt.Sprintf("Memory.CreateCategory(%q)", category.Slug)
+		return 0, &ConflictError{Operation: operation, Field: "slug", Value: category.Slug}
+	}
+	m.writable()
+	m.data.LastCategoryID++
+	c := category.ToCategory(m.data.LastCategoryID)
+	m.data.Categories = append(m.data.Categories, copyCategory(*c))
+	return c.ID, nil
+}
+
+// UpdateCategory updates a category, which may be moved to another parent.
+func (m *Memory) UpdateCategory(ctx context.Context, category *models.Category) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	operation := fmt.Sprintf("Memory.UpdateCategory(%d)", category.ID)
+	i := m.categoryIndex(category.ID)
+	if i < 0 {
+		return &NotFoundError{Operation: operation}
+	}
+	if err := m.checkParent(category.ID, category.ParentID); err != nil {
+		return err
+	}
+	if m.slugUsed(category.Slug, category.ID) {
+		return &ConflictError{Operation: operation, Field: "slug", Value: category.Slug}
+	}
+	m.writable()
+	m.data.Categories[i] = copyCategory(*category)
+	return nil
+}
+
+// DeleteCategory deletes a category by id. Its children are moved to its parent.
+func (m *Memory) DeleteCategory(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.categoryIndex(id)
+	if i < 0 {
+		return &NotFoundError{Operation: fmt.Sprintf("Memory.DeleteCategory(%d)", id)}
+	}
+	m.writable()
+	parentID := m.data.Categories[i].ParentID
+	m.data.Categories = append(m.data.Categories[:i], m.data.Categories[i+1:]...)
+	for i := range m.data.Categories {
+		if c := &m.data.Categories[i]; c.ParentID != nil && *c.ParentID == id {
+			c.ParentID = parentID
+		}
+	}
+	m.data.ProductCategories = slices.DeleteFunc(m.data.ProductCategories, func(pc memoryProductCategory) bool {
+		return pc.CategoryID == id
+	})
+	return nil
+}
+
+// SetProductCategories replaces the categories that a product is assigned to.
+func (m *Memory) SetProductCategories(ctx context.Context, productID int, categoryIDs []int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, p := m.find(productID); p == nil || p.DeletedAt != nil {
+		return &NotFoundError{Operation: fmt.Sprintf("Memory.SetProductCategories(%d)", productID)}
+	}
+	for _, id := range categoryIDs {
+		if m.categoryIndex(id) < 0 {
+			return &UnknownCategoryError{ID: id}
+		}
+	}
+	m.writable()
+	assignments := slices.DeleteFunc(m.data.ProductCategories, func(pc memoryProductCategory) bool {
+		return pc.ProductID == productID
+	})
+	for _, id := range categoryIDs {
+		assignments = append(assignments, memoryProductCategory{ProductID: productID, CategoryID: id})
+	}
+	slices.SortFunc(assignments, func(a, b memoryProductCategory) int {
+		if a.ProductID != b.ProductID {
+			return a.ProductID - b.ProductID
+		}
+		return a.CategoryID - b.CategoryID
+	})
+	m.data.ProductCategories = slices.Compact(assignments)
+	return nil
+}
+
+// categoryIndex returns the index of the category with id, or -1 if there is none. The caller must hold the lock.
+func (m *Memory) categoryIndex(id int) int {
+	i, found := slices.BinarySearchFunc(m.data.Categories, id, func(c models.Category, id int) int {
+		return c.ID - id
+	})
+	if !found {
+		return -1
+	}
+	return i
+}
+
+// checkParent returns an UnknownCategoryError if parentID is not nil and the category does not exist,
+// or a CategoryCycleError if it is the category with id or one of its descendants. The caller must hold the lock.
+func (m *Memory) checkParent(id int, parentID *int) error {
+	if parentID == nil {
+		return nil
+	}
+	if m.categoryIndex(*parentID) < 0 {
+		return &UnknownCategoryError{ID: *parentID}
+	}
+	for ancestor := parentID; ancestor != nil; ancestor = m.data.Categories[m.categoryIndex(*ancestor)].ParentID {
+		if *ancestor == id {
+			return &CategoryCycleError{ID: id, ParentID: *parentID}
+		}
+	}
+	return nil
+}
+
+// slugUsed returns whether a category other than the one with exceptID has slug. The caller must hold the lock.
+func (m *Memory) slugUsed(slug string, exceptID int) bool {
+	for i := range m.data.Categories {
+		if m.data.Categories[i].Slug == slug && m.data.Categories[i].ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// categoriesOf returns the IDs of the categories of the product with productID in ascending order,
+// or nil if it has none. The caller must hold the lock.
+func (m *Memory) categoriesOf(productID int) []int {
+	var result []int
+	for _, pc := range m.data.ProductCategories {
+		if pc.ProductID == productID {
+			result = append(result, pc.CategoryID)
+		}
+	}
+	return result
+}
+
+// copyCategory returns a copy of c that shares none of its parent ID or children.
+func copyCategory(c models.Category) models.Category {
+	if c.ParentID != nil {
+		parentID := *c.ParentID
+		c.ParentID = &parentID
+	}
+	c.Children = nil
+	return c
+}
+
+// SetProductTags replaces the tags of a product.
+func (m *Memory) SetProductTags(ctx context.Context, productID int, tags []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, p := m.find(productID); p == nil || p.DeletedAt != nil {
+		return &NotFoundError{Operation: fmt.Sprintf("Memory.SetProductTags(%d)", productID)}
+	}
+	m.writable()
+	productTags := slices.DeleteFunc(m.data.ProductTags, func(pt memoryProductTag) bool {
+		return pt.ProductID == productID
+	})
+	for _, tag := range tags {
+		productTags = append(productTags, memoryProductTag{ProductID: productID, Tag: tag})
+	}
+	slices.SortFunc(productTags, func(a, b memoryProductTag) int {
+		if a.ProductID != b.ProductID {
+			return a.ProductID - b.ProductID
+		}
+		return strings.Compare(a.Tag, b.Tag)
+	})
+	m.data.ProductTags = slices.Compact(productTags)
+	return nil
+}
+
+// SetProductAttributes replaces the attributes of a product.
+func (m *Memory) SetProductAttributes(ctx context.Context, productID int, attributes map[string]any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	operation := fmt.Sprintf("Memory.SetProductAttributes(%d)", productID)
+	if _, p := m.find(productID); p == nil || p.DeletedAt != nil {
+		return &NotFoundError{Operation: operation}
+	}
+	encoded := make([]memoryProductAttribute, 0, len(attributes))
+	for name, value := range attributes {
+		t, text, err := models.EncodeAttribute(value)
+		if err != nil {
+			return fmt.Errorf("%s: attribute %q: %w", operation, name, err)
+		}
+		encoded = append(encoded, memoryProductAttribute{ProductID: productID, Name: name, Type: t, Value: text})
+	}
+	m.writable()
+	productAttributes := slices.DeleteFunc(m.data.ProductAttributes, func(pa memoryProductAttribute) bool {
+		return pa.ProductID == productID
+	})
+	productAttributes = append(productAttributes, encoded...)
+	slices.SortFunc(productAttributes, func(a, b memoryProductAttribute) int {
+		if a.ProductID != b.ProductID {
+			return a.ProductID - b.ProductID
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	m.data.ProductAttributes = productAttributes
+	return nil
+}
+
+// tagsOf returns the tags of the product with productID in ascending order, or nil if it has none.
+// The caller must hold the lock.
+func (m *Memory) tagsOf(productID int) []string {
+	var result []string
+	for _, pt := range m.data.ProductTags {
+		if pt.ProductID == productID {
+			result = append(result, pt.Tag)
+		}
+	}
+	return result
+}
+
+// attributesOf returns the attributes of the product with productID, or nil if it has none.
+// The caller must hold the lock.
+func (m *Memory) attributesOf(productID int) map[string]any {
+	var result map[string]any
+	for _, pa := range m.data.ProductAttributes {
+		if pa.ProductID != productID {
+			continue
+		}
+		// The attributes were encoded by SetProductAttributes, so they can always be decoded.
+		value, _ := models.DecodeAttribute(pa.Type, pa.Value)
+		if result == nil {
+			result = make(map[string]any)
+		}
+		result[pa.Name] = value
+	}
+	return result
+}
+
+// GetImages returns the images of a product, ordered by ID.
+func (m *Memory) GetImages(ctx context.Context, productID int) ([]models.Image, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, p := m.find(productID); p == nil || p.DeletedAt != nil {
+		return nil, &NotFoundError{Operation: fmt.Sprintf("Memory.GetImages(%d)", productID)}
+	}
+	result := []models.Image{}
+	for _, image := range m.data.Images {
+		if image.ProductID == productID {
+			result = append(result, image.image())
+		}
+	}
+	return result, nil
+}
+
+// GetImageKeys returns the keys of the images of a product, ordered by ID, even if it is in the trash.
+func (m *Memory) GetImageKeys(ctx context.Context, productID int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := []string{}
+	for _, image := range m.data.Images {
+		if image.ProductID == productID {
+			result = append(result, image.BlobKey)
+		}
+	}
+	return result, nil
+}
+
+// GetImage returns an image of a product by id.
+func (m *Memory) GetImage(ctx context.Context, productID, imageID int) (*models.Image, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i, err := m.imageIndex(fmt.Sprintf("Memory.GetImage(%d)", imageID), productID, imageID)
+	if err != nil {
+		return nil, err
+	}
+	result := m.data.Images[i].image()
+	return &result, nil
+}
+
+// CreateImage creates the metadata of an image of a product.
+func (m *Memory) CreateImage(ctx context.Context, image *models.Image) (*models.Image, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, p := m.find(image.ProductID); p == nil || p.DeletedAt != nil {
+		return nil, &NotFoundError{Operation: fmt.Sprintf("Memory.CreateImage(%d)", image.ProductID)}
+	}
+	m.writable()
+	m.data.LastImageID++
+	result := *image
+	result.ID = m.data.LastImageID
+	result.CreatedAt = now()
+	m.data.Images = append(m.data.Images, memoryImage{Image: result, BlobKey: result.Key})
+	return &result, nil
+}
+
+// DeleteImage deletes the metadata of an image of a product by id.
+func (m *Memory) DeleteImage(ctx context.Context, productID, imageID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, err := m.imageIndex(fmt.Sprintf("Memory.DeleteImage(%d)", imageID), productID, imageID)
+	if err != nil {
+		return err
+	}
+	m.writable()
+	m.data.Images = append(m.data.Images[:i], m.data.Images[i+1:]...)
+	return nil
+}
+
+// imageIndex returns the index of the image with imageID of the product with productID.
+// A NotFoundError is returned if there is none, or if the product is in the trash. The caller must hold the lock.
+func (m *Memory) imageIndex(operation string, productID, imageID int) (int, error) {
+	if _, p := m.find(productID); p == nil || p.DeletedAt != nil {
+		return 0, &NotFoundError{Operation: operation}
+	}
+	i, found := slices.BinarySearchFunc(m.data.Images, imageID, func(image memoryImage, id int) int {
+		return image.ID - id
+	})
+	if !found || m.data.Images[i].ProductID != productID {
+		return 0, &NotFoundError{Operation: operation}
+	}
+	return i, nil
+}
+
+// image returns the image that i is the metadata of, with its key.
+func (i memoryImage) image() models.Image {
+	result := i.Image
+	result.Key = i.BlobKey
+	return result
+}
+
+// AddProducts adds the products as they are, including their IDs. It is intended for seeding test data.
+// The products must have unique IDs that are not already in use.
+func (m *Memory) AddProducts(products *[]models.Product) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.writable()
+
+	for _, p := range *products {
+		if m.index(p.ID) >= 0 {
+			return fmt.Errorf("Product with ID %d already exists", p.ID)
+		}
+		p.SetVariants(nil)
+		p.CategoryIDs = nil
+		p.Tags = nil
+		p.Attributes = nil
+		m.data.Products = append(m.data.Products, p)
+		m.data.NextID = max(m.data.NextID, p.ID+1)
+	}
+	sort.Slice(m.data.Products, func(i, j int) bool {
+		return m.data.Products[i].ID < m.data.Products[j].ID
+	})
+	return nil
+}
+
+// Ping only returns an error if ctx is done, since the store is always available.
+func (m *Memory) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// Close saves a snapshot of the store if it has a snapshot path.
+func (m *Memory) Close() error {
+	if m.snapshotPath == "" {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, err := json.Marshal(m.data)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a failed write never leaves a truncated snapshot.
+	tmp, err := os.CreateTemp(filepath.Dir(m.snapshotPath), filepath.Base(m.snapshotPath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), m.snapshotPath)
+}
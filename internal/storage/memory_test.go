@@ -0,0 +1,157 @@
+package storage_test
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+)
+
+// Tests that IDs are not reused after a product is deleted.
+func TestMemory_CreateProductAfterDelete(t *testing.T) {
+	ctx := context.Background()
+	m := storage.NewMemory()
+
+	for i := 0; i < 2; i++ {
+		if _, err := m.CreateProduct(ctx, &models.CreateProductRequest{Name: "Test Product"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.DeleteProduct(ctx, 1, storage.AnyVersion); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := m.CreateProduct(ctx, &models.CreateProductRequest{Name: "Test Product 3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, id, 3, "Created ID")
+}
+
+// Tests that products returned by the store cannot be used to modify it.
+func TestMemory_DefensiveCopies(t *testing.T) {
+	ctx := context.Background()
+	m := storage.NewMemory()
+	if _, err := m.CreateProduct(ctx, &models.CreateProductRequest{Name: "Test Product"}); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := m.GetProducts(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page.Products[0].Name = "Modified"
+	product, err := m.GetProduct(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	product.StockQuantity = 100
+
+	product, err = m.GetProduct(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := models.Product{ID: 1, Name: "Test Product", Slug: "test-product", Price: models.NewMoney(0, models.DefaultCurrency), Version: 1}
+	checkEqual(t, *product, want, "Product")
+
+	// Updating a product only sets its version on the caller's product, as the other engines do,
+	// and the product that is stored is not changed through it afterwards.
+	price := models.NewMoney(0, models.DefaultCurrency)
+	update := models.Product{ID: 1, Name: "Updated Product", Price: price, Version: 1, Tags: []string{"tag"}, CategoryIDs: []int{1}}
+	update.SetVariants([]models.Variant{{ID: 1, StockQuantity: 2}})
+	if err = m.UpdateProduct(ctx, &update); err != nil {
+		t.Fatal(err)
+	}
+	wantUpdate := models.Product{ID: 1, Name: "Updated Product", Price: price, Version: 2, Tags: []string{"tag"}, CategoryIDs: []int{1}}
+	wantUpdate.SetVariants([]models.Variant{{ID: 1, StockQuantity: 2}})
+	checkEqual(t, update, wantUpdate, "Updated Product")
+	update.Name = "Modified"
+
+	product, err = m.GetProduct(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = models.Product{ID: 1, Name: "Updated Product", Slug: "test-product", Price: price, Version: 2}
+	checkEqual(t, *product, want, "Product After Update")
+}
+
+// Tests that concurrently created products all get unique IDs.
+func TestMemory_ConcurrentCreates(t *testing.T) {
+	ctx := context.Background()
+	m := storage.NewMemory()
+
+	const n = 50
+	var wg sync.WaitGroup
+	ids := make(chan int, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := m.CreateProduct(ctx, &models.CreateProductRequest{Name: "Test Product"})
+			if err != nil {
+				t.Error(err)
+			}
+			ids <- id
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int]bool)
+	for id := range ids {
+		if seen[id] {
+			t.Errorf("Duplicate ID %d", id)
+		}
+		seen[id] = true
+	}
+	checkEqual(t, len(seen), n, "Unique IDs")
+}
+
+// Tests that a store with a snapshot path is restored from the snapshot saved when it was closed.
+func TestMemory_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	m, err := storage.NewMemoryWithSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Test Product", "Test Product 2"} {
+		if _, err = m.CreateProduct(ctx, &models.CreateProductRequest{Name: name, Price: models.NewMoney(199, models.DefaultCurrency)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = m.DeleteProduct(ctx, 2, storage.AnyVersion); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.SetPrice(ctx, 1, models.NewMoney(129, "USD")); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err = storage.NewMemoryWithSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	product, err := m.GetProduct(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, *product, models.Product{ID: 1, Name: "Test Product", Slug: "test-product", Price: models.NewMoney(199, models.DefaultCurrency), Version: 1}, "Restored Product")
+	prices, err := m.GetPrices(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, prices, []models.Money{models.NewMoney(129, "USD")}, "Restored Prices")
+
+	id, err := m.CreateProduct(ctx, &models.CreateProductRequest{Name: "Test Product 3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, id, 3, "Created ID")
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+)
+
+// PriceList is a struct that holds what is needed to price products in a currency.
+type PriceList struct {
+	Currency string
+	// Rate is the exchange rate of Currency, or nil if it has none.
+	Rate *big.Rat
+	// Overrides holds the prices set in Currency, by product ID.
+	Overrides map[int]models.Money
+}
+
+// Price returns the price of p in the currency of l: its override if it has one,
+// or otherwise its price converted using the exchange rate and rounded to the nearest minor unit (ties to even).
+// An UnsupportedCurrencyError is returned if p has no override and the currency has no exchange rate.
+func (l *PriceList) Price(p *models.Product) (models.Money, error) {
+	if l.Currency == p.Price.Currency {
+		return p.Price, nil
+	}
+	if price, ok := l.Overrides[p.ID]; ok {
+		return price, nil
+	}
+	if l.Rate == nil {
+		return models.Money{}, &UnsupportedCurrencyError{Currency: l.Currency}
+	}
+	return p.Price.MulRat(l.Rate, l.Currency), nil
+}
+
+// VariantPrice returns the price of the variant v in the currency of l, converted using the exchange rate.
+// Prices set in other currencies only apply to products, so an UnsupportedCurrencyError is returned
+// if the currency has no exchange rate.
+func (l *PriceList) VariantPrice(v *models.Variant) (models.Money, error) {
+	if l.Currency == v.Price.Currency {
+		return *v.Price, nil
+	}
+	if l.Rate == nil {
+		return models.Money{}, &UnsupportedCurrencyError{Currency: l.Currency}
+	}
+	return v.Price.MulRat(l.Rate, l.Currency), nil
+}
+
+// ConvertPrices sets the price of each of products and of their variants to its price in currency
+// (see PriceList.Price and PriceList.VariantPrice).
+// Products are left as they are if the currency is the DefaultCurrency.
+func ConvertPrices(ctx context.Context, s PriceStorage, products []models.Product, currency string) error {
+	if currency == models.DefaultCurrency || len(products) == 0 {
+		return nil
+	}
+	ids := make([]int, len(products))
+	for i := range products {
+		ids[i] = products[i].ID
+	}
+	list, err := s.GetPriceList(ctx, currency, ids)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		products[i].Price, err = list.Price(&products[i])
+		if err != nil {
+			return err
+		}
+		for j := range products[i].Variants {
+			v := &products[i].Variants[j]
+			if v.Price == nil {
+				continue
+			}
+			price, err := list.VariantPrice(v)
+			if err != nil {
+				return err
+			}
+			v.Price = &price
+		}
+	}
+	return nil
+}
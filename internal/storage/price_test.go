@@ -0,0 +1,98 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+)
+
+// Tests that prices are set, converted and deleted consistently by every storage engine.
+func TestStorage_Prices(t *testing.T) {
+	for _, engine := range testEngines() {
+		t.Run(engine.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := engine.storage(t)
+			for _, price := range []int64{1000, 1005} {
+				_, err := s.CreateProduct(ctx, &models.CreateProductRequest{Name: "Test Product", Price: models.NewMoney(price, "")})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			usd, err := models.ParseExchangeRate("USD", "0.650")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = s.SetExchangeRate(ctx, usd); err != nil {
+				t.Fatal(err)
+			}
+			if err = s.SetPrice(ctx, 2, models.NewMoney(599, "EUR")); err != nil {
+				t.Fatal(err)
+			}
+			if err = s.SetPrice(ctx, 2, models.NewMoney(699, "USD")); err != nil {
+				t.Fatal(err)
+			}
+			if err = s.SetPrice(ctx, 2, models.NewMoney(649, "USD")); err != nil {
+				t.Fatal(err)
+			}
+			err = s.SetPrice(ctx, 3, models.NewMoney(100, "USD"))
+			checkEqual(t, errors.As(err, new(*storage.NotFoundError)), true, "Set Price Of Unknown Product")
+
+			rates, err := s.GetExchangeRates(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, rates, []models.ExchangeRate{{Currency: "USD", Rate: "0.65"}}, "Exchange Rates")
+			prices, err := s.GetPrices(ctx, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, prices, []models.Money{models.NewMoney(599, "EUR"), models.NewMoney(649, "USD")}, "Prices")
+
+			// 10.00 * 0.65 is exact, and the override of the second product is used instead of 10.05 * 0.65.
+			page, err := s.GetProducts(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = storage.ConvertPrices(ctx, s, page.Products, "USD"); err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, page.Products[0].Price, models.NewMoney(650, "USD"), "Converted Price")
+			checkEqual(t, page.Products[1].Price, models.NewMoney(649, "USD"), "Overridden Price")
+
+			// Without its override, 10.05 * 0.65 = 6.5325 is rounded to 6.53.
+			if err = s.DeletePrice(ctx, 2, "USD"); err != nil {
+				t.Fatal(err)
+			}
+			err = s.DeletePrice(ctx, 2, "USD")
+			checkEqual(t, errors.As(err, new(*storage.NotFoundError)), true, "Delete Missing Price")
+			page, err = s.GetProducts(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = storage.ConvertPrices(ctx, s, page.Products, "USD"); err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, page.Products[1].Price, models.NewMoney(653, "USD"), "Converted Price After Delete")
+
+			// Only the product with an override can be priced in a currency without an exchange rate.
+			err = storage.ConvertPrices(ctx, s, page.Products[1:], "EUR")
+			checkEqual(t, err, nil, "Overridden Price Without Rate")
+			err = storage.ConvertPrices(ctx, s, page.Products[:1], "EUR")
+			checkEqual(t, errors.As(err, new(*storage.UnsupportedCurrencyError)), true, "Converted Price Without Rate")
+
+			// The prices of a purged product are deleted with it.
+			if err = s.PurgeProduct(ctx, 2, storage.AnyVersion); err != nil {
+				t.Fatal(err)
+			}
+			list, err := s.GetPriceList(ctx, "EUR", []int{2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, len(list.Overrides), 0, "Overrides After Purge")
+		})
+	}
+}
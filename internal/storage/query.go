@@ -0,0 +1,307 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+)
+
+const (
+	// DefaultPageLimit is the number of products returned when a ProductQuery has no Limit.
+	DefaultPageLimit = 20
+	// MaxPageLimit is the largest Limit a ProductQuery may have.
+	MaxPageLimit = 100
+)
+
+// ProductQuery is a struct that defines which page of products to retrieve, and how to filter and sort them.
+// Filters that are nil or empty are not applied.
+type ProductQuery struct {
+	// Limit is the maximum number of products in the page. Zero means DefaultPageLimit.
+	Limit int
+	// After is the opaque cursor of the previous page (see ProductPage.NextCursor).
+	// The page starts from the first product when it is empty.
+	After string
+
+	// MinPrice excludes products that are cheaper than it.
+	MinPrice *models.Money
+	// MaxPrice excludes products that are more expensive than it.
+	MaxPrice *models.Money
+	// InStock excludes products without stock when true, and products with stock when false.
+	InStock *bool
+	// Search excludes products that do not contain it in their name or description (case-insensitive).
+	Search string
+	// CategoryIDs excludes products that are not assigned to any of them.
+	CategoryIDs []int
+	// Tags excludes products that do not have every one of them.
+	Tags []string
+	// Attributes excludes products that do not have every one of these attributes, with a value whose text form
+	// (see models.EncodeAttribute) is equal to the one given.
+	Attributes map[string]string
+	// Sort is the order of the products. The zero value sorts by ascending ID.
+	Sort ProductSort
+	// Deleted lists the products in the trash instead of the products that are not.
+	Deleted bool
+}
+
+// SortField is a product field that products can be sorted by.
+type SortField string
+
+const (
+	SortByID            SortField = "id"
+	SortByName          SortField = "name"
+	SortByPrice         SortField = "price"
+	SortByStockQuantity SortField = "stock_quantity"
+)
+
+// ProductSort is a struct that defines the order of a list of products.
+// Products with equal Field values are ordered by ID in the same direction.
+type ProductSort struct {
+	Field      SortField
+	Descending bool
+}
+
+// ParseProductSort parses the sort s, which is a SortField optionally prefixed with "-" for descending order.
+// An empty s is the default sort.
+func ParseProductSort(s string) (ProductSort, error) {
+	sort := ProductSort{Field: SortField(strings.TrimPrefix(s, "-")), Descending: strings.HasPrefix(s, "-")}
+	switch sort.Field {
+	case "":
+		return ProductSort{}, nil
+	case SortByID, SortByName, SortByPrice, SortByStockQuantity:
+		return sort, nil
+	default:
+		return ProductSort{}, &InvalidSortError{Sort: s}
+	}
+}
+
+// String returns the sort in the form accepted by ParseProductSort.
+func (s ProductSort) String() string {
+	if s.Descending {
+		return "-" + string(s.field())
+	}
+	return string(s.field())
+}
+
+// field returns the sort field, substituting SortByID for the zero value.
+func (s ProductSort) field() SortField {
+	if s.Field == "" {
+		return SortByID
+	}
+	return s.Field
+}
+
+// ProductPage is a struct that holds a single page of products.
+type ProductPage struct {
+	Products []models.Product
+	// NextCursor is used as ProductQuery.After to retrieve the next page. It is empty on the last page.
+	NextCursor string
+}
+
+// limit returns the page size of q, substituting and clamping it to the allowed range.
+func (q *ProductQuery) limit() int {
+	if q == nil {
+		return pageLimit(0)
+	}
+	return pageLimit(q.Limit)
+}
+
+// pageLimit returns the page size for limit, substituting DefaultPageLimit for zero and clamping it to MaxPageLimit.
+func pageLimit(limit int) int {
+	switch {
+	case limit <= 0:
+		return DefaultPageLimit
+	case limit > MaxPageLimit:
+		return MaxPageLimit
+	default:
+		return limit
+	}
+}
+
+// after decodes the After cursor of q. The zero cursor is returned when there is none.
+// The cursor must have been created with the same sort as q.
+func (q *ProductQuery) after() (cursor, error) {
+	if q == nil || q.After == "" {
+		return cursor{}, nil
+	}
+	c, err := decodeCursor(q.After)
+	if err != nil {
+		return c, err
+	}
+	if c.Sort != q.Sort.String() || !c.valid(q.Sort.field()) {
+		return cursor{}, &InvalidCursorError{Cursor: q.After}
+	}
+	return c, nil
+}
+
+// matches returns whether p passes the filters of q.
+func (q *ProductQuery) matches(p *models.Product) bool {
+	if q == nil {
+		return p.DeletedAt == nil
+	}
+	if (p.DeletedAt != nil) != q.Deleted {
+		return false
+	}
+	if q.MinPrice != nil && p.Price.Cmp(*q.MinPrice) < 0 {
+		return false
+	}
+	if q.MaxPrice != nil && p.Price.Cmp(*q.MaxPrice) > 0 {
+		return false
+	}
+	if q.InStock != nil && (p.StockQuantity > 0) != *q.InStock {
+		return false
+	}
+	if q.Search != "" {
+		search := strings.ToLower(q.Search)
+		if !strings.Contains(strings.ToLower(p.Name), search) &&
+			!strings.Contains(strings.ToLower(p.Description.String), search) {
+			return false
+		}
+	}
+	if len(q.CategoryIDs) > 0 && !containsAny(p.CategoryIDs, q.CategoryIDs) {
+		return false
+	}
+	for _, tag := range q.Tags {
+		if !slices.Contains(p.Tags, tag) {
+			return false
+		}
+	}
+	for name, text := range q.Attributes {
+		value, ok := p.Attributes[name]
+		if !ok {
+			return false
+		}
+		if _, valueText, err := models.EncodeAttribute(value); err != nil || valueText != text {
+			return false
+		}
+	}
+	return true
+}
+
+// containsAny returns whether any of values is in s.
+func containsAny(s, values []int) bool {
+	for _, v := range values {
+		if slices.Contains(s, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareProducts returns -1 if a comes before b in the order of s, 1 if it comes after, and 0 if they are equal.
+func compareProducts(a, b *models.Product, s ProductSort) int {
+	result := 0
+	switch s.field() {
+	case SortByName:
+		result = strings.Compare(a.Name, b.Name)
+	case SortByPrice:
+		result = a.Price.Cmp(b.Price)
+	case SortByStockQuantity:
+		result = compare(a.StockQuantity, b.StockQuantity)
+	case SortByID:
+	}
+	if result == 0 {
+		result = compare(a.ID, b.ID)
+	}
+	if s.Descending {
+		return -result
+	}
+	return result
+}
+
+// compare returns -1, 0 or 1 depending on whether a is less than, equal to, or greater than b.
+func compare[T int | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// cursor is the position of the last product in a page.
+// It is encoded as base64 JSON so that clients treat it as opaque.
+type cursor struct {
+	ID int `json:"id"`
+	// Sort is the sort of the page in the form accepted by ParseProductSort.
+	Sort string `json:"sort,omitempty"`
+	// Value is the value of the sort field of the product, unless it is sorted by ID.
+	// Prices are held as decimal strings so that they are exact.
+	Value any `json:"value,omitempty"`
+}
+
+// newCursor returns the cursor of p in a page sorted by s.
+func newCursor(p *models.Product, s ProductSort) cursor {
+	c := cursor{ID: p.ID, Sort: s.String()}
+	switch s.field() {
+	case SortByName:
+		c.Value = p.Name
+	case SortByPrice:
+		c.Value = p.Price.String()
+	case SortByStockQuantity:
+		c.Value = p.StockQuantity
+	case SortByID:
+	}
+	return c
+}
+
+// valid returns whether the Value of c has the type of field after being decoded.
+func (c cursor) valid(field SortField) bool {
+	switch field {
+	case SortByName:
+		_, ok := c.Value.(string)
+		return ok
+	case SortByPrice:
+		s, _ := c.Value.(string)
+		_, err := models.ParseMoney(s, models.DefaultCurrency)
+		return err == nil
+	case SortByStockQuantity:
+		_, ok := c.Value.(float64)
+		return ok
+	case SortByID:
+		return c.Value == nil
+	}
+	return false
+}
+
+// product returns a product with the ID and sort field value of c, for comparison using compareProducts.
+// c must be valid for field.
+func (c cursor) product(field SortField) *models.Product {
+	p := &models.Product{ID: c.ID}
+	switch field {
+	case SortByName:
+		p.Name, _ = c.Value.(string)
+	case SortByPrice:
+		s, _ := c.Value.(string)
+		p.Price, _ = models.ParseMoney(s, models.DefaultCurrency)
+	case SortByStockQuantity:
+		v, _ := c.Value.(float64)
+		p.StockQuantity = int(v)
+	case SortByID:
+	}
+	return p
+}
+
+// encode returns the opaque string form of c.
+func (c cursor) encode() string {
+	// Marshalling a struct of basic types cannot fail.
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor parses the opaque string form of a cursor.
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, &InvalidCursorError{Cursor: s}
+	}
+	if err = json.Unmarshal(b, &c); err != nil {
+		return c, &InvalidCursorError{Cursor: s}
+	}
+	return c, nil
+}
@@ -0,0 +1,132 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+)
+
+// Tests reserving, releasing and committing stock, and that expired reservations return their stock.
+func TestStorage_Reservations(t *testing.T) {
+	for _, engine := range testEngines() {
+		t.Run(engine.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := engine.storage(t)
+			id, err := s.CreateProduct(ctx, &models.CreateProductRequest{Name: "Test Product", StockQuantity: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			stock := func(msg string, want int) {
+				t.Helper()
+				product, err := s.GetProduct(ctx, id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				checkEqual(t, product.StockQuantity, want, msg)
+			}
+
+			first, err := s.ReserveStock(ctx, id, 6, time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, first.Status, models.ReservationPending, "Reserved Status")
+			stock("Stock After Reserve", 4)
+
+			var insufficientErr *storage.InsufficientStockError
+			_, err = s.ReserveStock(ctx, id, 5, time.Hour)
+			checkEqual(t, errors.As(err, &insufficientErr), true, "Insufficient Stock")
+			checkEqual(t, insufficientErr.Available, 4, "Available Stock")
+
+			expiring, err := s.ReserveStock(ctx, id, 4, time.Millisecond)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stock("Stock After Reserve All", 0)
+			time.Sleep(5 * time.Millisecond)
+
+			// The expired reservation returns its stock when more is reserved.
+			third, err := s.ReserveStock(ctx, id, 3, time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stock("Stock After Expiry", 1)
+
+			var stateErr *storage.ReservationStateError
+			err = s.CommitReservation(ctx, id, expiring.ID)
+			checkEqual(t, errors.As(err, &stateErr), true, "Commit Expired")
+			checkEqual(t, stateErr.Status, models.ReservationExpired, "Expired Status")
+
+			if err = s.ReleaseStock(ctx, id, first.ID); err != nil {
+				t.Fatal(err)
+			}
+			stock("Stock After Release", 7)
+			err = s.ReleaseStock(ctx, id, first.ID)
+			checkEqual(t, errors.As(err, &stateErr), true, "Release Released")
+
+			if err = s.CommitReservation(ctx, id, third.ID); err != nil {
+				t.Fatal(err)
+			}
+			stock("Stock After Commit", 7)
+			err = s.ReleaseStock(ctx, id, third.ID)
+			checkEqual(t, errors.As(err, &stateErr), true, "Release Committed")
+			checkEqual(t, stateErr.Status, models.ReservationCommitted, "Committed Status")
+
+			var notFoundErr *storage.NotFoundError
+			err = s.CommitReservation(ctx, id+1, third.ID)
+			checkEqual(t, errors.As(err, &notFoundErr), true, "Commit Other Product")
+			_, err = s.ReserveStock(ctx, id+1, 1, time.Hour)
+			checkEqual(t, errors.As(err, &notFoundErr), true, "Reserve Not Found")
+		})
+	}
+}
+
+// Tests that concurrent reservations never take more stock than the product has.
+func TestStorage_ConcurrentReservations(t *testing.T) {
+	for _, engine := range testEngines() {
+		t.Run(engine.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := engine.storage(t)
+			id, err := s.CreateProduct(ctx, &models.CreateProductRequest{Name: "Test Product", StockQuantity: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			const n = 25
+			var wg sync.WaitGroup
+			results := make(chan error, n)
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := s.ReserveStock(ctx, id, 1, time.Hour)
+					results <- err
+				}()
+			}
+			wg.Wait()
+			close(results)
+
+			reserved := 0
+			for err := range results {
+				var insufficientErr *storage.InsufficientStockError
+				switch {
+				case err == nil:
+					reserved++
+				case !errors.As(err, &insufficientErr):
+					t.Error(err)
+				}
+			}
+			checkEqual(t, reserved, 10, "Reserved")
+
+			product, err := s.GetProduct(ctx, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, product.StockQuantity, 0, "Stock")
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy is a struct that defines how operations that fail with a transient error (eg. a deadlock) are retried.
+// Only operations that can safely run more than once are retried, such as a transaction that was rolled back.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times that an operation is attempted, including the first. Zero means once.
+	MaxAttempts int
+	// BaseDelay is the longest delay before the first retry, which doubles before each retry after it, up to MaxDelay.
+	// The delay before each retry is chosen at random up to it, so that operations that failed together are spread out.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// OnRetry is called before each retry with the number of the attempt that failed, its error, and the delay
+	// before the next attempt (eg. to log it). It may be nil.
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// DefaultRetryPolicy returns the policy that storage engines retry transient errors with unless they are given another.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 5, BaseDelay: 10 * time.Millisecond, MaxDelay: time.Second}
+}
+
+// Do calls fn until it succeeds, fails with an error that retryable returns false for, or has been attempted
+// MaxAttempts times, and returns its last error. If ctx is done before fn is retried, its error is returned
+// joined with the last error of fn.
+func (p RetryPolicy) Do(ctx context.Context, retryable func(err error) bool, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !retryable(err) || attempt >= p.MaxAttempts {
+			return err
+		}
+		if ctx.Err() != nil {
+			return errors.Join(ctx.Err(), err)
+		}
+
+		delay := p.delay(attempt)
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, delay)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// delay returns the delay before the retry that follows attempt, using exponential backoff with full jitter.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	limit := p.BaseDelay
+	for i := 1; i < attempt && limit < p.MaxDelay; i++ {
+		limit *= 2
+	}
+	if p.MaxDelay > 0 {
+		limit = min(limit, p.MaxDelay)
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(limit) + 1)) //nolint:gosec // the jitter does not need to be unpredictable
+}
@@ -0,0 +1,72 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+)
+
+// Tests that operations are retried while they fail with a retryable error, up to the attempts of the policy,
+// and that each retry is reported with a delay that backs off exponentially.
+func TestRetryPolicy_Do(t *testing.T) {
+	errTransient := errors.New("transient")
+	errPermanent := errors.New("permanent")
+	retryable := func(err error) bool { return errors.Is(err, errTransient) }
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tt := []struct {
+		name string
+		ctx  context.Context
+		// errs are the errors returned by the attempts of the operation, which succeeds after them.
+		errs             []error
+		maxAttempts      int
+		expectedAttempts int
+		// expectedRetries are the attempts that are reported as retried.
+		expectedRetries []int
+		expectedErr     error
+	}{
+		{"success", context.Background(), nil, 3, 1, nil, nil},
+		{"success after retries", context.Background(), []error{errTransient, errTransient}, 3, 3, []int{1, 2}, nil},
+		{"permanent error", context.Background(), []error{errPermanent}, 3, 1, nil, errPermanent},
+		{"too many attempts", context.Background(), []error{errTransient, errTransient, errTransient}, 3, 3, []int{1, 2}, errTransient},
+		{"no retries", context.Background(), []error{errTransient}, 0, 1, nil, errTransient},
+		{"cancelled", cancelled, []error{errTransient}, 3, 1, nil, context.Canceled},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var retries []int
+			policy := storage.RetryPolicy{
+				MaxAttempts: tc.maxAttempts,
+				BaseDelay:   time.Millisecond,
+				MaxDelay:    3 * time.Millisecond,
+				OnRetry: func(attempt int, err error, delay time.Duration) {
+					retries = append(retries, attempt)
+					checkEqual(t, errors.Is(err, errTransient), true, "Retried Error")
+					limit := min(time.Millisecond<<(attempt-1), 3*time.Millisecond)
+					checkEqual(t, delay >= 0 && delay <= limit, true, "Delay")
+				},
+			}
+
+			attempts := 0
+			err := policy.Do(tc.ctx, retryable, func() error {
+				attempts++
+				if attempts <= len(tc.errs) {
+					return tc.errs[attempts-1]
+				}
+				return nil
+			})
+			checkEqual(t, attempts, tc.expectedAttempts, "Attempts")
+			checkEqual(t, retries, tc.expectedRetries, "Retries")
+			if tc.expectedErr == nil {
+				checkEqual(t, err, nil, "Error")
+			} else {
+				checkEqual(t, errors.Is(err, tc.expectedErr), true, "Error")
+			}
+		})
+	}
+}
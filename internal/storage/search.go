@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+)
+
+const (
+	// The number of characters shown either side of the first match in a snippet.
+	snippetRadius = 60
+	// The weight of a match in the product name relative to a match in its description.
+	nameWeight = 2
+)
+
+// searchTerms splits the search query q into lowercase terms.
+// Runes are lowercased one at a time (unlike strings.ToLower) so that rune indexes are preserved by highlight.
+func searchTerms(q string) []string {
+	return strings.FieldsFunc(strings.Map(unicode.ToLower, q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// scoreProduct returns a naive relevance score for p: the number of times the terms occur in its description,
+// plus the weighted number of times they occur in its name. Zero means p does not match.
+func scoreProduct(p *models.Product, terms []string) float64 {
+	name := strings.ToLower(p.Name)
+	description := strings.ToLower(p.Description.String)
+
+	score := 0
+	for _, term := range terms {
+		score += nameWeight*strings.Count(name, term) + strings.Count(description, term)
+	}
+	return float64(score)
+}
+
+// newSearchResult returns the search result for p, with a snippet highlighting the terms.
+func newSearchResult(p *models.Product, terms []string, score float64) models.SearchResult {
+	text := p.Description.String
+	if firstMatch(text, terms) < 0 {
+		text = p.Name
+	}
+	return models.SearchResult{Product: *p, Score: score, Snippet: highlight(text, terms)}
+}
+
+// highlight returns an HTML-escaped excerpt of text around the first occurrence of any of the terms,
+// with every occurrence of the terms in the excerpt wrapped in <mark> tags.
+func highlight(text string, terms []string) string {
+	runes := []rune(text)
+	start, end := 0, len(runes)
+	if first := firstMatch(text, terms); first > 0 {
+		start = max(0, first-snippetRadius)
+	}
+	end = min(end, start+2*snippetRadius)
+	excerpt := runes[start:end]
+	lower := []rune(strings.Map(unicode.ToLower, string(excerpt)))
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := 0; i < len(excerpt); {
+		n := matchLength(lower[i:], terms)
+		if n == 0 {
+			b.WriteString(html.EscapeString(string(excerpt[i])))
+			i++
+			continue
+		}
+		b.WriteString("<mark>" + html.EscapeString(string(excerpt[i:i+n])) + "</mark>")
+		i += n
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// firstMatch returns the rune index of the first occurrence of any of the terms in text, or -1 if there is none.
+func firstMatch(text string, terms []string) int {
+	lower := []rune(strings.Map(unicode.ToLower, text))
+	for i := range lower {
+		if matchLength(lower[i:], terms) > 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// matchLength returns the length of the longest term that s starts with, or 0 if it starts with none of them.
+func matchLength(s []rune, terms []string) int {
+	longest := 0
+	for _, term := range terms {
+		t := []rune(term)
+		if len(t) > longest && len(t) <= len(s) && string(s[:len(t)]) == term {
+			longest = len(t)
+		}
+	}
+	return longest
+}
@@ -0,0 +1,94 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+)
+
+// Tests that products have unique SKUs and slugs, and are found by them, in every storage engine.
+func TestStorage_SKUsAndSlugs(t *testing.T) {
+	for _, engine := range testEngines() {
+		t.Run(engine.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := engine.storage(t)
+			create := func(req *models.CreateProductRequest) (int, error) {
+				req.Price = models.NewMoney(1000, "")
+				return s.CreateProduct(ctx, req)
+			}
+			for _, req := range []*models.CreateProductRequest{
+				{Name: "Wool Hat", SKU: "HAT-1"},
+				{Name: "Wool Hat"},
+				{Name: "Scarf", Slug: "winter-scarf"},
+				{Name: "!!!"},
+			} {
+				if _, err := create(req); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			slugs := []string{}
+			for id := 1; id <= 4; id++ {
+				product, err := s.GetProduct(ctx, id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				slugs = append(slugs, product.Slug)
+			}
+			checkEqual(t, slugs, []string{"wool-hat", "wool-hat-2", "winter-scarf", "product"}, "Slugs")
+
+			product, err := s.GetProductBySlug(ctx, "wool-hat-2")
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, product.ID, 2, "Get By Slug")
+			product, err = s.GetProductBySKU(ctx, "HAT-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, product.ID, 1, "Get By SKU")
+			_, err = s.GetProductBySKU(ctx, "HAT-9")
+			checkEqual(t, errors.As(err, new(*storage.NotFoundError)), true, "Get By Unknown SKU")
+
+			conflict := func(err error) string {
+				var conflictErr *storage.ConflictError
+				if !errors.As(err, &conflictErr) {
+					t.Fatalf("Conflict: got %v want ConflictError", err)
+				}
+				return conflictErr.Field
+			}
+			_, err = create(&models.CreateProductRequest{Name: "Hat", SKU: "HAT-1"})
+			checkEqual(t, conflict(err), "sku", "Create With Used SKU")
+			_, err = create(&models.CreateProductRequest{Name: "Hat", Slug: "winter-scarf"})
+			checkEqual(t, conflict(err), "slug", "Create With Used Slug")
+
+			product, err = s.GetProduct(ctx, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			product.SKU = "HAT-1"
+			checkEqual(t, conflict(s.UpdateProduct(ctx, product)), "sku", "Update With Used SKU")
+			product.SKU, product.Slug = "HAT-2", ""
+			if err = s.UpdateProduct(ctx, product); err != nil {
+				t.Fatal(err)
+			}
+			product, err = s.GetProductBySKU(ctx, "HAT-2")
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, product.Slug, "wool-hat-2", "Kept Slug")
+
+			// Products in the trash keep their SKU and slug, but are not found by them.
+			if err = s.DeleteProduct(ctx, 1, storage.AnyVersion); err != nil {
+				t.Fatal(err)
+			}
+			_, err = s.GetProductBySlug(ctx, "wool-hat")
+			checkEqual(t, errors.As(err, new(*storage.NotFoundError)), true, "Get Trashed By Slug")
+			_, err = create(&models.CreateProductRequest{Name: "Hat", SKU: "HAT-1"})
+			checkEqual(t, conflict(err), "sku", "Create With Trashed SKU")
+		})
+	}
+}
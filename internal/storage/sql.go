@@ -0,0 +1,1644 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+)
+
+// sqlStore implements the parts of the Storage interface that are common to the SQL databases.
+// The SQL it uses must be understood by both MariaDB and SQLite.
+type sqlStore struct {
+	db *sql.DB
+	// tx is the transaction that the store is a view of within WithTx. It is nil outside of a transaction.
+	tx *sql.Tx
+	// name is the name of the storage engine that is used in errors (eg. "Maria").
+	name string
+	// forUpdate is appended to the SELECT statements that lock the rows they read until the transaction is done.
+	// It is empty for SQLite, which locks the whole database for a write transaction instead.
+	forUpdate string
+	// retryable reports whether an operation that failed with an error should be retried (eg. after a deadlock).
+	// It is nil if operations are never retried.
+	retryable func(err error) bool
+	// retry is the policy that operations that fail with a retryable error are retried with.
+	retry RetryPolicy
+	// duplicate reports whether a statement failed with an error because it would duplicate a value in a unique index.
+	duplicate func(err error) bool
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// conn returns the transaction of s if it is in one, and otherwise its database.
+func (s sqlStore) conn() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+// productColumns are the columns of the products table in the order that scanProduct expects them.
+const productColumns = "id, name, sku, slug, description, price, stock_quantity, version, deleted_at"
+
+// scanner is implemented by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanProduct scans a row selected with productColumns into a product.
+// Any columns selected after productColumns are scanned into extra.
+func scanProduct(row scanner, extra ...any) (*models.Product, error) {
+	result := &models.Product{}
+	var sku sql.NullString
+	var deletedAt sql.NullTime
+	dest := []any{
+		&result.ID, &result.Name, &sku, &result.Slug, &result.Description, &result.Price, &result.StockQuantity,
+		&result.Version, &deletedAt,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	result.SKU = sku.String
+	if deletedAt.Valid {
+		result.DeletedAt = &deletedAt.Time
+	}
+	return result, err
+}
+
+// GetProduct returns a product by id, unless it is in the trash.
+func (s sqlStore) GetProduct(ctx context.Context, id int) (*models.Product, error) {
+	query := `
+	SELECT ` + productColumns + `
+	FROM products 
+	WHERE id = ? AND deleted_at IS NULL`
+	row := s.conn().QueryRowContext(ctx, query, id)
+
+	result, err := scanProduct(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &NotFoundError{Operation: fmt.Sprintf("%s.GetProduct(%d)", s.name, id)}
+		}
+		return nil, err
+	}
+	variants, err := s.variantsOf(ctx, []int{id})
+	if err != nil {
+		return nil, err
+	}
+	result.SetVariants(variants[id])
+	categories, err := s.categoriesOf(ctx, []int{id})
+	if err != nil {
+		return nil, err
+	}
+	result.CategoryIDs = categories[id]
+	tags, err := s.tagsOf(ctx, []int{id})
+	if err != nil {
+		return nil, err
+	}
+	result.Tags = tags[id]
+	attributes, err := s.attributesOf(ctx, []int{id})
+	if err != nil {
+		return nil, err
+	}
+	result.Attributes = attributes[id]
+	return result, nil
+}
+
+// GetProductBySlug returns a product by its slug, unless it is in the trash.
+func (s sqlStore) GetProductBySlug(ctx context.Context, slug string) (*models.Product, error) {
+	return s.getProductBy(ctx, fmt.Sprintf("%s.GetProductBySlug(%q)", s.name, slug), "slug", slug)
+}
+
+// GetProductBySKU returns a product by its SKU, unless it is in the trash.
+func (s sqlStore) GetProductBySKU(ctx context.Context, sku string) (*models.Product, error) {
+	return s.getProductBy(ctx, fmt.Sprintf("%s.GetProductBySKU(%q)", s.name, sku), "sku", sku)
+}
+
+// getProductBy returns the product whose unique column has value, unless it is in the trash.
+// column must not come from user input, since it is interpolated into the query.
+func (s sqlStore) getProductBy(ctx context.Context, operation, column, value string) (*models.Product, error) {
+	var id int
+	query := "SELECT id FROM products WHERE " + column + " = ? AND deleted_at IS NULL"
+	err := s.conn().QueryRowContext(ctx, query, value).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Operation: operation}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetProduct(ctx, id)
+}
+
+// sortColumns maps each SortField to the column that is sorted on.
+// Only these columns may be interpolated into ORDER BY clauses.
+var sortColumns = map[SortField]string{ //nolint:gochecknoglobals // read-only lookup table
+	SortByID:            "id",
+	SortByName:          "name",
+	SortByPrice:         "price",
+	SortByStockQuantity: "stock_quantity",
+}
+
+// likeEscaper escapes the wildcard characters of a LIKE pattern.
+// The escape character is declared in the LIKE clause since SQLite has no default.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_") //nolint:gochecknoglobals // stateless replacer
+
+// productsWhere returns the WHERE clause conditions and arguments that filter products by query,
+// and position them after the cursor.
+func productsWhere(query *ProductQuery, after cursor) ([]string, []any, error) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []any
+
+	order := ProductSort{}
+	if query != nil {
+		order = query.Sort
+		if query.Deleted {
+			conditions[0] = "deleted_at IS NOT NULL"
+		}
+		if query.MinPrice != nil {
+			conditions = append(conditions, "price >= ?")
+			args = append(args, *query.MinPrice)
+		}
+		if query.MaxPrice != nil {
+			conditions = append(conditions, "price <= ?")
+			args = append(args, *query.MaxPrice)
+		}
+		if query.InStock != nil {
+			if *query.InStock {
+				conditions = append(conditions, "stock_quantity > 0")
+			} else {
+				conditions = append(conditions, "stock_quantity = 0")
+			}
+		}
+		if query.Search != "" {
+			pattern := "%" + likeEscaper.Replace(query.Search) + "%"
+			conditions = append(conditions, "(name LIKE ? ESCAPE '!' OR description LIKE ? ESCAPE '!')")
+			args = append(args, pattern, pattern)
+		}
+		if len(query.CategoryIDs) > 0 {
+			conditions = append(conditions, `id IN (
+			SELECT product_id FROM product_categories WHERE category_id IN (?`+strings.Repeat(", ?", len(query.CategoryIDs)-1)+`))`)
+			for _, id := range query.CategoryIDs {
+				args = append(args, id)
+			}
+		}
+		for _, tag := range query.Tags {
+			conditions = append(conditions, "id IN (SELECT product_id FROM product_tags WHERE tag = ?)")
+			args = append(args, tag)
+		}
+		// The attributes are filtered in order of name so that the statement is the same for the same query.
+		names := make([]string, 0, len(query.Attributes))
+		for name := range query.Attributes {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			conditions = append(conditions, "id IN (SELECT product_id FROM product_attributes WHERE name = ? AND value = ?)")
+			args = append(args, name, query.Attributes[name])
+		}
+	}
+
+	column, ok := sortColumns[order.field()]
+	if !ok {
+		return nil, nil, &InvalidSortError{Sort: order.String()}
+	}
+	if after.ID != 0 {
+		op := ">"
+		if order.Descending {
+			op = "<"
+		}
+		if order.field() == SortByID {
+			conditions = append(conditions, "id "+op+" ?")
+			args = append(args, after.ID)
+		} else {
+			conditions = append(conditions, fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", column, op))
+			args = append(args, after.Value, after.Value, after.ID)
+		}
+	}
+	return conditions, args, nil
+}
+
+// GetProducts returns a page of the products that match the filters of query, in the order of its sort.
+// The page starts after the cursor in query, and holds up to its limit.
+func (s sqlStore) GetProducts(ctx context.Context, query *ProductQuery) (*ProductPage, error) {
+	after, err := query.after()
+	if err != nil {
+		return nil, err
+	}
+	limit := query.limit()
+	var order ProductSort
+	if query != nil {
+		order = query.Sort
+	}
+
+	conditions, args, err := productsWhere(query, after)
+	if err != nil {
+		return nil, err
+	}
+	where := "WHERE " + strings.Join(conditions, " AND ")
+	direction := "ASC"
+	if order.Descending {
+		direction = "DESC"
+	}
+	orderBy := fmt.Sprintf("%[1]s %[2]s, id %[2]s", sortColumns[order.field()], direction)
+	if order.field() == SortByID {
+		orderBy = "id " + direction
+	}
+
+	stmt := `
+	SELECT ` + productColumns + `
+	FROM products
+	` + where + `
+	ORDER BY ` + orderBy + `
+	LIMIT ?`
+	// One extra row is selected to find out whether there is a next page.
+	rows, err := s.conn().QueryContext(ctx, stmt, append(args, limit+1)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &ProductPage{Products: []models.Product{}}
+	for rows.Next() {
+		var row *models.Product
+		row, err = scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		result.Products = append(result.Products, *row)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(result.Products) > limit {
+		result.Products = result.Products[:limit]
+		result.NextCursor = newCursor(&result.Products[limit-1], order).encode()
+	}
+
+	ids := make([]int, len(result.Products))
+	for i := range result.Products {
+		ids[i] = result.Products[i].ID
+	}
+	variants, err := s.variantsOf(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.categoriesOf(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.tagsOf(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	attributes, err := s.attributesOf(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result.Products {
+		id := result.Products[i].ID
+		result.Products[i].SetVariants(variants[id])
+		result.Products[i].CategoryIDs = categories[id]
+		result.Products[i].Tags = tags[id]
+		result.Products[i].Attributes = attributes[id]
+	}
+	return result, nil
+}
+
+// CreateProduct creates a product. A unique slug is generated from its name if it has none.
+func (s sqlStore) CreateProduct(ctx context.Context, product *models.CreateProductRequest) (int, error) {
+	var id int
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		slug := product.Slug
+		if slug == "" {
+			var err error
+			if slug, err = s.uniqueSlug(ctx, tx, product.Name); err != nil {
+				return err
+			}
+		}
+		query := `
+		INSERT INTO products (name, sku, slug, description, price, stock_quantity)
+		VALUES (?, NULLIF(?, ''), ?, ?, ?, ?)`
+		// An empty description is stored as NULL, as it is by CreateProductRequest.ToProduct.
+		description := product.ToProduct(0).Description
+		result, err := tx.ExecContext(ctx, query, product.Name, product.SKU, slug, description, product.Price, product.StockQuantity)
+		if err != nil {
+			return s.productConflictError(ctx, tx, err, fmt.Sprintf("%s.CreateProduct", s.name), 0, product.SKU, slug)
+		}
+		lastID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		id = int(lastID)
+
+		after, err := s.lockProduct(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		return s.recordChange(ctx, tx, newProductChange(ctx, models.ChangeCreate, id, nil, after))
+	})
+	return id, err
+}
+
+// UpdateProduct updates a product if it has the expected version, and increments its version.
+// Products in the trash cannot be updated.
+func (s sqlStore) UpdateProduct(ctx context.Context, product *models.Product) error {
+	operation := fmt.Sprintf("%s.UpdateProduct(%d)", s.name, product.ID)
+	after, err := s.changeProduct(ctx, models.ChangeUpdate, product.ID, func(tx *sql.Tx, before *models.Product) error {
+		if err := checkVersion(operation, before, product.Version, false); err != nil {
+			return err
+		}
+		// An empty slug keeps the current one, so that the URLs of the product do not change.
+		query := `
+		UPDATE products
+		SET name = ?, sku = NULLIF(?, ''), slug = COALESCE(NULLIF(?, ''), slug), description = ?, price = ?, stock_quantity = ?,
+			version = version + 1
+		WHERE id = ?`
+		_, err := tx.ExecContext(ctx, query,
+			product.Name, product.SKU, product.Slug, product.Description, product.Price, product.StockQuantity, product.ID)
+		if err != nil {
+			return s.productConflictError(ctx, tx, err, operation, product.ID, product.SKU, product.Slug)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	product.Version = after.Version
+	return nil
+}
+
+// DeleteProduct moves a product to the trash if it has the expected version, and increments its version.
+func (s sqlStore) DeleteProduct(ctx context.Context, id int, version int) error {
+	operation := fmt.Sprintf("%s.DeleteProduct(%d)", s.name, id)
+	_, err := s.changeProduct(ctx, models.ChangeDelete, id, func(tx *sql.Tx, before *models.Product) error {
+		if err := checkVersion(operation, before, version, false); err != nil {
+			return err
+		}
+		query := `
+		UPDATE products
+		SET deleted_at = ?, version = version + 1
+		WHERE id = ?`
+		_, err := tx.ExecContext(ctx, query, now(), id)
+		return err
+	})
+	return err
+}
+
+// RestoreProduct restores a product from the trash, and increments its version.
+func (s sqlStore) RestoreProduct(ctx context.Context, id int) error {
+	_, err := s.changeProduct(ctx, models.ChangeRestore, id, func(tx *sql.Tx, before *models.Product) error {
+		if before == nil || before.DeletedAt == nil {
+			return &NotFoundError{Operation: fmt.Sprintf("%s.RestoreProduct(%d)", s.name, id)}
+		}
+		query := `
+		UPDATE products
+		SET deleted_at = NULL, version = version + 1
+		WHERE id = ?`
+		_, err := tx.ExecContext(ctx, query, id)
+		return err
+	})
+	return err
+}
+
+// PurgeProduct permanently deletes a product by id if it has the expected version, whether or not it is in the trash.
+func (s sqlStore) PurgeProduct(ctx context.Context, id int, version int) error {
+	operation := fmt.Sprintf("%s.PurgeProduct(%d)", s.name, id)
+	_, err := s.changeProduct(ctx, models.ChangePurge, id, func(tx *sql.Tx, before *models.Product) error {
+		if err := checkVersion(operation, before, version, true); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "DELETE FROM products WHERE id = ?", id)
+		return err
+	})
+	return err
+}
+
+// ReserveStock takes quantity from the stock of a product and holds it in a reservation that expires after ttl.
+// The expired reservations of the product are released first, returning their stock to it.
+func (s sqlStore) ReserveStock(ctx context.Context, productID, quantity int, ttl time.Duration) (*models.Reservation, error) {
+	operation := fmt.Sprintf("%s.ReserveStock(%d)", s.name, productID)
+	createdAt := now()
+	reservation := &models.Reservation{
+		ProductID: productID,
+		Quantity:  quantity,
+		Status:    models.ReservationPending,
+		CreatedAt: createdAt,
+		ExpiresAt: createdAt.Add(ttl),
+	}
+	_, err := s.changeProduct(ctx, models.ChangeReserve, productID, func(tx *sql.Tx, before *models.Product) error {
+		if before == nil || before.DeletedAt != nil {
+			return &NotFoundError{Operation: operation}
+		}
+		expired, err := s.expireReservations(ctx, tx, productID, createdAt)
+		if err != nil {
+			return err
+		}
+
+		// The condition on the stock guarantees that it never becomes negative, even without the row lock.
+		query := `
+		UPDATE products
+		SET stock_quantity = stock_quantity + ? - ?, version = version + 1
+		WHERE id = ? AND stock_quantity + ? >= ?`
+		result, err := tx.ExecContext(ctx, query, expired, quantity, productID, expired, quantity)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("Error getting rows affected: %s", err.Error())
+		}
+		if rowsAffected == 0 {
+			return &InsufficientStockError{Operation: operation, Requested: quantity, Available: before.StockQuantity + expired}
+		}
+
+		query = `
+		INSERT INTO reservations (product_id, quantity, status, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)`
+		result, err = tx.ExecContext(ctx, query, productID, quantity, reservation.Status, reservation.CreatedAt, reservation.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		id, err := result.LastInsertId()
+		reservation.ID = int(id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// expireReservations marks the pending reservations of a product that expired at or before at as expired,
+// and returns the total quantity of stock they held, which the caller must return to the product.
+// The product must be locked by tx.
+func (s sqlStore) expireReservations(ctx context.Context, tx *sql.Tx, productID int, at time.Time) (int, error) {
+	var expired sql.NullInt64
+	query := `
+	SELECT SUM(quantity)
+	FROM reservations
+	WHERE product_id = ? AND status = ? AND expires_at <= ?`
+	err := tx.QueryRowContext(ctx, query, productID, models.ReservationPending, at).Scan(&expired)
+	if err != nil || !expired.Valid {
+		return 0, err
+	}
+
+	query = `
+	UPDATE reservations
+	SET status = ?
+	WHERE product_id = ? AND status = ? AND expires_at <= ?`
+	_, err = tx.ExecContext(ctx, query, models.ReservationExpired, productID, models.ReservationPending, at)
+	return int(expired.Int64), err
+}
+
+// ReleaseStock releases a pending reservation of a product, returning its stock to the product.
+func (s sqlStore) ReleaseStock(ctx context.Context, productID, reservationID int) error {
+	operation := fmt.Sprintf("%s.ReleaseStock(%d)", s.name, reservationID)
+	return s.finishReservation(ctx, operation, productID, reservationID, models.ReservationReleased)
+}
+
+// CommitReservation commits a pending reservation of a product, so that its stock is never returned to the product.
+func (s sqlStore) CommitReservation(ctx context.Context, productID, reservationID int) error {
+	operation := fmt.Sprintf("%s.CommitReservation(%d)", s.name, reservationID)
+	return s.finishReservation(ctx, operation, productID, reservationID, models.ReservationCommitted)
+}
+
+// finishReservation changes a pending reservation of a product to status, returning its stock to the product
+// unless it is committed. If the reservation has expired, it is marked as expired (and its stock returned)
+// and a ReservationStateError is returned instead.
+func (s sqlStore) finishReservation(
+	ctx context.Context, operation string, productID, reservationID int, status models.ReservationStatus,
+) error {
+	var stateErr error
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		// Locking the product serializes the changes to its reservations.
+		before, err := s.lockProduct(ctx, tx, productID)
+		if err != nil {
+			return err
+		}
+		var reservation models.Reservation
+		query := `
+		SELECT quantity, status, expires_at
+		FROM reservations
+		WHERE id = ? AND product_id = ?`
+		err = tx.QueryRowContext(ctx, query, reservationID, productID).
+			Scan(&reservation.Quantity, &reservation.Status, &reservation.ExpiresAt)
+		if err == sql.ErrNoRows {
+			return &NotFoundError{Operation: operation}
+		}
+		if err != nil {
+			return err
+		}
+
+		if reservation.Status != models.ReservationPending {
+			return &ReservationStateError{Operation: operation, Status: reservation.Status}
+		}
+		if !reservation.ExpiresAt.After(now()) {
+			status = models.ReservationExpired
+			stateErr = &ReservationStateError{Operation: operation, Status: status}
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE reservations SET status = ? WHERE id = ?", status, reservationID)
+		if err != nil || status == models.ReservationCommitted {
+			return err
+		}
+		query = `
+		UPDATE products
+		SET stock_quantity = stock_quantity + ?, version = version + 1
+		WHERE id = ?`
+		if _, err = tx.ExecContext(ctx, query, reservation.Quantity, productID); err != nil {
+			return err
+		}
+		after, err := s.lockProduct(ctx, tx, productID)
+		if err != nil {
+			return err
+		}
+		return s.recordChange(ctx, tx, newProductChange(ctx, models.ChangeRelease, productID, before, after))
+	})
+	if err != nil {
+		return err
+	}
+	return stateErr
+}
+
+// GetPrices returns the prices set for a product in currencies other than the DefaultCurrency, ordered by currency.
+func (s sqlStore) GetPrices(ctx context.Context, productID int) ([]models.Money, error) {
+	if err := s.checkProduct(ctx, s.conn(), fmt.Sprintf("%s.GetPrices(%d)", s.name, productID), productID); err != nil {
+		return nil, err
+	}
+	query := `
+	SELECT currency, amount
+	FROM product_prices
+	WHERE product_id = ?
+	ORDER BY currency`
+	rows, err := s.conn().QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.Money{}
+	for rows.Next() {
+		var price models.Money
+		// The currency is scanned first, so that scanning the amount keeps it.
+		if err = rows.Scan(&price.Currency, &price); err != nil {
+			return nil, err
+		}
+		result = append(result, price)
+	}
+	return result, rows.Err()
+}
+
+// SetPrice sets the price of a product in the currency of price, replacing any price it had in that currency.
+func (s sqlStore) SetPrice(ctx context.Context, productID int, price models.Money) error {
+	operation := fmt.Sprintf("%s.SetPrice(%d)", s.name, productID)
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := s.checkProduct(ctx, tx, operation, productID); err != nil {
+			return err
+		}
+		query := "DELETE FROM product_prices WHERE product_id = ? AND currency = ?"
+		if _, err := tx.ExecContext(ctx, query, productID, price.Currency); err != nil {
+			return err
+		}
+		query = "INSERT INTO product_prices (product_id, currency, amount) VALUES (?, ?, ?)"
+		_, err := tx.ExecContext(ctx, query, productID, price.Currency, price)
+		return err
+	})
+}
+
+// DeletePrice deletes the price of a product in currency, so that its price is converted to currency instead.
+func (s sqlStore) DeletePrice(ctx context.Context, productID int, currency string) error {
+	operation := fmt.Sprintf("%s.DeletePrice(%d, %s)", s.name, productID, currency)
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := s.checkProduct(ctx, tx, operation, productID); err != nil {
+			return err
+		}
+		query := "DELETE FROM product_prices WHERE product_id = ? AND currency = ?"
+		result, err := tx.ExecContext(ctx, query, productID, currency)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("Error getting rows affected: %s", err.Error())
+		}
+		if rowsAffected == 0 {
+			return &NotFoundError{Operation: operation}
+		}
+		return nil
+	})
+}
+
+// checkProduct returns a NotFoundError for operation if there is no product with id that is not in the trash.
+func (s sqlStore) checkProduct(ctx context.Context, q querier, operation string, id int) error {
+	var exists int
+	err := q.QueryRowContext(ctx, "SELECT 1 FROM products WHERE id = ? AND deleted_at IS NULL", id).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return &NotFoundError{Operation: operation}
+	}
+	return err
+}
+
+// GetExchangeRates returns every exchange rate, ordered by currency.
+func (s sqlStore) GetExchangeRates(ctx context.Context) ([]models.ExchangeRate, error) {
+	rows, err := s.conn().QueryContext(ctx, "SELECT currency, rate FROM exchange_rates ORDER BY currency")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.ExchangeRate{}
+	for rows.Next() {
+		var currency, rate string
+		if err = rows.Scan(&currency, &rate); err != nil {
+			return nil, err
+		}
+		var exchangeRate models.ExchangeRate
+		if exchangeRate, err = scanExchangeRate(currency, rate); err != nil {
+			return nil, err
+		}
+		result = append(result, exchangeRate)
+	}
+	return result, rows.Err()
+}
+
+// SetExchangeRate sets the exchange rate of a currency, replacing the rate it had.
+func (s sqlStore) SetExchangeRate(ctx context.Context, rate models.ExchangeRate) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM exchange_rates WHERE currency = ?", rate.Currency); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "INSERT INTO exchange_rates (currency, rate) VALUES (?, ?)", rate.Currency, rate.Rate)
+		return err
+	})
+}
+
+// GetPriceList returns the exchange rate of currency, and the prices set in currency for the products with productIDs.
+func (s sqlStore) GetPriceList(ctx context.Context, currency string, productIDs []int) (*PriceList, error) {
+	result := &PriceList{Currency: currency, Overrides: make(map[int]models.Money)}
+
+	var rate string
+	err := s.conn().QueryRowContext(ctx, "SELECT rate FROM exchange_rates WHERE currency = ?", currency).Scan(&rate)
+	switch {
+	case err == nil:
+		var exchangeRate models.ExchangeRate
+		if exchangeRate, err = scanExchangeRate(currency, rate); err != nil {
+			return nil, err
+		}
+		result.Rate = exchangeRate.Rat()
+	case err != sql.ErrNoRows:
+		return nil, err
+	}
+	if len(productIDs) == 0 {
+		return result, nil
+	}
+
+	args := []any{currency}
+	for _, id := range productIDs {
+		args = append(args, id)
+	}
+	query := `
+	SELECT product_id, amount
+	FROM product_prices
+	WHERE currency = ? AND product_id IN (?` + strings.Repeat(", ?", len(productIDs)-1) + `)`
+	rows, err := s.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		price := models.Money{Currency: currency}
+		if err = rows.Scan(&id, &price); err != nil {
+			return nil, err
+		}
+		result.Overrides[id] = price
+	}
+	return result, rows.Err()
+}
+
+// scanExchangeRate returns the exchange rate of currency from the decimal rate stored in the database.
+// The rate is returned in its canonical form, since drivers may format it with trailing zeros or as a float.
+func scanExchangeRate(currency, rate string) (models.ExchangeRate, error) {
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok {
+		return models.ExchangeRate{}, fmt.Errorf("Invalid exchange rate %q of %s", rate, currency)
+	}
+	return models.ParseExchangeRate(currency, r.FloatString(8))
+}
+
+// variantColumns are the columns of the product_variants table in the order that scanVariant expects them.
+const variantColumns = "id, product_id, sku, options, price, stock_quantity"
+
+// scanVariant scans a row selected with variantColumns into a variant.
+func scanVariant(row scanner) (*models.Variant, error) {
+	result := &models.Variant{}
+	var options string
+	var price any
+	err := row.Scan(&result.ID, &result.ProductID, &result.SKU, &options, &price, &result.StockQuantity)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal([]byte(options), &result.Options); err != nil {
+		return nil, err
+	}
+	if price != nil {
+		result.Price = &models.Money{}
+		if err = result.Price.Scan(price); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// variantsOf returns the variants of the products with productIDs, ordered by ID, by product ID.
+func (s sqlStore) variantsOf(ctx context.Context, productIDs []int) (map[int][]models.Variant, error) {
+	result := make(map[int][]models.Variant)
+	if len(productIDs) == 0 {
+		return result, nil
+	}
+	args := make([]any, len(productIDs))
+	for i, id := range productIDs {
+		args[i] = id
+	}
+	query := `
+	SELECT ` + variantColumns + `
+	FROM product_variants
+	WHERE product_id IN (?` + strings.Repeat(", ?", len(productIDs)-1) + `)
+	ORDER BY id`
+	rows, err := s.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v *models.Variant
+		if v, err = scanVariant(rows); err != nil {
+			return nil, err
+		}
+		result[v.ProductID] = append(result[v.ProductID], *v)
+	}
+	return result, rows.Err()
+}
+
+// GetVariants returns the variants of a product, ordered by ID.
+func (s sqlStore) GetVariants(ctx context.Context, productID int) ([]models.Variant, error) {
+	if err := s.checkProduct(ctx, s.conn(), fmt.Sprintf("%s.GetVariants(%d)", s.name, productID), productID); err != nil {
+		return nil, err
+	}
+	variants, err := s.variantsOf(ctx, []int{productID})
+	if err != nil {
+		return nil, err
+	}
+	if variants[productID] == nil {
+		return []models.Variant{}, nil
+	}
+	return variants[productID], nil
+}
+
+// GetVariant returns a variant of a product by id.
+func (s sqlStore) GetVariant(ctx context.Context, productID, variantID int) (*models.Variant, error) {
+	operation := fmt.Sprintf("%s.GetVariant(%d)", s.name, variantID)
+	if err := s.checkProduct(ctx, s.conn(), operation, productID); err != nil {
+		return nil, err
+	}
+	query := `
+	SELECT ` + variantColumns + `
+	FROM product_variants
+	WHERE id = ? AND product_id = ?`
+	result, err := scanVariant(s.conn().QueryRowContext(ctx, query, variantID, productID))
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Operation: operation}
+	}
+	return result, err
+}
+
+// CreateVariant creates a variant of a product.
+func (s sqlStore) CreateVariant(ctx context.Context, productID int, variant *models.VariantRequest) (int, error) {
+	operation := fmt.Sprintf("%s.CreateVariant(%d)", s.name, productID)
+	v := variant.ToVariant(productID, 0)
+	options, err := json.Marshal(v.Options)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := s.checkProduct(ctx, tx, operation, productID); err != nil {
+			return err
+		}
+		query := `
+		INSERT INTO product_variants (product_id, sku, options, price, stock_quantity)
+		VALUES (?, ?, ?, ?, ?)`
+		result, err := tx.ExecContext(ctx, query, productID, v.SKU, string(options), v.Price, v.StockQuantity)
+		if err != nil {
+			return s.conflictError(err, operation, "sku", v.SKU)
+		}
+		id, err := result.LastInsertId()
+		v.ID = int(id)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return v.ID, nil
+}
+
+// UpdateVariant updates a variant of a product.
+func (s sqlStore) UpdateVariant(ctx context.Context, variant *models.Variant) error {
+	operation := fmt.Sprintf("%s.UpdateVariant(%d)", s.name, variant.ID)
+	options, err := json.Marshal(variant.Options)
+	if err != nil {
+		return err
+	}
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := s.checkProduct(ctx, tx, operation, variant.ProductID); err != nil {
+			return err
+		}
+		// The number of rows affected by an UPDATE is zero in MariaDB if nothing changed, so it is checked first.
+		var exists int
+		query := "SELECT 1 FROM product_variants WHERE id = ? AND product_id = ?" + s.forUpdate
+		err := tx.QueryRowContext(ctx, query, variant.ID, variant.ProductID).Scan(&exists)
+		if err == sql.ErrNoRows {
+			return &NotFoundError{Operation: operation}
+		}
+		if err != nil {
+			return err
+		}
+
+		query = `
+		UPDATE product_variants
+		SET sku = ?, options = ?, price = ?, stock_quantity = ?
+		WHERE id = ?`
+		_, err = tx.ExecContext(ctx, query, variant.SKU, string(options), variant.Price, variant.StockQuantity, variant.ID)
+		if err != nil {
+			return s.conflictError(err, operation, "sku", variant.SKU)
+		}
+		return nil
+	})
+}
+
+// DeleteVariant deletes a variant of a product by id.
+func (s sqlStore) DeleteVariant(ctx context.Context, productID, variantID int) error {
+	operation := fmt.Sprintf("%s.DeleteVariant(%d)", s.name, variantID)
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := s.checkProduct(ctx, tx, operation, productID); err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, "DELETE FROM product_variants WHERE id = ? AND product_id = ?", variantID, productID)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("Error getting rows affected: %s", err.Error())
+		}
+		if rowsAffected == 0 {
+			return &NotFoundError{Operation: operation}
+		}
+		return nil
+	})
+}
+
+// categoryColumns are the columns of the categories table in the order that scanCategory expects them.
+const categoryColumns = "id, parent_id, name, slug, position"
+
+// scanCategory scans a row selected with categoryColumns into a category.
+func scanCategory(row scanner) (*models.Category, error) {
+	result := &models.Category{}
+	var parentID sql.NullInt64
+	err := row.Scan(&result.ID, &parentID, &result.Name, &result.Slug, &result.Position)
+	if err != nil {
+		return nil, err
+	}
+	if parentID.Valid {
+		id := int(parentID.Int64)
+		result.ParentID = &id
+	}
+	return result, nil
+}
+
+// GetCategories returns every category, ordered by position and then ID.
+func (s sqlStore) GetCategories(ctx context.Context) ([]models.Category, error) {
+	rows, err := s.conn().QueryContext(ctx, "SELECT "+categoryColumns+" FROM categories ORDER BY position, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.Category{}
+	for rows.Next() {
+		var c *models.Category
+		if c, err = scanCategory(rows); err != nil {
+			return nil, err
+		}
+		result = append(result, *c)
+	}
+	return result, rows.Err()
+}
+
+// GetCategory returns a category by id.
+func (s sqlStore) GetCategory(ctx context.Context, id int) (*models.Category, error) {
+	row := s.conn().QueryRowContext(ctx, "SELECT "+categoryColumns+" FROM categories WHERE id = ?", id)
+	result, err := scanCategory(row)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Operation: fmt.Sprintf("%s.GetCategory(%d)", s.name, id)}
+	}
+	return result, err
+}
+
+// CreateCategory creates a category.
+func (s sqlStore) CreateCategory(ctx context.Context, category *models.CategoryRequest) (int, error) {
+	operation := fmt.Sprintf("%s.CreateCategory(%q)", s.name, category.Slug)
+	var id int
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := s.checkParent(ctx, tx, 0, category.ParentID); err != nil {
+			return err
+		}
+		query := `
+		INSERT INTO categories (parent_id, name, slug, position)
+		VALUES (?, ?, ?, ?)`
+		result, err := tx.ExecContext(ctx, query, category.ParentID, category.Name, category.Slug, category.Position)
+		if err != nil {
+			return s.conflictError(err, operation, "slug", category.Slug)
+		}
+		lastID, err := result.LastInsertId()
+		id = int(lastID)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// UpdateCategory updates a category, which may be moved to another parent.
+func (s sqlStore) UpdateCategory(ctx context.Context, category *models.Category) error {
+	operation := fmt.Sprintf("%s.UpdateCategory(%d)", s.name, category.ID)
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		var exists int
+		err := tx.QueryRowContext(ctx, "SELECT 1 FROM categories WHERE id = ?"+s.forUpdate, category.ID).Scan(&exists)
+		if err == sql.ErrNoRows {
+			return &NotFoundError{Operation: operation}
+		}
+		if err != nil {
+			return err
+		}
+		if err = s.checkParent(ctx, tx, category.ID, category.ParentID); err != nil {
+			return err
+		}
+
+		query := `
+		UPDATE categories
+		SET parent_id = ?, name = ?, slug = ?, position = ?
+		WHERE id = ?`
+		_, err = tx.ExecContext(ctx, query, category.ParentID, category.Name, category.Slug, category.Position, category.ID)
+		if err != nil {
+			return s.conflictError(err, operation, "slug", category.Slug)
+		}
+		return nil
+	})
+}
+
+// checkParent returns an UnknownCategoryError if parentID is not nil and the category does not exist,
+// or a CategoryCycleError if it is the category with id or one of its descendants.
+// The ancestors of the parent are walked up to the root to find out.
+func (s sqlStore) checkParent(ctx context.Context, q querier, id int, parentID *int) error {
+	if parentID == nil {
+		return nil
+	}
+	ancestor := sql.NullInt64{Int64: int64(*parentID), Valid: true}
+	for ancestor.Valid {
+		if int(ancestor.Int64) == id {
+			return &CategoryCycleError{ID: id, ParentID: *parentID}
+		}
+		current := ancestor.Int64
+		err := q.QueryRowContext(ctx, "SELECT parent_id FROM categories WHERE id = ?", current).Scan(&ancestor)
+		if err == sql.ErrNoRows && current == int64(*parentID) {
+			return &UnknownCategoryError{ID: *parentID}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteCategory deletes a category by id. Its children are moved to its parent.
+func (s sqlStore) DeleteCategory(ctx context.Context, id int) error {
+	operation := fmt.Sprintf("%s.DeleteCategory(%d)", s.name, id)
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		var parentID sql.NullInt64
+		err := tx.QueryRowContext(ctx, "SELECT parent_id FROM categories WHERE id = ?"+s.forUpdate, id).Scan(&parentID)
+		if err == sql.ErrNoRows {
+			return &NotFoundError{Operation: operation}
+		}
+		if err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, "UPDATE categories SET parent_id = ? WHERE parent_id = ?", parentID, id); err != nil {
+			return err
+		}
+		// The category is unassigned from its products by the foreign key of product_categories.
+		_, err = tx.ExecContext(ctx, "DELETE FROM categories WHERE id = ?", id)
+		return err
+	})
+}
+
+// SetProductCategories replaces the categories that a product is assigned to.
+func (s sqlStore) SetProductCategories(ctx context.Context, productID int, categoryIDs []int) error {
+	operation := fmt.Sprintf("%s.SetProductCategories(%d)", s.name, productID)
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := s.checkProduct(ctx, tx, operation, productID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM product_categories WHERE product_id = ?", productID); err != nil {
+			return err
+		}
+		assigned := make(map[int]bool, len(categoryIDs))
+		for _, id := range categoryIDs {
+			if assigned[id] {
+				continue
+			}
+			assigned[id] = true
+
+			var exists int
+			err := tx.QueryRowContext(ctx, "SELECT 1 FROM categories WHERE id = ?", id).Scan(&exists)
+			if err == sql.ErrNoRows {
+				return &UnknownCategoryError{ID: id}
+			}
+			if err != nil {
+				return err
+			}
+			query := "INSERT INTO product_categories (product_id, category_id) VALUES (?, ?)"
+			if _, err = tx.ExecContext(ctx, query, productID, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// categoriesOf returns the IDs of the categories of the products with productIDs, in ascending order, by product ID.
+func (s sqlStore) categoriesOf(ctx context.Context, productIDs []int) (map[int][]int, error) {
+	result := make(map[int][]int)
+	if len(productIDs) == 0 {
+		return result, nil
+	}
+	args := make([]any, len(productIDs))
+	for i, id := range productIDs {
+		args[i] = id
+	}
+	query := `
+	SELECT product_id, category_id
+	FROM product_categories
+	WHERE product_id IN (?` + strings.Repeat(", ?", len(productIDs)-1) + `)
+	ORDER BY product_id, category_id`
+	rows, err := s.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID, categoryID int
+		if err = rows.Scan(&productID, &categoryID); err != nil {
+			return nil, err
+		}
+		result[productID] = append(result[productID], categoryID)
+	}
+	return result, rows.Err()
+}
+
+// SetProductTags replaces the tags of a product.
+func (s sqlStore) SetProductTags(ctx context.Context, productID int, tags []string) error {
+	operation := fmt.Sprintf("%s.SetProductTags(%d)", s.name, productID)
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := s.checkProduct(ctx, tx, operation, productID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM product_tags WHERE product_id = ?", productID); err != nil {
+			return err
+		}
+		added := make(map[string]bool, len(tags))
+		for _, tag := range tags {
+			if added[tag] {
+				continue
+			}
+			added[tag] = true
+			if _, err := tx.ExecContext(ctx, "INSERT INTO product_tags (product_id, tag) VALUES (?, ?)", productID, tag); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SetProductAttributes replaces the attributes of a product.
+func (s sqlStore) SetProductAttributes(ctx context.Context, productID int, attributes map[string]any) error {
+	operation := fmt.Sprintf("%s.SetProductAttributes(%d)", s.name, productID)
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := s.checkProduct(ctx, tx, operation, productID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM product_attributes WHERE product_id = ?", productID); err != nil {
+			return err
+		}
+		for name, value := range attributes {
+			t, text, err := models.EncodeAttribute(value)
+			if err != nil {
+				return fmt.Errorf("%s: attribute %q: %w", operation, name, err)
+			}
+			query := "INSERT INTO product_attributes (product_id, name, type, value) VALUES (?, ?, ?, ?)"
+			if _, err = tx.ExecContext(ctx, query, productID, name, t, text); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// tagsOf returns the tags of the products with productIDs, in ascending order, by product ID.
+func (s sqlStore) tagsOf(ctx context.Context, productIDs []int) (map[int][]string, error) {
+	result := make(map[int][]string)
+	if len(productIDs) == 0 {
+		return result, nil
+	}
+	args := make([]any, len(productIDs))
+	for i, id := range productIDs {
+		args[i] = id
+	}
+	query := `
+	SELECT product_id, tag
+	FROM product_tags
+	WHERE product_id IN (?` + strings.Repeat(", ?", len(productIDs)-1) + `)
+	ORDER BY product_id, tag`
+	rows, err := s.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID int
+		var tag string
+		if err = rows.Scan(&productID, &tag); err != nil {
+			return nil, err
+		}
+		result[productID] = append(result[productID], tag)
+	}
+	return result, rows.Err()
+}
+
+// attributesOf returns the attributes of the products with productIDs by product ID.
+func (s sqlStore) attributesOf(ctx context.Context, productIDs []int) (map[int]map[string]any, error) {
+	result := make(map[int]map[string]any)
+	if len(productIDs) == 0 {
+		return result, nil
+	}
+	args := make([]any, len(productIDs))
+	for i, id := range productIDs {
+		args[i] = id
+	}
+	query := `
+	SELECT product_id, name, type, value
+	FROM product_attributes
+	WHERE product_id IN (?` + strings.Repeat(", ?", len(productIDs)-1) + `)`
+	rows, err := s.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID int
+		var name, text string
+		var t models.AttributeType
+		if err = rows.Scan(&productID, &name, &t, &text); err != nil {
+			return nil, err
+		}
+		var value any
+		if value, err = models.DecodeAttribute(t, text); err != nil {
+			return nil, err
+		}
+		if result[productID] == nil {
+			result[productID] = make(map[string]any)
+		}
+		result[productID][name] = value
+	}
+	return result, rows.Err()
+}
+
+// imageColumns are the columns of the product_images table in the order that scanImage expects them.
+const imageColumns = "id, product_id, blob_key, content_type, size, checksum, created_at"
+
+// scanImage scans a row selected with imageColumns into an image.
+func scanImage(row scanner) (*models.Image, error) {
+	result := &models.Image{}
+	err := row.Scan(&result.ID, &result.ProductID, &result.Key, &result.ContentType, &result.Size, &result.Checksum, &result.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	result.CreatedAt = result.CreatedAt.UTC()
+	return result, nil
+}
+
+// GetImages returns the images of a product, ordered by ID.
+func (s sqlStore) GetImages(ctx context.Context, productID int) ([]models.Image, error) {
+	if err := s.checkProduct(ctx, s.conn(), fmt.Sprintf("%s.GetImages(%d)", s.name, productID), productID); err != nil {
+		return nil, err
+	}
+	query := `
+	SELECT ` + imageColumns + `
+	FROM product_images
+	WHERE product_id = ?
+	ORDER BY id`
+	rows, err := s.conn().QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.Image{}
+	for rows.Next() {
+		var image *models.Image
+		if image, err = scanImage(rows); err != nil {
+			return nil, err
+		}
+		result = append(result, *image)
+	}
+	return result, rows.Err()
+}
+
+// GetImageKeys returns the keys of the images of a product, ordered by ID, even if it is in the trash.
+func (s sqlStore) GetImageKeys(ctx context.Context, productID int) ([]string, error) {
+	rows, err := s.conn().QueryContext(ctx, "SELECT blob_key FROM product_images WHERE product_id = ? ORDER BY id", productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []string{}
+	for rows.Next() {
+		var key string
+		if err = rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		result = append(result, key)
+	}
+	return result, rows.Err()
+}
+
+// GetImage returns an image of a product by id.
+func (s sqlStore) GetImage(ctx context.Context, productID, imageID int) (*models.Image, error) {
+	operation := fmt.Sprintf("%s.GetImage(%d)", s.name, imageID)
+	if err := s.checkProduct(ctx, s.conn(), operation, productID); err != nil {
+		return nil, err
+	}
+	query := `
+	SELECT ` + imageColumns + `
+	FROM product_images
+	WHERE id = ? AND product_id = ?`
+	result, err := scanImage(s.conn().QueryRowContext(ctx, query, imageID, productID))
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Operation: operation}
+	}
+	return result, err
+}
+
+// CreateImage creates the metadata of an image of a product.
+func (s sqlStore) CreateImage(ctx context.Context, image *models.Image) (*models.Image, error) {
+	operation := fmt.Sprintf("%s.CreateImage(%d)", s.name, image.ProductID)
+	result := *image
+	result.CreatedAt = now()
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := s.checkProduct(ctx, tx, operation, image.ProductID); err != nil {
+			return err
+		}
+		query := `
+		INSERT INTO product_images (product_id, blob_key, content_type, size, checksum, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`
+		inserted, err := tx.ExecContext(ctx, query,
+			result.ProductID, result.Key, result.ContentType, result.Size, result.Checksum, result.CreatedAt)
+		if err != nil {
+			return err
+		}
+		id, err := inserted.LastInsertId()
+		result.ID = int(id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DeleteImage deletes the metadata of an image of a product by id.
+func (s sqlStore) DeleteImage(ctx context.Context, productID, imageID int) error {
+	operation := fmt.Sprintf("%s.DeleteImage(%d)", s.name, imageID)
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := s.checkProduct(ctx, tx, operation, productID); err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, "DELETE FROM product_images WHERE id = ? AND product_id = ?", imageID, productID)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("Error getting rows affected: %s", err.Error())
+		}
+		if rowsAffected == 0 {
+			return &NotFoundError{Operation: operation}
+		}
+		return nil
+	})
+}
+
+// uniqueSlug returns the slug generated for a product with name (see models.ProductSlug),
+// with a suffix if it is already used by another product.
+func (s sqlStore) uniqueSlug(ctx context.Context, q querier, name string) (string, error) {
+	slug := models.ProductSlug(name)
+	// Slugs have no LIKE wildcards, so they do not need to be escaped.
+	rows, err := q.QueryContext(ctx, "SELECT slug FROM products WHERE slug = ? OR slug LIKE ?", slug, slug+"-%")
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	used := make(map[string]bool)
+	for rows.Next() {
+		var u string
+		if err = rows.Scan(&u); err != nil {
+			return "", err
+		}
+		used[u] = true
+	}
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+	return models.UniqueSlug(slug, func(slug string) bool { return used[slug] }), nil
+}
+
+// productConflictError returns a ConflictError for operation if err was caused by a duplicate SKU or slug
+// of the product with id, and otherwise returns err. Which of them is duplicated is found out with tx.
+func (s sqlStore) productConflictError(ctx context.Context, tx *sql.Tx, err error, operation string, id int, sku, slug string) error {
+	if s.duplicate == nil || !s.duplicate(err) {
+		return err
+	}
+	if sku != "" {
+		var exists int
+		query := "SELECT 1 FROM products WHERE sku = ? AND id <> ?"
+		if tx.QueryRowContext(ctx, query, sku, id).Scan(&exists) == nil {
+			return &ConflictError{Operation: operation, Field: "sku", Value: sku}
+		}
+	}
+	return &ConflictError{Operation: operation, Field: "slug", Value: slug}
+}
+
+// conflictError returns a ConflictError for operation if err was caused by a duplicate value of field,
+// and otherwise returns err.
+func (s sqlStore) conflictError(err error, operation, field, value string) error {
+	if s.duplicate != nil && s.duplicate(err) {
+		return &ConflictError{Operation: operation, Field: field, Value: value}
+	}
+	return err
+}
+
+// inTx calls fn with a new transaction, which is committed if fn succeeds and rolled back otherwise.
+// The transaction is attempted again (see retryIdempotent) if it fails with a retryable error before it is committed.
+// If s is already in a transaction, fn is called with it instead, and it is left to be committed by its owner.
+func (s sqlStore) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+	err := s.retryIdempotent(ctx, func() error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback() //nolint:errcheck // the rollback is a no-op after a commit
+
+		if err = fn(tx); err != nil {
+			return err
+		}
+		if err = tx.Commit(); err != nil {
+			return &commitError{err: err}
+		}
+		return nil
+	})
+	var commitErr *commitError
+	if errors.As(err, &commitErr) {
+		return commitErr.err
+	}
+	return err
+}
+
+// commitError is an error returned by committing a transaction. It is never retried, since the transaction
+// may have been committed even so (eg. if the connection was lost while waiting for the result).
+type commitError struct {
+	err error
+}
+
+func (e *commitError) Error() string {
+	return e.err.Error()
+}
+
+// retryIdempotent calls fn, which must be safe to call again after it fails, and retries it with the retry policy
+// of s while it fails with a retryable error. fn is only called once within a transaction, which is retried as
+// a whole by its owner instead, or if s has no retryable errors.
+func (s sqlStore) retryIdempotent(ctx context.Context, fn func() error) error {
+	if s.tx != nil || s.retryable == nil {
+		return fn()
+	}
+	return s.retry.Do(ctx, func(err error) bool {
+		var commitErr *commitError
+		return !errors.As(err, &commitErr) && s.retryable(err)
+	}, fn)
+}
+
+// withTx calls fn with a view of s in a new transaction, which is committed if fn succeeds and rolled back otherwise.
+// The transaction is attempted again if it fails with a retryable error (see inTx).
+// If s is already in a transaction, fn is called with s instead.
+func (s sqlStore) withTx(ctx context.Context, fn func(tx sqlStore) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		view := s
+		view.tx = tx
+		return fn(view)
+	})
+}
+
+// changeProduct calls change with the product with id (or nil if there is none, even in the trash),
+// and records the change made by operation in the history of the product.
+// The product is locked until the change is committed, and the product after the change is returned.
+func (s sqlStore) changeProduct(
+	ctx context.Context, operation models.ChangeOperation, id int, change func(tx *sql.Tx, before *models.Product) error,
+) (*models.Product, error) {
+	var after *models.Product
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := s.lockProduct(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err = change(tx, before); err != nil {
+			return err
+		}
+		after, err = s.lockProduct(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		return s.recordChange(ctx, tx, newProductChange(ctx, operation, id, before, after))
+	})
+	return after, err
+}
+
+// lockProduct returns the product with id, even if it is in the trash, and locks it until tx is done.
+// Nil is returned if there is no product with id.
+func (s sqlStore) lockProduct(ctx context.Context, tx *sql.Tx, id int) (*models.Product, error) {
+	query := `
+	SELECT ` + productColumns + `
+	FROM products
+	WHERE id = ?` + s.forUpdate
+	result, err := scanProduct(tx.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return result, err
+}
+
+// recordChange appends change to the history of its product.
+func (s sqlStore) recordChange(ctx context.Context, tx *sql.Tx, change *models.ProductChange) error {
+	before, err := marshalState(change.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshalState(change.After)
+	if err != nil {
+		return err
+	}
+	query := `
+	INSERT INTO product_history (product_id, operation, before_state, after_state, changed_at, actor, request_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err = tx.ExecContext(ctx, query, change.ProductID, change.Operation, before, after,
+		change.ChangedAt, change.Actor, change.RequestID)
+	return err
+}
+
+// GetProductHistory returns a page of the changes made to the product with id, from the most recent.
+// A NotFoundError is returned if the product has never existed.
+func (s sqlStore) GetProductHistory(ctx context.Context, id int, query *HistoryQuery) (*HistoryPage, error) {
+	after, err := query.after()
+	if err != nil {
+		return nil, err
+	}
+	limit := query.limit()
+
+	conditions := []string{"product_id = ?"}
+	args := []any{id}
+	if after != 0 {
+		conditions = append(conditions, "id < ?")
+		args = append(args, after)
+	}
+	if query != nil && query.AsOf != nil {
+		conditions = append(conditions, "changed_at <= ?")
+		args = append(args, query.AsOf.UTC())
+	}
+	stmt := `
+	SELECT id, product_id, operation, before_state, after_state, changed_at, actor, request_id
+	FROM product_history
+	WHERE ` + strings.Join(conditions, " AND ") + `
+	ORDER BY id DESC
+	LIMIT ?`
+	// One extra row is selected to find out whether there is a next page.
+	rows, err := s.conn().QueryContext(ctx, stmt, append(args, limit+1)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &HistoryPage{Changes: []models.ProductChange{}}
+	for rows.Next() {
+		var change models.ProductChange
+		var before, after sql.NullString
+		err = rows.Scan(&change.ID, &change.ProductID, &change.Operation, &before, &after,
+			&change.ChangedAt, &change.Actor, &change.RequestID)
+		if err != nil {
+			return nil, err
+		}
+		if change.Before, err = unmarshalState(before); err != nil {
+			return nil, err
+		}
+		if change.After, err = unmarshalState(after); err != nil {
+			return nil, err
+		}
+		change.ChangedAt = change.ChangedAt.UTC()
+		result.Changes = append(result.Changes, change)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(result.Changes) > limit {
+		result.Changes = result.Changes[:limit]
+		result.NextCursor = newHistoryCursor(&result.Changes[limit-1])
+	}
+	if len(result.Changes) == 0 && after == 0 {
+		// The product may still exist without any history, such as when it was created before history was recorded.
+		var exists int
+		stmt = "SELECT 1 FROM products WHERE id = ? UNION SELECT 1 FROM product_history WHERE product_id = ?"
+		err = s.conn().QueryRowContext(ctx, stmt, id, id).Scan(&exists)
+		if err == sql.ErrNoRows {
+			return nil, &NotFoundError{Operation: fmt.Sprintf("%s.GetProductHistory(%d)", s.name, id)}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// marshalState returns the JSON of product p as it is stored in the history, or NULL if p is nil.
+func marshalState(p *models.Product) (sql.NullString, error) {
+	if p == nil {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(p)
+	return sql.NullString{String: string(b), Valid: true}, err
+}
+
+// unmarshalState returns the product stored in the history as JSON by marshalState.
+func unmarshalState(s sql.NullString) (*models.Product, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	p := &models.Product{}
+	err := json.Unmarshal([]byte(s.String), p)
+	return p, err
+}
+
+// Ping checks that the database can be reached.
+func (s sqlStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close closes the database. It does nothing within a transaction, which is closed by WithTx.
+func (s sqlStore) Close() error {
+	if s.tx != nil {
+		return nil
+	}
+	return s.db.Close()
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/schema"
+	"github.com/Broderick-Westrope/e-gommerce/migrations"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQLite is an implementation of the Storage interface using SQLite.
+// It needs no external database server, which makes it suitable for local development and CI.
+type SQLite struct {
+	sqlStore
+}
+
+// OpenSQLite opens the SQLite database at dsn (a file path, or ":memory:").
+// Foreign keys are enforced on every connection, since the schema relies on them to cascade deletes.
+func OpenSQLite(ctx context.Context, dsn string) (*sql.DB, error) {
+	// The pragma is given in the DSN rather than executed, since it only applies to the connection that runs it.
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	db, err := sql.Open("sqlite", dsn+separator+"_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite only allows one writer at a time, and every connection to ":memory:" opens a separate database.
+	db.SetMaxOpenConns(1)
+
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// NewSQLite returns a SQLite storage using db. If autoMigrate is true, its schema is first migrated to the latest
+// version, and otherwise it is left as it is (eg. so that it can be migrated with a schema.Migrator).
+func NewSQLite(ctx context.Context, db *sql.DB, autoMigrate bool) (*SQLite, error) {
+	if autoMigrate {
+		migrator, err := schema.NewMigrator(db, migrations.SQLite())
+		if err != nil {
+			return nil, err
+		}
+		if err = migrator.Up(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return &SQLite{
+		sqlStore: sqlStore{db: db, name: "SQLite", duplicate: isSQLiteDuplicate},
+	}, nil
+}
+
+// isSQLiteDuplicate returns whether err was caused by a violation of a unique index or primary key.
+func isSQLiteDuplicate(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code()
+	return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+// SearchProducts returns up to limit products that match the search query, ordered by descending relevance.
+// SQLite has no FULLTEXT index, so the products containing any of the query terms are scored
+// using the same naive scoring as Memory (see scoreProduct).
+func (s SQLite) SearchProducts(ctx context.Context, query string, limit int) ([]models.SearchResult, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return []models.SearchResult{}, nil
+	}
+
+	var conditions []string
+	var args []any
+	for _, term := range terms {
+		pattern := "%" + likeEscaper.Replace(term) + "%"
+		conditions = append(conditions, "name LIKE ? ESCAPE '!' OR description LIKE ? ESCAPE '!'")
+		args = append(args, pattern, pattern)
+	}
+	stmt := `
+	SELECT ` + productColumns + `
+	FROM products
+	WHERE deleted_at IS NULL AND (` + strings.Join(conditions, " OR ") + `)
+	ORDER BY id`
+	rows, err := s.conn().QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.SearchResult{}
+	for rows.Next() {
+		var row *models.Product
+		row, err = scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		if score := scoreProduct(row, terms); score > 0 {
+			result = append(result, newSearchResult(row, terms, score))
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Score > result[j].Score
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// WithTx calls fn with a view of s in a transaction, which is committed if fn succeeds and rolled back otherwise.
+// SQLite serializes write transactions instead of deadlocking, so they are never retried.
+func (s SQLite) WithTx(ctx context.Context, fn func(tx Storage) error) error {
+	return s.withTx(ctx, func(tx sqlStore) error {
+		return fn(SQLite{sqlStore: tx})
+	})
+}
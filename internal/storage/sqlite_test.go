@@ -0,0 +1,234 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/schema"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+	"github.com/Broderick-Westrope/e-gommerce/migrations"
+)
+
+// Returns a SQLite storage backed by a new in-memory database.
+func newSQLite(t *testing.T) *storage.SQLite {
+	t.Helper()
+
+	db, err := storage.OpenSQLite(context.Background(), ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := storage.NewSQLite(context.Background(), db, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// Tests that foreign keys are enforced on every connection to a SQLite database, not only the first.
+func TestOpenSQLite_ForeignKeys(t *testing.T) {
+	ctx := context.Background()
+	db, err := storage.OpenSQLite(ctx, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	// Connections are closed after each use, so that each query opens a new one.
+	db.SetMaxIdleConns(0)
+
+	for i := 0; i < 2; i++ {
+		var enabled int
+		if err = db.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&enabled); err != nil {
+			t.Fatal(err)
+		}
+		checkEqual(t, enabled, 1, "Foreign Keys")
+	}
+}
+
+// Tests that a SQLite database that has been migrated down stays at that version when it is reopened
+// without migrating it, as the migrate subcommand does.
+func TestNewSQLite_AutoMigrate(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	open := func(autoMigrate bool) (*storage.SQLite, *schema.Migrator) {
+		t.Helper()
+		db, err := storage.OpenSQLite(ctx, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := storage.NewSQLite(ctx, db, autoMigrate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		migrator, err := schema.NewMigrator(db, migrations.SQLite())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s, migrator
+	}
+	current := func(migrator *schema.Migrator) int {
+		t.Helper()
+		version, err := migrator.Current(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return version
+	}
+
+	s, migrator := open(true)
+	latest := migrator.Latest()
+	checkEqual(t, current(migrator), latest, "Version After Auto Migration")
+	if err := migrator.Down(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s, migrator = open(false)
+	checkEqual(t, current(migrator), latest-1, "Version After Reopening")
+	s.Close()
+}
+
+// Tests the product lifecycle against a SQLite database.
+func TestSQLite_Products(t *testing.T) {
+	ctx := context.Background()
+	s := newSQLite(t)
+
+	requests := []models.CreateProductRequest{
+		{Name: "Cotton Shirt", Description: "A 100% cotton shirt.", Price: models.NewMoney(1999, models.DefaultCurrency), StockQuantity: 5},
+		{Name: "Wool Hat", Price: models.NewMoney(999, models.DefaultCurrency), StockQuantity: 0},
+		{Name: "Socks", Description: "Cotton blend socks.", Price: models.NewMoney(499, models.DefaultCurrency), StockQuantity: 12},
+	}
+	for i := range requests {
+		id, err := s.CreateProduct(ctx, &requests[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkEqual(t, id, i+1, "Created ID")
+	}
+
+	product, err := s.GetProduct(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, *product, models.Product{ID: 2, Name: "Wool Hat", Slug: "wool-hat", Price: models.NewMoney(999, models.DefaultCurrency), Version: 1}, "Product")
+
+	inStock := true
+	query := &storage.ProductQuery{Limit: 1, InStock: &inStock, Sort: storage.ProductSort{Field: storage.SortByPrice}}
+	var ids []int
+	for {
+		var page *storage.ProductPage
+		page, err = s.GetProducts(ctx, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range page.Products {
+			ids = append(ids, p.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.After = page.NextCursor
+	}
+	checkEqual(t, ids, []int{3, 1}, "Listed IDs")
+
+	query = &storage.ProductQuery{Search: "100%"}
+	page, err := s.GetProducts(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(page.Products), 1, "Escaped Search Length")
+
+	results, err := s.SearchProducts(ctx, "cotton", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(results), 2, "Search Results Length")
+	checkEqual(t, results[0].Product.ID, 1, "Most Relevant ID")
+
+	product.Description = sql.NullString{String: "Warm.", Valid: true}
+	if err = s.UpdateProduct(ctx, product); err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, product.Version, 2, "Updated Version")
+
+	var conflictErr *storage.VersionConflictError
+	err = s.UpdateProduct(ctx, &models.Product{ID: 2, Name: "Wool Hat", Version: 1})
+	checkEqual(t, errors.As(err, &conflictErr), true, "Update Stale Version Conflict")
+	checkEqual(t, conflictErr.Actual, 2, "Conflicting Version")
+	err = s.DeleteProduct(ctx, 2, 1)
+	checkEqual(t, errors.As(err, &conflictErr), true, "Delete Stale Version Conflict")
+
+	if err = s.DeleteProduct(ctx, 3, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	var notFoundErr *storage.NotFoundError
+	_, err = s.GetProduct(ctx, 3)
+	checkEqual(t, errors.As(err, &notFoundErr), true, "Get Deleted Not Found")
+	err = s.UpdateProduct(ctx, &models.Product{ID: 3})
+	checkEqual(t, errors.As(err, &notFoundErr), true, "Update Deleted Not Found")
+	err = s.DeleteProduct(ctx, 3, storage.AnyVersion)
+	checkEqual(t, errors.As(err, &notFoundErr), true, "Delete Deleted Not Found")
+	results, err = s.SearchProducts(ctx, "socks", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(results), 0, "Search Deleted Results Length")
+
+	trash, err := s.GetProducts(ctx, &storage.ProductQuery{Deleted: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(trash.Products), 1, "Trash Length")
+	checkEqual(t, trash.Products[0].ID, 3, "Trashed ID")
+	checkEqual(t, trash.Products[0].Version, 2, "Trashed Version")
+	checkEqual(t, trash.Products[0].DeletedAt != nil, true, "Trashed Has DeletedAt")
+
+	if err = s.RestoreProduct(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	product, err = s.GetProduct(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, product.Version, 3, "Restored Version")
+	checkEqual(t, product.DeletedAt == nil, true, "Restored Has No DeletedAt")
+	err = s.RestoreProduct(ctx, 3)
+	checkEqual(t, errors.As(err, &notFoundErr), true, "Restore Not Deleted Not Found")
+
+	if err = s.DeleteProduct(ctx, 3, storage.AnyVersion); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.PurgeProduct(ctx, 3, 4); err != nil {
+		t.Fatal(err)
+	}
+	err = s.RestoreProduct(ctx, 3)
+	checkEqual(t, errors.As(err, &notFoundErr), true, "Restore Purged Not Found")
+	err = s.PurgeProduct(ctx, 3, storage.AnyVersion)
+	checkEqual(t, errors.As(err, &notFoundErr), true, "Purge Purged Not Found")
+
+	history, err := s.GetProductHistory(ctx, 3, &storage.HistoryQuery{Limit: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var operations []models.ChangeOperation
+	for _, change := range history.Changes {
+		operations = append(operations, change.Operation)
+	}
+	checkEqual(t, operations, []models.ChangeOperation{"purge", "delete", "restore", "delete"}, "History Operations")
+	checkEqual(t, history.Changes[0].After == nil, true, "Purged Has No After")
+	checkEqual(t, history.Changes[1].After.Version, 4, "Deleted After Version")
+	history, err = s.GetProductHistory(ctx, 3, &storage.HistoryQuery{After: history.NextCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(history.Changes), 1, "History Last Page Length")
+	checkEqual(t, history.Changes[0].Before == nil, true, "Created Has No Before")
+	checkEqual(t, history.Changes[0].After.Name, "Socks", "Created After Name")
+	_, err = s.GetProductHistory(ctx, 4, nil)
+	checkEqual(t, errors.As(err, &notFoundErr), true, "History Not Found")
+}
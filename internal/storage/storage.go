@@ -1,18 +1,183 @@
 package storage
 
-import "github.com/Broderick-Westrope/e-gommerce/internal/models"
+import (
+	"context"
+	"time"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+)
+
+// AnyVersion is passed as the version of a resource to modify it regardless of its current version.
+const AnyVersion = 0
 
 // Storage is an interface that defines the methods that a storage engine must implement.
+//
+// WithTx runs fn as a unit of work: the changes that fn makes through tx are committed together if it returns nil,
+// and are all rolled back if it returns an error (which WithTx then returns).
+// fn must only use tx, and may be called more than once if the storage engine retries the transaction,
+// so it must not have other side effects. Calling WithTx on tx runs fn in the same transaction.
 type Storage interface {
 	ProductStorage
+	ReservationStorage
+	PriceStorage
+	VariantStorage
+	CategoryStorage
+	AttributeStorage
+	ImageStorage
+	WithTx(ctx context.Context, fn func(tx Storage) error) error
 }
 
 // ProductStorage is an interface that defines the methods that a product storage engine must implement.
+// Every method takes the context of the calling request so that slow operations are cancelled when it is done.
+//
+// DeleteProduct moves a product to the trash, from where it can be restored with RestoreProduct.
+// Products in the trash are excluded from every method except PurgeProduct and GetProducts with ProductQuery.Deleted,
+// which lists the trash. PurgeProduct deletes a product permanently, whether or not it is in the trash.
+//
+// UpdateProduct, DeleteProduct and PurgeProduct only modify a product if its version is the expected version
+// (or AnyVersion), and otherwise return a VersionConflictError. UpdateProduct expects product.Version,
+// and sets it to the new version. Moving a product to and from the trash also increments its version.
+//
+// The SKU and slug of a product must be unique among every product, including those in the trash, and creating
+// or updating a product with a SKU or slug that is already used returns a ConflictError. CreateProduct generates
+// a unique slug from the name of a product that has none, and UpdateProduct keeps the slug of a product that has none.
+//
+// Each of these changes is recorded in the history of the product along with the Audit held by ctx,
+// atomically with the change itself. The history is kept after the product is purged.
+//
+// Ping returns an error if the storage engine cannot currently be used (eg. its database is unreachable).
 type ProductStorage interface {
-	GetProduct(id int) (*models.Product, error)
-	GetProducts() (*[]models.Product, error)
-	CreateProduct(product *models.CreateProductRequest) (int, error)
-	UpdateProduct(product *models.Product) error
-	DeleteProduct(id int) error
+	GetProduct(ctx context.Context, id int) (*models.Product, error)
+	GetProductBySlug(ctx context.Context, slug string) (*models.Product, error)
+	GetProductBySKU(ctx context.Context, sku string) (*models.Product, error)
+	GetProducts(ctx context.Context, query *ProductQuery) (*ProductPage, error)
+	SearchProducts(ctx context.Context, query string, limit int) ([]models.SearchResult, error)
+	CreateProduct(ctx context.Context, product *models.CreateProductRequest) (int, error)
+	UpdateProduct(ctx context.Context, product *models.Product) error
+	DeleteProduct(ctx context.Context, id int, version int) error
+	RestoreProduct(ctx context.Context, id int) error
+	PurgeProduct(ctx context.Context, id int, version int) error
+	GetProductHistory(ctx context.Context, id int, query *HistoryQuery) (*HistoryPage, error)
+	Ping(ctx context.Context) error
 	Close() error
 }
+
+// ReservationStorage is an interface that defines the methods that a stock reservation storage engine must implement.
+//
+// ReserveStock takes quantity from the stock of a product and holds it in a pending reservation that expires after ttl,
+// or returns an InsufficientStockError if the product has less stock. The stock is returned to the product
+// if the reservation is released with ReleaseStock, or if it expires before it is committed with CommitReservation.
+// Expired reservations are released lazily, when stock of their product is next reserved or when they are used.
+// Using a reservation that is not pending returns a ReservationStateError.
+// Reserving and returning stock increments the version of the product, and is recorded in its history.
+type ReservationStorage interface {
+	ReserveStock(ctx context.Context, productID, quantity int, ttl time.Duration) (*models.Reservation, error)
+	ReleaseStock(ctx context.Context, productID, reservationID int) error
+	CommitReservation(ctx context.Context, productID, reservationID int) error
+}
+
+// PriceStorage is an interface that defines the methods that a price list storage engine must implement.
+//
+// Product prices are stored in the DefaultCurrency. A product may also have a price set in other currencies,
+// which overrides converting its price using the exchange rate of the currency. Setting a price or exchange rate
+// replaces the existing one. Products in the trash are excluded, and their prices are deleted when they are purged.
+//
+// GetPriceList returns what is needed to price the products with productIDs in currency, ignoring unknown IDs.
+type PriceStorage interface {
+	GetPrices(ctx context.Context, productID int) ([]models.Money, error)
+	SetPrice(ctx context.Context, productID int, price models.Money) error
+	DeletePrice(ctx context.Context, productID int, currency string) error
+	GetExchangeRates(ctx context.Context) ([]models.ExchangeRate, error)
+	SetExchangeRate(ctx context.Context, rate models.ExchangeRate) error
+	GetPriceList(ctx context.Context, currency string, productIDs []int) (*PriceList, error)
+}
+
+// VariantStorage is an interface that defines the methods that a product variant storage engine must implement.
+//
+// Variants belong to a product, and are returned with it by GetProduct and GetProducts. They are excluded while
+// the product is in the trash, and are deleted when it is purged. The SKU of a variant must be unique among every
+// variant, and creating or updating a variant with a SKU that is already used returns a ConflictError.
+type VariantStorage interface {
+	GetVariants(ctx context.Context, productID int) ([]models.Variant, error)
+	GetVariant(ctx context.Context, productID, variantID int) (*models.Variant, error)
+	CreateVariant(ctx context.Context, productID int, variant *models.VariantRequest) (int, error)
+	UpdateVariant(ctx context.Context, variant *models.Variant) error
+	DeleteVariant(ctx context.Context, productID, variantID int) error
+}
+
+// CategoryStorage is an interface that defines the methods that a product category storage engine must implement.
+//
+// Categories form a tree, and GetCategories returns every category ordered by position and then ID.
+// The parent of a category must exist, or an UnknownCategoryError is returned, and a category cannot be moved
+// below itself, or a CategoryCycleError is returned. The slug of a category must be unique among every category,
+// and creating or updating a category with a slug that is already used returns a ConflictError.
+// Deleting a category moves its children to its parent, and unassigns it from its products.
+//
+// SetProductCategories replaces the categories that a product is assigned to, which are returned with it
+// (ordered by ID) by GetProduct and GetProducts. An UnknownCategoryError is returned if a category does not exist.
+type CategoryStorage interface {
+	GetCategories(ctx context.Context) ([]models.Category, error)
+	GetCategory(ctx context.Context, id int) (*models.Category, error)
+	CreateCategory(ctx context.Context, category *models.CategoryRequest) (int, error)
+	UpdateCategory(ctx context.Context, category *models.Category) error
+	DeleteCategory(ctx context.Context, id int) error
+	SetProductCategories(ctx context.Context, productID int, categoryIDs []int) error
+}
+
+// AttributeStorage is an interface that defines the methods that a product tag and attribute storage engine
+// must implement.
+//
+// SetProductTags and SetProductAttributes replace the tags and attributes of a product, which are returned with it
+// by GetProduct and GetProducts. Duplicate tags are stored once. Attribute values must be strings, numbers or
+// booleans (see models.EncodeAttribute), and numbers are returned as float64.
+type AttributeStorage interface {
+	SetProductTags(ctx context.Context, productID int, tags []string) error
+	SetProductAttributes(ctx context.Context, productID int, attributes map[string]any) error
+}
+
+// ImageStorage is an interface that defines the methods that a product image storage engine must implement.
+//
+// Only the metadata of images is stored, and their content is kept in a BlobStore under their key.
+// CreateImage returns the image with its ID and creation time. Images are excluded while their product is
+// in the trash, and their metadata is deleted when it is purged. GetImageKeys returns the keys of the images
+// of a product even if it is in the trash, so that their content can be deleted when it is purged.
+type ImageStorage interface {
+	GetImages(ctx context.Context, productID int) ([]models.Image, error)
+	GetImageKeys(ctx context.Context, productID int) ([]string, error)
+	GetImage(ctx context.Context, productID, imageID int) (*models.Image, error)
+	CreateImage(ctx context.Context, image *models.Image) (*models.Image, error)
+	DeleteImage(ctx context.Context, productID, imageID int) error
+}
+
+// readPrimaryKey is the context key that marks reads that must be made from the primary database.
+type readPrimaryKey struct{}
+
+// ReadPrimary returns a copy of ctx whose reads are made from the primary database, rather than a replica or cache,
+// so that they see every write that has been committed (eg. to read a product before changing it).
+func ReadPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readPrimaryKey{}, true)
+}
+
+// readsPrimary returns whether ctx was returned by ReadPrimary.
+func readsPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(readPrimaryKey{}).(bool)
+	return primary
+}
+
+// checkVersion returns an error if product p cannot be modified by operation when it is expected to have version:
+// a NotFoundError if p is nil or in the trash (unless trashed is true), or a VersionConflictError.
+func checkVersion(operation string, p *models.Product, version int, trashed bool) error {
+	if p == nil || (p.DeletedAt != nil && !trashed) {
+		return &NotFoundError{Operation: operation}
+	}
+	if version != AnyVersion && version != p.Version {
+		return &VersionConflictError{Operation: operation, Expected: version, Actual: p.Version}
+	}
+	return nil
+}
+
+// now returns the current time in UTC, truncated to microseconds, which is the precision of the times stored by MariaDB.
+// It is used for every time that is stored so that the storage engines agree.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
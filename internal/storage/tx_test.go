@@ -0,0 +1,81 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+)
+
+// Tests that the changes made in a transaction are committed together, or not at all, by every storage engine.
+func TestStorage_WithTx(t *testing.T) {
+	errRollback := errors.New("rollback")
+
+	for _, engine := range testEngines() {
+		t.Run(engine.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := engine.storage(t)
+			id, err := s.CreateProduct(ctx, &models.CreateProductRequest{Name: "Test Product", StockQuantity: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// A failed transaction leaves the storage as it was.
+			err = s.WithTx(ctx, func(tx storage.Storage) error {
+				if _, err := tx.CreateProduct(ctx, &models.CreateProductRequest{Name: "Test Product 2"}); err != nil {
+					return err
+				}
+				product, err := tx.GetProduct(ctx, id)
+				if err != nil {
+					return err
+				}
+				product.StockQuantity = 0
+				if err = tx.UpdateProduct(ctx, product); err != nil {
+					return err
+				}
+				return errRollback
+			})
+			checkEqual(t, errors.Is(err, errRollback), true, "Rollback Error")
+
+			page, err := s.GetProducts(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, len(page.Products), 1, "Products After Rollback")
+			checkEqual(t, page.Products[0].StockQuantity, 10, "Stock After Rollback")
+			history, err := s.GetProductHistory(ctx, id, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, len(history.Changes), 1, "History After Rollback")
+
+			// A successful transaction commits every change, including those made in a nested transaction.
+			var newID int
+			err = s.WithTx(ctx, func(tx storage.Storage) error {
+				if err := tx.DeleteProduct(ctx, id, storage.AnyVersion); err != nil {
+					return err
+				}
+				return tx.WithTx(ctx, func(tx storage.Storage) error {
+					var err error
+					newID, err = tx.CreateProduct(ctx, &models.CreateProductRequest{Name: "Test Product 3"})
+					return err
+				})
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			page, err = s.GetProducts(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, len(page.Products), 1, "Products After Commit")
+			checkEqual(t, page.Products[0].ID, newID, "Product After Commit")
+			var notFoundErr *storage.NotFoundError
+			_, err = s.GetProduct(ctx, id)
+			checkEqual(t, errors.As(err, &notFoundErr), true, "Deleted After Commit")
+		})
+	}
+}
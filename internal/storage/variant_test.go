@@ -0,0 +1,105 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Broderick-Westrope/e-gommerce/internal/models"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
+)
+
+// Tests that variants are created, embedded in their products, updated and deleted consistently by every storage engine.
+func TestStorage_Variants(t *testing.T) {
+	for _, engine := range testEngines() {
+		t.Run(engine.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := engine.storage(t)
+			for i := 0; i < 2; i++ {
+				_, err := s.CreateProduct(ctx, &models.CreateProductRequest{
+					Name: "T-Shirt", Price: models.NewMoney(2000, ""), StockQuantity: 1,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			price := models.NewMoney(2500, models.DefaultCurrency)
+			small, err := s.CreateVariant(ctx, 1, &models.VariantRequest{
+				SKU: "TSHIRT-S", Options: map[string]string{"size": "S"}, StockQuantity: 3,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			large, err := s.CreateVariant(ctx, 1, &models.VariantRequest{
+				SKU: "TSHIRT-L", Options: map[string]string{"size": "L"}, Price: &price, StockQuantity: 4,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = s.CreateVariant(ctx, 2, &models.VariantRequest{SKU: "TSHIRT-S"})
+			checkEqual(t, errors.As(err, new(*storage.ConflictError)), true, "Create Duplicate SKU")
+			_, err = s.CreateVariant(ctx, 3, &models.VariantRequest{SKU: "TSHIRT-M"})
+			checkEqual(t, errors.As(err, new(*storage.NotFoundError)), true, "Create Variant Of Unknown Product")
+
+			product, err := s.GetProduct(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := []models.Variant{
+				{ID: small, ProductID: 1, SKU: "TSHIRT-S", Options: map[string]string{"size": "S"}, StockQuantity: 3},
+				{ID: large, ProductID: 1, SKU: "TSHIRT-L", Options: map[string]string{"size": "L"}, Price: &price, StockQuantity: 4},
+			}
+			checkEqual(t, product.Variants, expected, "Embedded Variants")
+			checkEqual(t, product.TotalStockQuantity, 8, "Total Stock Quantity")
+			page, err := s.GetProducts(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, page.Products[0].Variants, expected, "Listed Variants")
+			checkEqual(t, page.Products[1].Variants == nil, true, "Product Without Variants")
+
+			// The SKU of a variant may be kept, but not changed to the SKU of another variant.
+			updated := expected[0].Copy()
+			updated.StockQuantity = 5
+			if err = s.UpdateVariant(ctx, &updated); err != nil {
+				t.Fatal(err)
+			}
+			updated.SKU = "TSHIRT-L"
+			err = s.UpdateVariant(ctx, &updated)
+			checkEqual(t, errors.As(err, new(*storage.ConflictError)), true, "Update Duplicate SKU")
+			updated.ProductID = 2
+			err = s.UpdateVariant(ctx, &updated)
+			checkEqual(t, errors.As(err, new(*storage.NotFoundError)), true, "Update Variant Of Other Product")
+			variant, err := s.GetVariant(ctx, 1, small)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, variant.StockQuantity, 5, "Updated Stock Quantity")
+
+			if err = s.DeleteVariant(ctx, 1, large); err != nil {
+				t.Fatal(err)
+			}
+			err = s.DeleteVariant(ctx, 1, large)
+			checkEqual(t, errors.As(err, new(*storage.NotFoundError)), true, "Delete Deleted Variant")
+			variants, err := s.GetVariants(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEqual(t, len(variants), 1, "Variants After Delete")
+
+			// Variants are hidden with their product in the trash, and deleted when it is purged.
+			if err = s.DeleteProduct(ctx, 1, storage.AnyVersion); err != nil {
+				t.Fatal(err)
+			}
+			_, err = s.GetVariants(ctx, 1)
+			checkEqual(t, errors.As(err, new(*storage.NotFoundError)), true, "Get Variants Of Trashed Product")
+			if err = s.PurgeProduct(ctx, 1, storage.AnyVersion); err != nil {
+				t.Fatal(err)
+			}
+			if _, err = s.CreateVariant(ctx, 2, &models.VariantRequest{SKU: "TSHIRT-S"}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -1,17 +1,59 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/Broderick-Westrope/e-gommerce/cmd/migrate"
 	"github.com/Broderick-Westrope/e-gommerce/cmd/web"
 	"github.com/Broderick-Westrope/e-gommerce/internal/config"
+	"github.com/Broderick-Westrope/e-gommerce/internal/storage"
 )
 
+// shutdownTimeout is how long in-flight requests are given to complete when the server is stopped.
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	config := config.New()
 
+	if flag.Arg(0) == "migrate" {
+		err := migrate.Run(context.Background(), config.Migrator, flag.Args()[1:], os.Stdout)
+		config.Storage.Close()
+		if errors.Is(err, migrate.ErrUsage) {
+			fmt.Fprintln(os.Stderr, migrate.Usage)
+		}
+		if err != nil {
+			config.Logger.Error(err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+	if config.RequireCurrentSchema && config.Migrator != nil {
+		if err := config.Migrator.CheckCurrent(context.Background()); err != nil {
+			config.Storage.Close()
+			config.Logger.Error(err.Error())
+			os.Exit(1)
+		}
+	}
+
 	srv := web.NewServer("chi", *config)
-	defer srv.Storage().Close()
+	defer func() {
+		if cached, ok := srv.Storage().(*storage.Cached); ok {
+			stats := cached.Stats()
+			srv.Logger().Info("Product cache stats", "hits", stats.Hits, "misses", stats.Misses, "size", stats.Size)
+		}
+		// Closing the storage may persist it (eg. a memory snapshot), so errors are worth knowing about.
+		if err := srv.Storage().Close(); err != nil {
+			srv.Logger().Error("Failed to close storage", "close_error", err.Error())
+		}
+	}()
 
 	srv.MountHandlers()
 
@@ -21,8 +63,27 @@ func main() {
 		Handler:           srv.Mux(),
 	}
 
+	// Stop gracefully on interrupt so that the deferred cleanup runs.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			srv.Logger().Error("Failed to shut down server", "shutdown_error", err.Error())
+		}
+	}()
+
 	srv.Logger().Info("Starting server", "addr", config.Addr)
-	if err := httpServer.ListenAndServe(); err != nil {
+	err := httpServer.ListenAndServe()
+	if !errors.Is(err, http.ErrServerClosed) {
 		srv.Logger().Error(err.Error())
+		return
 	}
+	// Wait for in-flight requests to complete before the storage is closed.
+	<-shutdownDone
+	srv.Logger().Info("Server stopped")
 }
@@ -0,0 +1,2 @@
+DROP INDEX products_deleted_at ON products;
+ALTER TABLE products DROP COLUMN deleted_at;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS product_history (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    product_id INT NOT NULL,
+    operation VARCHAR(16) NOT NULL,
+    before_state TEXT,
+    after_state TEXT,
+    changed_at DATETIME(6) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    request_id VARCHAR(255) NOT NULL,
+    INDEX product_history_product (product_id, changed_at)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS product_prices (
+    product_id INT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    PRIMARY KEY (product_id, currency),
+    FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    currency CHAR(3) PRIMARY KEY,
+    rate DECIMAL(18, 8) NOT NULL
+);
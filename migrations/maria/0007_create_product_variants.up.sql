@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS product_variants (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    product_id INT NOT NULL,
+    sku VARCHAR(64) NOT NULL,
+    options TEXT NOT NULL,
+    price DECIMAL(10, 2),
+    stock_quantity INT NOT NULL,
+    UNIQUE INDEX product_variants_sku (sku),
+    FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS product_tags (
+    product_id INT NOT NULL,
+    tag VARCHAR(64) NOT NULL,
+    PRIMARY KEY (product_id, tag),
+    INDEX product_tags_tag (tag),
+    FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS product_attributes (
+    product_id INT NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    type VARCHAR(16) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    PRIMARY KEY (product_id, name),
+    INDEX product_attributes_name_value (name, value),
+    FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+DROP INDEX products_slug ON products;
+DROP INDEX products_sku ON products;
+ALTER TABLE products DROP COLUMN slug;
+ALTER TABLE products DROP COLUMN sku;
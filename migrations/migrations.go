@@ -0,0 +1,32 @@
+// Package migrations holds the numbered SQL migrations that set up the database schema.
+//
+// Each storage engine has its own directory of migrations, since their SQL dialects differ.
+// A migration is a pair of files named "<version>_<name>.up.sql" and "<version>_<name>.down.sql",
+// where version is a positive number. The down migration must revert everything the up migration does.
+// Statements are separated by a semicolon at the end of a line.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed maria/*.sql sqlite/*.sql
+var files embed.FS
+
+// Maria returns the migrations of the MariaDB schema.
+func Maria() fs.FS {
+	return sub("maria")
+}
+
+// SQLite returns the migrations of the SQLite schema.
+func SQLite() fs.FS {
+	return sub("sqlite")
+}
+
+// sub returns the embedded directory dir.
+func sub(dir string) fs.FS {
+	// fs.Sub only fails if dir is not a valid path, and the embedded directories are.
+	f, _ := fs.Sub(files, dir)
+	return f
+}
@@ -1,8 +1,5 @@
-CREATE DATABASE main;
-USE main;
-
 CREATE TABLE products (
-    id INT AUTO_INCREMENT PRIMARY KEY,
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
     name VARCHAR(255) NOT NULL,
     description TEXT,
     price DECIMAL(10, 2) NOT NULL,
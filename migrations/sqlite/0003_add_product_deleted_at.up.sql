@@ -0,0 +1,2 @@
+ALTER TABLE products ADD COLUMN deleted_at DATETIME NULL;
+CREATE INDEX products_deleted_at ON products (deleted_at);
@@ -0,0 +1,11 @@
+CREATE TABLE product_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INT NOT NULL,
+    operation VARCHAR(16) NOT NULL,
+    before_state TEXT,
+    after_state TEXT,
+    changed_at DATETIME NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    request_id VARCHAR(255) NOT NULL
+);
+CREATE INDEX product_history_product ON product_history (product_id, changed_at);
@@ -0,0 +1,9 @@
+CREATE TABLE reservations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    quantity INT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL
+);
+CREATE INDEX reservations_expiry ON reservations (product_id, status, expires_at);
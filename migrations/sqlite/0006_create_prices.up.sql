@@ -0,0 +1,11 @@
+CREATE TABLE product_prices (
+    product_id INT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    currency CHAR(3) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    PRIMARY KEY (product_id, currency)
+);
+
+CREATE TABLE exchange_rates (
+    currency CHAR(3) PRIMARY KEY,
+    rate DECIMAL(18, 8) NOT NULL
+);
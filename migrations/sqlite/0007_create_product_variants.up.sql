@@ -0,0 +1,10 @@
+CREATE TABLE product_variants (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    sku VARCHAR(64) NOT NULL,
+    options TEXT NOT NULL,
+    price DECIMAL(10, 2),
+    stock_quantity INT NOT NULL
+);
+CREATE UNIQUE INDEX product_variants_sku ON product_variants (sku);
+CREATE INDEX product_variants_product ON product_variants (product_id);
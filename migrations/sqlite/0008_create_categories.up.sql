@@ -0,0 +1,16 @@
+CREATE TABLE categories (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    parent_id INT REFERENCES categories (id),
+    name VARCHAR(255) NOT NULL,
+    slug VARCHAR(255) NOT NULL,
+    position INT NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX categories_slug ON categories (slug);
+CREATE INDEX categories_parent ON categories (parent_id);
+
+CREATE TABLE product_categories (
+    product_id INT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    category_id INT NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    PRIMARY KEY (product_id, category_id)
+);
+CREATE INDEX product_categories_category ON product_categories (category_id);
@@ -0,0 +1,10 @@
+CREATE TABLE product_images (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    blob_key VARCHAR(255) NOT NULL,
+    content_type VARCHAR(64) NOT NULL,
+    size BIGINT NOT NULL,
+    checksum CHAR(64) NOT NULL,
+    created_at DATETIME NOT NULL
+);
+CREATE INDEX product_images_product ON product_images (product_id);
@@ -0,0 +1,15 @@
+CREATE TABLE product_tags (
+    product_id INT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    tag VARCHAR(64) NOT NULL,
+    PRIMARY KEY (product_id, tag)
+);
+CREATE INDEX product_tags_tag ON product_tags (tag);
+
+CREATE TABLE product_attributes (
+    product_id INT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL,
+    type VARCHAR(16) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    PRIMARY KEY (product_id, name)
+);
+CREATE INDEX product_attributes_name_value ON product_attributes (name, value);
@@ -0,0 +1,4 @@
+DROP INDEX products_slug;
+DROP INDEX products_sku;
+ALTER TABLE products DROP COLUMN slug;
+ALTER TABLE products DROP COLUMN sku;
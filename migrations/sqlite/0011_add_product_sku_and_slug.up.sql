@@ -0,0 +1,6 @@
+ALTER TABLE products ADD COLUMN sku VARCHAR(64) NULL;
+ALTER TABLE products ADD COLUMN slug VARCHAR(255) NOT NULL DEFAULT '';
+-- Existing products are given slugs that cannot collide, since their names may not be unique.
+UPDATE products SET slug = 'product-' || id;
+CREATE UNIQUE INDEX products_sku ON products (sku);
+CREATE UNIQUE INDEX products_slug ON products (slug);
@@ -1 +0,0 @@
-DROP DATABASE main;